@@ -1,39 +1,265 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io/fs"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/cache"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog/google"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog/openlibrary"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/config"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/db"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/metrics"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/purge"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience"
 	server "github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/health"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service/cached"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service/traced"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/storage/postgres"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/tracing"
 )
 
 func main() {
-	// Load .env
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	// Load .env if there is one; variables already set in the environment win
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
 	}
 
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		log.Fatal("DATABASE_URL not set in environment")
+	cfg, args, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
+	// `app config print` shows the effective configuration with secrets redacted
+	if len(args) > 0 && args[0] == "config" {
+		if len(args) != 2 || args[1] != "print" {
+			log.Fatal("usage: app config print")
+		}
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("config: %v", err)
+		}
+		if err := cfg.Validate(); err != nil {
+			log.Fatalf("Invalid configuration:\n%v", err)
+		}
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	logger, logLevels, err := newLogger(cfg.Log)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	// Route the standard library logger and slog's default through it too
+	slog.SetDefault(logger)
+
+	// Tracing must be set up before anything creates a tracer
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	}, os.Stdout)
+	if err != nil {
+		fatal(logger, "unable to set up tracing", err)
+	}
+	defer flushTraces(logger, shutdownTracing)
+
 	// Connect to DB
-	dbPool := db.NewPostgresConnection(dsn)
+	dbPool, err := db.NewPostgresConnection(cfg.Database.URL, postgres.NewQueryTracer())
+	if err != nil {
+		fatal(logger, "database unavailable", err)
+	}
 	defer dbPool.Close()
+	logger.Info("connected to the database")
+
+	// `app migrate up|down|status` runs the migrations and exits
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(context.Background(), newMigrator(dbPool), args[1:]); err != nil {
+			dbPool.Close()
+			fatal(logger, "migrate failed", err)
+		}
+		return
+	}
+
+	// Apply pending migrations before serving when database.migrate_on_start is set
+	if cfg.Database.MigrateOnStart {
+		applied, err := newMigrator(dbPool).Up(context.Background(), 0)
+		if err != nil {
+			dbPool.Close()
+			fatal(logger, "unable to apply migrations", err)
+		}
+		logger.Info("applied pending migrations", "count", len(applied))
+	}
+
+	// Metrics cover HTTP requests, repository queries, the pool and Google Books
+	appMetrics := metrics.New()
+	appMetrics.RegisterPool(dbPool)
 
 	// Initialize Repositories
-	repo := postgres.NewRepository(dbPool)
+	repo := postgres.NewRepository(postgres.Instrument(dbPool, appMetrics), logger)
 
 	// Initialize Services
-	authorService := domain.NewAuthorService(repo.Author, repo.Book)
-	bookService := domain.NewBookService(repo.Book)
+	authorService := domain.NewAuthorService(repo.Author, repo.Book, logger)
+	googleClient := google.New(google.Config{
+		BaseURL:    cfg.Google.BaseURL,
+		APIKey:     cfg.Google.APIKey,
+		Timeout:    cfg.Google.Timeout,
+		Transport:  appMetrics.InstrumentTransport("google_books", nil),
+		Resilience: googleResilience(cfg.Google, logger),
+	})
+	openLibraryClient := openlibrary.New(openlibrary.Config{
+		BaseURL:   cfg.OpenLibrary.BaseURL,
+		Timeout:   cfg.OpenLibrary.Timeout,
+		Transport: appMetrics.InstrumentTransport("open_library", nil),
+	})
+
+	// External search queries the configured catalogs in their listed order
+	providers := map[string]catalog.Provider{
+		google.ProviderName:      googleClient,
+		openlibrary.ProviderName: openLibraryClient,
+	}
+	var catalogProviders []catalog.Provider
+	for _, name := range cfg.Catalog.ProviderNames() {
+		catalogProviders = append(catalogProviders, providers[name])
+	}
+	bookCatalog := catalog.New(logger, catalogProviders...)
+
+	bookService := domain.NewBookService(repo.Book, repo.Author, repo.Genre, googleClient, bookCatalog, logger)
+
+	// External searches are cached unless the cache is disabled
+	var books service.BookService = bookService
+	if cfg.Cache.Backend == "memory" {
+		books = cached.NewBookService(bookService, cache.NewMemory(cfg.Cache.MaxEntries), cache.Options{
+			TTL:                  cfg.Cache.TTL,
+			StaleWhileRevalidate: cfg.Cache.StaleWhileRevalidate,
+			LoadTimeout:          cfg.Cache.LoadTimeout,
+			Observer:             appMetrics,
+			Logger:               logger,
+		})
+	}
+
+	// Readiness pings the database and, optionally, Google Books
+	checks := []health.Check{
+		{Name: "postgres", Timeout: cfg.Health.CheckTimeout, Fn: dbPool.Ping},
+	}
+	if cfg.Health.CheckGoogle {
+		checks = append(checks, health.Check{Name: "google_books", Timeout: cfg.Health.CheckTimeout, Fn: googleClient.Check})
+	}
+	healthH := health.NewHandler(checks...)
+
+	// Stop serving on SIGINT/SIGTERM; StartServer drains in-flight requests
+	// before returning so the deferred dbPool.Close() runs afterwards.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Deleted books and authors are purged once kept for the retention,
+	// books first since they may reference authors
+	if cfg.Purge.Retention > 0 {
+		go purge.New(cfg.Purge.Retention, cfg.Purge.Interval, logger,
+			purge.Target{Name: "books", Purger: repo.Book},
+			purge.Target{Name: "authors", Purger: repo.Author},
+		).Run(ctx)
+	}
 
 	// Start HTTP Server
-	server.StartServer(authorService, bookService)
+	serverConfig := server.Config{
+		Addr:            cfg.Server.Addr,
+		ReadTimeout:     cfg.Server.ReadTimeout,
+		WriteTimeout:    cfg.Server.WriteTimeout,
+		IdleTimeout:     cfg.Server.IdleTimeout,
+		MaxHeaderBytes:  cfg.Server.MaxHeaderBytes,
+		ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		DrainDelay:      cfg.Server.DrainDelay,
+	}
+	deps := server.Dependencies{
+		AuthorService:  traced.NewAuthorService(authorService),
+		BookService:    traced.NewBookService(books),
+		GenreService:   traced.NewGenreService(domain.NewGenreService(repo.Genre, logger)),
+		TagService:     traced.NewTagService(domain.NewTagService(repo.Tag, logger)),
+		Health:         healthH,
+		Metrics:        appMetrics,
+		Logger:         logger,
+		LogLevels:      logLevels,
+		AdminToken:     cfg.Admin.Token,
+		IdentityHeader: cfg.Auth.IdentityHeader,
+	}
+	if err := server.StartServer(ctx, serverConfig, deps); err != nil {
+		stop()
+		dbPool.Close()
+		fatal(logger, "HTTP server failed", err)
+	}
+}
+
+// newLogger builds the application logger and its runtime-adjustable
+// levels from the log settings.
+func newLogger(cfg config.LogConfig) (*slog.Logger, *logging.Levels, error) {
+	fallback, err := logging.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	components, err := logging.ParseLevels(cfg.Levels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	levels := logging.NewLevels(fallback)
+	for name, level := range components {
+		levels.Set(name, level)
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.Format, levels)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logger, levels, nil
+}
+
+// flushTraces exports the spans still buffered when the server stops.
+func flushTraces(logger *slog.Logger, shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		logger.Warn("unable to flush traces", "err", err)
+	}
+}
+
+// googleResilience builds the retry, circuit breaker and rate limit
+// settings of the Google Books client.
+func googleResilience(cfg config.GoogleConfig, logger *slog.Logger) resilience.Config {
+	rc := resilience.Config{
+		MaxRetries: cfg.MaxRetries,
+		BaseDelay:  cfg.RetryBaseDelay,
+		MaxDelay:   cfg.RetryMaxDelay,
+	}
+	if cfg.BreakerThreshold > 0 {
+		rc.Breaker = resilience.NewBreaker(cfg.BreakerThreshold, cfg.BreakerOpenTimeout, nil, func(from, to resilience.State) {
+			logger.Warn("google books circuit breaker changed state", "from", from, "to", to)
+		})
+	}
+	if cfg.RateLimit > 0 {
+		rc.Limiter = resilience.NewLimiter(cfg.RateLimit, cfg.RateBurst, nil)
+	}
+	return rc
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "err", err)
+	os.Exit(1)
 }
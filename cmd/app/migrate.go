@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/db"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/db/migrations"
+)
+
+const migrateUsage = "usage: app migrate up [n] | down [n] | status"
+
+// runMigrate implements the `migrate up|down|status` subcommand.
+func runMigrate(ctx context.Context, migrator *db.Migrator, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	n := 0
+	if len(args) > 1 {
+		parsed, err := strconv.Atoi(args[1])
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("invalid migration count %q: %s", args[1], migrateUsage)
+		}
+		n = parsed
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx, n)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		rolledBack, err := migrator.Down(ctx, n)
+		for _, m := range rolledBack {
+			fmt.Printf("rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(tw, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q: %s", args[0], migrateUsage)
+	}
+
+	return nil
+}
+
+func newMigrator(pool db.MigrationDB) *db.Migrator {
+	migrator, err := db.NewMigrator(pool, migrations.FS)
+	if err != nil {
+		log.Fatalf("Unable to load migrations: %v", err)
+	}
+	return migrator
+}
@@ -0,0 +1,134 @@
+// Package apperr defines the error kinds shared by the storage, service and
+// HTTP layers. Callers classify errors with errors.Is against the sentinel
+// kinds and never by matching error strings.
+package apperr
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound means the requested entity does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the request conflicts with the current state, e.g.
+	// a duplicate key or a delete blocked by dependent rows.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation means the caller supplied invalid input.
+	ErrValidation = errors.New("validation failed")
+	// ErrUnavailable means a dependency (database, upstream API) could not
+	// be reached. Retrying later may succeed.
+	ErrUnavailable = errors.New("service unavailable")
+	// ErrRateLimited means a rate limit, ours or an upstream API's, was
+	// exceeded. Retrying after a while may succeed.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// Error is an error of a known kind with a message that is safe to show to
+// API clients. The underlying cause is kept for logging and errors.Is/As.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+	// RetryAfter, if positive, is how long the caller should wait before
+	// retrying an ErrUnavailable or ErrRateLimited error.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Is reports whether target is the error's kind, so that
+// errors.Is(err, apperr.ErrNotFound) works through any wrapping.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NotFound returns an ErrNotFound error with the given message.
+func NotFound(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+// Conflict returns an ErrConflict error with the given message and cause.
+func Conflict(message string, cause error) error {
+	return &Error{Kind: ErrConflict, Message: message, Err: cause}
+}
+
+// Validation returns an ErrValidation error with the given message and cause.
+func Validation(message string, cause error) error {
+	return &Error{Kind: ErrValidation, Message: message, Err: cause}
+}
+
+// Unavailable returns an ErrUnavailable error with the given message and cause.
+func Unavailable(message string, cause error) error {
+	return &Error{Kind: ErrUnavailable, Message: message, Err: cause}
+}
+
+// RateLimited returns an ErrRateLimited error with the given message, retry
+// hint and cause.
+func RateLimited(message string, retryAfter time.Duration, cause error) error {
+	return &Error{Kind: ErrRateLimited, Message: message, Err: cause, RetryAfter: retryAfter}
+}
+
+// RetryAfter returns the retry hint of the first *Error in err's chain, or
+// zero if there is none.
+func RetryAfter(err error) time.Duration {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.RetryAfter
+	}
+	return 0
+}
+
+// Message returns the client-safe message of the first *Error in err's
+// chain, or "" if there is none.
+func Message(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	return ""
+}
+
+// FieldError describes why a single input field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationError is an ErrValidation error listing every rejected field.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Is makes errors.Is(err, apperr.ErrValidation) true for field errors.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Fields returns the field errors carried by err, if any.
+func Fields(err error) []FieldError {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Fields
+	}
+	return nil
+}
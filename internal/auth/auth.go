@@ -0,0 +1,47 @@
+// Package auth tells whether a request is made by an admin, and who makes
+// it. Admins are identified by the admin token of the configuration, which
+// the HTTP server checks before marking the request context with
+// WithAdmin; the caller named by the configured identity header is set
+// with WithActor.
+package auth
+
+import "context"
+
+// Actors recorded in the audit log when the context names none.
+const (
+	ActorAdmin     = "admin"
+	ActorAnonymous = "anonymous"
+)
+
+type adminKey struct{}
+
+type actorKey struct{}
+
+// WithAdmin returns a context marking the request as made by an admin.
+func WithAdmin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminKey{}, true)
+}
+
+// IsAdmin reports whether ctx belongs to a request made by an admin.
+func IsAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey{}).(bool)
+	return admin
+}
+
+// WithActor returns a context whose writes are made by actor: the caller
+// a request names, or a background job.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor names who makes the writes of ctx: the actor of WithActor,
+// ActorAdmin for admins, or ActorAnonymous.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok {
+		return actor
+	}
+	if IsAdmin(ctx) {
+		return ActorAdmin
+	}
+	return ActorAnonymous
+}
@@ -0,0 +1,19 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+)
+
+func TestActor(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Equal(t, auth.ActorAnonymous, auth.Actor(ctx))
+	assert.Equal(t, auth.ActorAdmin, auth.Actor(auth.WithAdmin(ctx)))
+	// A named actor wins over the admin marking
+	assert.Equal(t, "purge", auth.Actor(auth.WithActor(auth.WithAdmin(ctx), "purge")))
+}
@@ -0,0 +1,199 @@
+// Package cache is a read-through cache for slow or rate-limited lookups
+// such as upstream catalog searches. Values are stored JSON-encoded in a
+// pluggable Backend; identical concurrent loads are coalesced and entries
+// may be served stale while they are refreshed in the background.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+)
+
+// Results reported to an Observer.
+const (
+	// ResultHit is a fresh entry served from the backend.
+	ResultHit = "hit"
+	// ResultStale is an expired entry served while it is refreshed.
+	ResultStale = "stale"
+	// ResultMiss is a lookup that had to wait for the loader.
+	ResultMiss = "miss"
+)
+
+// DefaultLoadTimeout bounds the loads of a cache without a LoadTimeout.
+const DefaultLoadTimeout = 30 * time.Second
+
+// Entry is a cached value and the time it was loaded.
+type Entry struct {
+	Value    []byte
+	StoredAt time.Time
+}
+
+// Backend stores entries. Implementations must be safe for concurrent use.
+type Backend interface {
+	// Get returns the entry stored under key, if any.
+	Get(ctx context.Context, key string) (Entry, bool, error)
+	// Set stores entry under key. The backend may drop it after ttl.
+	Set(ctx context.Context, key string, entry Entry, ttl time.Duration) error
+}
+
+// Observer receives the result of every lookup.
+type Observer interface {
+	ObserveCache(cache, result string)
+}
+
+// Options configures a Cache.
+type Options struct {
+	// Name labels the cache's lookups for the Observer and in logs.
+	Name string
+	// TTL is how long an entry is served without reloading it.
+	TTL time.Duration
+	// StaleWhileRevalidate is how long past its TTL an entry is still
+	// served while a background load refreshes it; zero disables it.
+	StaleWhileRevalidate time.Duration
+	// LoadTimeout bounds each load. Loads run detached from the callers
+	// waiting for them, so that one giving up does not fail the others;
+	// zero means DefaultLoadTimeout.
+	LoadTimeout time.Duration
+	// Observer, if set, counts hits, stale hits and misses.
+	Observer Observer
+	Logger   *slog.Logger
+}
+
+// Cache caches values of type T.
+type Cache[T any] struct {
+	backend Backend
+	opts    Options
+	logger  *slog.Logger
+	group   singleflight.Group
+	now     func() time.Time
+	storeIf func(T) bool
+
+	// refreshes tracks background loads so tests can wait for them.
+	refreshes sync.WaitGroup
+}
+
+// New returns a cache storing its entries in backend.
+func New[T any](backend Backend, opts Options) *Cache[T] {
+	return &Cache[T]{
+		backend: backend,
+		opts:    opts,
+		logger:  logging.Component(opts.Logger, "cache").With("cache", opts.Name),
+		now:     time.Now,
+	}
+}
+
+// StoreIf makes the cache store only the loaded values for which keep
+// returns true. Other values are still returned to every caller waiting
+// for the load. It returns c for chaining.
+func (c *Cache[T]) StoreIf(keep func(T) bool) *Cache[T] {
+	c.storeIf = keep
+	return c
+}
+
+// Get returns the value cached under key or, when there is none or it has
+// expired, the value returned by load, which is then cached. Concurrent
+// calls for the same key share a single load. Errors are not cached. A
+// caller whose ctx ends stops waiting, but the load goes on for the others.
+func (c *Cache[T]) Get(ctx context.Context, key string, load func(context.Context) (T, error)) (T, error) {
+	if value, age, ok := c.lookup(ctx, key); ok {
+		switch {
+		case age < c.opts.TTL:
+			c.observe(ResultHit)
+			return value, nil
+		case age < c.opts.TTL+c.opts.StaleWhileRevalidate:
+			c.observe(ResultStale)
+			c.refresh(ctx, key, load)
+			return value, nil
+		}
+	}
+
+	c.observe(ResultMiss)
+	var zero T
+	loaded := c.group.DoChan(key, func() (any, error) {
+		return c.load(ctx, key, load)
+	})
+	select {
+	case result := <-loaded:
+		if result.Err != nil {
+			return zero, result.Err
+		}
+		return result.Val.(T), nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// lookup returns the decoded entry under key and its age. Backend and
+// decoding errors are logged and treated as misses.
+func (c *Cache[T]) lookup(ctx context.Context, key string) (T, time.Duration, bool) {
+	var value T
+	entry, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		c.logger.WarnContext(ctx, "cache read failed", "key", key, "err", err)
+		return value, 0, false
+	}
+	if !ok {
+		return value, 0, false
+	}
+	if err := json.Unmarshal(entry.Value, &value); err != nil {
+		c.logger.WarnContext(ctx, "cache entry is corrupt", "key", key, "err", err)
+		return value, 0, false
+	}
+	return value, c.now().Sub(entry.StoredAt), true
+}
+
+// load calls load and stores its result, unless StoreIf rejects it. A
+// failure to store is logged but does not fail the lookup. load runs on
+// the values of ctx but not its cancellation, within the load timeout.
+func (c *Cache[T]) load(ctx context.Context, key string, load func(context.Context) (T, error)) (T, error) {
+	timeout := c.opts.LoadTimeout
+	if timeout <= 0 {
+		timeout = DefaultLoadTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	value, err := load(ctx)
+	if err != nil || (c.storeIf != nil && !c.storeIf(value)) {
+		return value, err
+	}
+
+	data, err := json.Marshal(value)
+	if err == nil {
+		entry := Entry{Value: data, StoredAt: c.now()}
+		err = c.backend.Set(ctx, key, entry, c.opts.TTL+c.opts.StaleWhileRevalidate)
+	}
+	if err != nil {
+		c.logger.WarnContext(ctx, "cache write failed", "key", key, "err", err)
+	}
+	return value, nil
+}
+
+// refresh reloads key in the background unless a load for it is already
+// running. The load outlives the request that triggered it.
+func (c *Cache[T]) refresh(ctx context.Context, key string, load func(context.Context) (T, error)) {
+	ctx = context.WithoutCancel(ctx)
+	c.refreshes.Add(1)
+	go func() {
+		defer c.refreshes.Done()
+		_, err, _ := c.group.Do(key, func() (any, error) {
+			return c.load(ctx, key, load)
+		})
+		if err != nil {
+			c.logger.WarnContext(ctx, "cache refresh failed", "key", key, "err", err)
+		}
+	}()
+}
+
+func (c *Cache[T]) observe(result string) {
+	if c.opts.Observer != nil {
+		c.opts.Observer.ObserveCache(c.opts.Name, result)
+	}
+}
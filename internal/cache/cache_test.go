@@ -0,0 +1,300 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a settable time source shared by a cache and its backend.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// countingObserver records lookups by result.
+type countingObserver struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (o *countingObserver) ObserveCache(cache, result string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.counts == nil {
+		o.counts = make(map[string]int)
+	}
+	o.counts[cache+"/"+result]++
+}
+
+func newTestCache(opts Options) (*Cache[string], *Memory, *clock, *countingObserver) {
+	clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	backend := NewMemory(10)
+	backend.now = clk.Now
+	observer := &countingObserver{}
+	opts.Name = "test"
+	opts.Observer = observer
+	opts.Logger = slog.New(slog.DiscardHandler)
+	c := New[string](backend, opts)
+	c.now = clk.Now
+	return c, backend, clk, observer
+}
+
+// loader returns a load function answering "<prefix>-<n>" for the nth
+// call, and the call counter.
+func loader(prefix string) (func(context.Context) (string, error), *atomic.Int32) {
+	var calls atomic.Int32
+	return func(context.Context) (string, error) {
+		n := calls.Add(1)
+		return prefix + "-" + string(rune('0'+n)), nil
+	}, &calls
+}
+
+func TestCache_Get(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("serves fresh entries until the TTL passes", func(t *testing.T) {
+		c, _, clk, observer := newTestCache(Options{TTL: time.Minute})
+		load, calls := loader("v")
+
+		v, err := c.Get(ctx, "k", load)
+		require.NoError(t, err)
+		assert.Equal(t, "v-1", v)
+
+		clk.Advance(59 * time.Second)
+		v, _ = c.Get(ctx, "k", load)
+		assert.Equal(t, "v-1", v)
+
+		clk.Advance(time.Second)
+		v, _ = c.Get(ctx, "k", load)
+		assert.Equal(t, "v-2", v)
+
+		assert.EqualValues(t, 2, calls.Load())
+		assert.Equal(t, map[string]int{"test/miss": 2, "test/hit": 1}, observer.counts)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		c, backend, _, _ := newTestCache(Options{TTL: time.Minute})
+		boom := errors.New("boom")
+
+		_, err := c.Get(ctx, "k", func(context.Context) (string, error) { return "", boom })
+		assert.ErrorIs(t, err, boom)
+		assert.Zero(t, backend.Len())
+
+		v, err := c.Get(ctx, "k", func(context.Context) (string, error) { return "ok", nil })
+		require.NoError(t, err)
+		assert.Equal(t, "ok", v)
+	})
+
+	t.Run("coalesces concurrent loads of a key", func(t *testing.T) {
+		c, _, _, _ := newTestCache(Options{TTL: time.Minute})
+		release := make(chan struct{})
+		var calls atomic.Int32
+		load := func(context.Context) (string, error) {
+			calls.Add(1)
+			<-release
+			return "v", nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]string, 5)
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i], _ = c.Get(ctx, "k", load)
+			}()
+		}
+		// Let every goroutine reach the shared load before it returns.
+		require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.EqualValues(t, 1, calls.Load())
+		assert.Equal(t, []string{"v", "v", "v", "v", "v"}, results)
+	})
+
+	t.Run("keeps loading for the others when the first caller gives up", func(t *testing.T) {
+		c, _, _, _ := newTestCache(Options{TTL: time.Minute})
+		started, release := make(chan struct{}), make(chan struct{})
+		var calls atomic.Int32
+		load := func(ctx context.Context) (string, error) {
+			calls.Add(1)
+			close(started)
+			<-release
+			// The load must not see the first caller's cancellation
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			return "v", nil
+		}
+
+		firstCtx, cancel := context.WithCancel(ctx)
+		firstErr := make(chan error)
+		go func() {
+			_, err := c.Get(firstCtx, "k", load)
+			firstErr <- err
+		}()
+		<-started
+		second := make(chan string)
+		go func() {
+			v, _ := c.Get(ctx, "k", load)
+			second <- v
+		}()
+		// Let the second caller join the load before the first gives up.
+		time.Sleep(10 * time.Millisecond)
+
+		cancel()
+		assert.ErrorIs(t, <-firstErr, context.Canceled)
+		close(release)
+
+		assert.Equal(t, "v", <-second)
+		assert.EqualValues(t, 1, calls.Load())
+		v, err := c.Get(ctx, "k", load)
+		require.NoError(t, err)
+		assert.Equal(t, "v", v)
+	})
+
+	t.Run("times out loads after the load timeout", func(t *testing.T) {
+		c, _, _, _ := newTestCache(Options{TTL: time.Minute, LoadTimeout: 10 * time.Millisecond})
+
+		_, err := c.Get(ctx, "k", func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		})
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("serves stale entries while refreshing them", func(t *testing.T) {
+		c, _, clk, observer := newTestCache(Options{TTL: time.Minute, StaleWhileRevalidate: time.Minute})
+		load, calls := loader("v")
+
+		_, err := c.Get(ctx, "k", load)
+		require.NoError(t, err)
+
+		clk.Advance(90 * time.Second)
+		v, err := c.Get(ctx, "k", load)
+		require.NoError(t, err)
+		assert.Equal(t, "v-1", v)
+		c.refreshes.Wait()
+
+		v, _ = c.Get(ctx, "k", load)
+		assert.Equal(t, "v-2", v)
+		assert.EqualValues(t, 2, calls.Load())
+		assert.Equal(t, map[string]int{"test/miss": 1, "test/stale": 1, "test/hit": 1}, observer.counts)
+	})
+
+	t.Run("keeps the stale entry when a refresh fails", func(t *testing.T) {
+		c, _, clk, _ := newTestCache(Options{TTL: time.Minute, StaleWhileRevalidate: time.Minute})
+		_, err := c.Get(ctx, "k", func(context.Context) (string, error) { return "old", nil })
+		require.NoError(t, err)
+
+		clk.Advance(90 * time.Second)
+		failing := func(context.Context) (string, error) { return "", errors.New("upstream down") }
+		v, err := c.Get(ctx, "k", failing)
+		c.refreshes.Wait()
+
+		require.NoError(t, err)
+		assert.Equal(t, "old", v)
+		v, _ = c.Get(ctx, "k", failing)
+		assert.Equal(t, "old", v)
+	})
+
+	t.Run("reloads once the stale window has passed", func(t *testing.T) {
+		c, _, clk, _ := newTestCache(Options{TTL: time.Minute, StaleWhileRevalidate: time.Minute})
+		load, calls := loader("v")
+
+		_, _ = c.Get(ctx, "k", load)
+		clk.Advance(2 * time.Minute)
+		v, _ := c.Get(ctx, "k", load)
+
+		assert.Equal(t, "v-2", v)
+		assert.EqualValues(t, 2, calls.Load())
+	})
+}
+
+func TestCache_StoreIf(t *testing.T) {
+	c, backend, _, _ := newTestCache(Options{TTL: time.Minute})
+	c.StoreIf(func(v string) bool { return v != "partial" })
+
+	v, err := c.Get(context.Background(), "k", func(context.Context) (string, error) { return "partial", nil })
+
+	require.NoError(t, err)
+	assert.Equal(t, "partial", v)
+	assert.Zero(t, backend.Len())
+}
+
+// failingBackend fails every operation.
+type failingBackend struct{}
+
+func (failingBackend) Get(context.Context, string) (Entry, bool, error) {
+	return Entry{}, false, errors.New("backend down")
+}
+
+func (failingBackend) Set(context.Context, string, Entry, time.Duration) error {
+	return errors.New("backend down")
+}
+
+func TestCache_BackendErrorsFallBackToTheLoader(t *testing.T) {
+	c := New[string](failingBackend{}, Options{TTL: time.Minute, Logger: slog.New(slog.DiscardHandler)})
+
+	v, err := c.Get(context.Background(), "k", func(context.Context) (string, error) { return "v", nil })
+
+	require.NoError(t, err)
+	assert.Equal(t, "v", v)
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(2)
+
+	require.NoError(t, m.Set(ctx, "a", Entry{Value: []byte("1")}, time.Minute))
+	require.NoError(t, m.Set(ctx, "b", Entry{Value: []byte("2")}, time.Minute))
+	_, ok, _ := m.Get(ctx, "a") // a is now more recently used than b
+	require.True(t, ok)
+	require.NoError(t, m.Set(ctx, "c", Entry{Value: []byte("3")}, time.Minute))
+
+	_, ok, _ = m.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok, _ = m.Get(ctx, "a")
+	assert.True(t, ok)
+	_, ok, _ = m.Get(ctx, "c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, m.Len())
+}
+
+func TestMemory_DropsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := NewMemory(0)
+	m.now = clk.Now
+
+	require.NoError(t, m.Set(ctx, "a", Entry{Value: []byte("1")}, time.Minute))
+	clk.Advance(time.Minute)
+
+	_, ok, err := m.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Zero(t, m.Len())
+}
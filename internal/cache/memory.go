@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process Backend holding at most a fixed number of
+// entries. When full, it evicts the least recently used entry.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	// order holds *memoryItem, most recently used first.
+	order *list.List
+	now   func() time.Time
+}
+
+type memoryItem struct {
+	key       string
+	entry     Entry
+	expiresAt time.Time
+}
+
+var _ Backend = (*Memory)(nil)
+
+// NewMemory returns an empty in-memory backend bounded to maxEntries.
+func NewMemory(maxEntries int) *Memory {
+	return &Memory{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+	}
+}
+
+// Get implements Backend. Expired entries are dropped on access.
+func (m *Memory) Get(ctx context.Context, key string) (Entry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.items[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	item := el.Value.(*memoryItem)
+	if !m.now().Before(item.expiresAt) {
+		m.remove(el)
+		return Entry{}, false, nil
+	}
+	m.order.MoveToFront(el)
+	return item.entry, true, nil
+}
+
+// Set implements Backend.
+func (m *Memory) Set(ctx context.Context, key string, entry Entry, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expiresAt := m.now().Add(ttl)
+	if el, ok := m.items[key]; ok {
+		item := el.Value.(*memoryItem)
+		item.entry, item.expiresAt = entry, expiresAt
+		m.order.MoveToFront(el)
+		return nil
+	}
+
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, entry: entry, expiresAt: expiresAt})
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+	return nil
+}
+
+// Len returns the number of entries held, including expired ones not yet
+// dropped.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+func (m *Memory) remove(el *list.Element) {
+	m.order.Remove(el)
+	delete(m.items, el.Value.(*memoryItem).key)
+}
@@ -0,0 +1,216 @@
+// Package catalog searches external book catalogs. Each catalog is a
+// Provider; a Catalog queries several of them in parallel and merges the
+// books they report by ISBN.
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/isbn"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+)
+
+// Provider is an external book catalog.
+type Provider interface {
+	// Name identifies the provider in configuration, the provider query
+	// parameter and ExternalBook.Sources.
+	Name() string
+	// SearchBooks returns the books matching a free-text query. The books
+	// carry a single source: the provider's own ID for them.
+	SearchBooks(ctx context.Context, query string) ([]entities.ExternalBook, error)
+}
+
+// Catalog queries a fixed set of providers.
+type Catalog struct {
+	providers []Provider
+	logger    *slog.Logger
+}
+
+// New returns a catalog over providers. Their order decides which
+// provider's title, authors and description win when books are merged.
+func New(logger *slog.Logger, providers ...Provider) *Catalog {
+	return &Catalog{providers: providers, logger: logging.Component(logger, "catalog")}
+}
+
+// Names returns the names of the configured providers in order.
+func (c *Catalog) Names() []string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// Search queries the named providers, or all of them when names is empty,
+// in parallel and merges their results. A provider that fails is reported
+// in the result's Errors; Search fails only when every provider does.
+func (c *Catalog) Search(ctx context.Context, query string, names []string) (*entities.ExternalSearchResult, error) {
+	providers, err := c.selectProviders(names)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]entities.ExternalBook, len(providers))
+	errs := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = p.SearchBooks(ctx, query)
+		}()
+	}
+	wg.Wait()
+
+	result := &entities.ExternalSearchResult{Items: []entities.ExternalBook{}}
+	var lastErr error
+	for i, p := range providers {
+		if errs[i] != nil {
+			c.logger.WarnContext(ctx, "catalog search failed", "provider", p.Name(), "err", errs[i])
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[p.Name()] = errorMessage(errs[i])
+			lastErr = errs[i]
+			continue
+		}
+		result.Items = Merge(result.Items, results[i])
+	}
+
+	if len(result.Errors) == len(providers) {
+		if len(providers) == 1 {
+			return nil, lastErr
+		}
+		return nil, apperr.Unavailable("no catalog provider answered", lastErr)
+	}
+	return result, nil
+}
+
+// selectProviders returns the providers named in names, in configuration
+// order, or all providers when names is empty.
+func (c *Catalog) selectProviders(names []string) ([]Provider, error) {
+	if len(names) == 0 {
+		if len(c.providers) == 0 {
+			return nil, apperr.Unavailable("no catalog provider is configured", nil)
+		}
+		return c.providers, nil
+	}
+
+	known := c.Names()
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			return nil, apperr.Validation(fmt.Sprintf("unknown catalog provider %q, expected one of: %s", name, strings.Join(known, ", ")), nil)
+		}
+	}
+
+	var selected []Provider
+	for _, p := range c.providers {
+		if slices.Contains(names, p.Name()) {
+			selected = append(selected, p)
+		}
+	}
+	return selected, nil
+}
+
+// errorMessage returns the client-safe message of err.
+func errorMessage(err error) string {
+	if msg := apperr.Message(err); msg != "" {
+		return msg
+	}
+	return "catalog search failed"
+}
+
+// Merge appends books to merged. A book sharing an ISBN with an entry
+// already in merged is folded into that entry: its sources and ISBNs are
+// added and it fills in fields the entry lacks. Books without ISBNs are
+// never merged.
+func Merge(merged, books []entities.ExternalBook) []entities.ExternalBook {
+	byISBN := make(map[string]int)
+	for i, b := range merged {
+		for _, n := range b.ISBNs {
+			byISBN[n] = i
+		}
+	}
+
+	for _, b := range books {
+		i, ok := -1, false
+		for _, n := range b.ISBNs {
+			if i, ok = byISBN[n]; ok {
+				break
+			}
+		}
+		if !ok {
+			merged = append(merged, clone(b))
+			i = len(merged) - 1
+		} else {
+			fold(&merged[i], b)
+		}
+		for _, n := range merged[i].ISBNs {
+			byISBN[n] = i
+		}
+	}
+	return merged
+}
+
+func clone(b entities.ExternalBook) entities.ExternalBook {
+	sources := make(map[string]string, len(b.Sources))
+	for k, v := range b.Sources {
+		sources[k] = v
+	}
+	b.Sources = sources
+	b.Authors = slices.Clone(b.Authors)
+	b.ISBNs = slices.Clone(b.ISBNs)
+	return b
+}
+
+// fold merges b into dst, keeping dst's fields where both are set.
+func fold(dst *entities.ExternalBook, b entities.ExternalBook) {
+	for k, v := range b.Sources {
+		if _, ok := dst.Sources[k]; !ok {
+			dst.Sources[k] = v
+		}
+	}
+	for _, n := range b.ISBNs {
+		if !slices.Contains(dst.ISBNs, n) {
+			dst.ISBNs = append(dst.ISBNs, n)
+		}
+	}
+	sort.Strings(dst.ISBNs)
+
+	if dst.Title == "" {
+		dst.Title = b.Title
+	}
+	if len(dst.Authors) == 0 {
+		dst.Authors = slices.Clone(b.Authors)
+	}
+	if dst.Description == "" {
+		dst.Description = b.Description
+	}
+	if len(b.PublishedDate) > len(dst.PublishedDate) && strings.HasPrefix(b.PublishedDate, dst.PublishedDate) {
+		// Prefer the more precise date when both agree on the year.
+		dst.PublishedDate = b.PublishedDate
+	}
+}
+
+// ISBNs normalizes ids to ISBN-13, dropping invalid ones and duplicates.
+// Providers use it to fill ExternalBook.ISBNs.
+func ISBNs(ids []string) []string {
+	var out []string
+	for _, id := range ids {
+		n, err := isbn.Normalize(id)
+		if err != nil || slices.Contains(out, n) {
+			continue
+		}
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
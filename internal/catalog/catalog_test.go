@@ -0,0 +1,140 @@
+package catalog_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+)
+
+// fakeProvider answers every search with books or err and counts calls.
+type fakeProvider struct {
+	name  string
+	books []entities.ExternalBook
+	err   error
+	calls atomic.Int32
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) SearchBooks(ctx context.Context, query string) ([]entities.ExternalBook, error) {
+	p.calls.Add(1)
+	return p.books, p.err
+}
+
+func book(source, id, title string, isbns ...string) entities.ExternalBook {
+	return entities.ExternalBook{Sources: map[string]string{source: id}, Title: title, ISBNs: isbns}
+}
+
+func TestCatalog_Search(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.DiscardHandler)
+
+	newProviders := func() (*fakeProvider, *fakeProvider) {
+		google := &fakeProvider{name: "google", books: []entities.ExternalBook{
+			book("google", "g1", "Dune", "9780441172719"),
+			book("google", "g2", "Untracked"),
+		}}
+		openLibrary := &fakeProvider{name: "openlibrary", books: []entities.ExternalBook{
+			{
+				Sources:       map[string]string{"openlibrary": "OL1W"},
+				Title:         "Dune (Ace)",
+				Authors:       []string{"Frank Herbert"},
+				PublishedDate: "1965",
+				ISBNs:         []string{"9780441172719", "9780593099322"},
+			},
+			book("openlibrary", "OL2W", "Untracked"),
+		}}
+		return google, openLibrary
+	}
+
+	t.Run("merges every provider by ISBN", func(t *testing.T) {
+		google, openLibrary := newProviders()
+		c := catalog.New(logger, google, openLibrary)
+
+		result, err := c.Search(ctx, "dune", nil)
+
+		require.NoError(t, err)
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, []entities.ExternalBook{
+			{
+				Sources:       map[string]string{"google": "g1", "openlibrary": "OL1W"},
+				Title:         "Dune",
+				Authors:       []string{"Frank Herbert"},
+				PublishedDate: "1965",
+				ISBNs:         []string{"9780441172719", "9780593099322"},
+			},
+			book("google", "g2", "Untracked"),
+			book("openlibrary", "OL2W", "Untracked"),
+		}, result.Items)
+	})
+
+	t.Run("queries only the requested providers", func(t *testing.T) {
+		google, openLibrary := newProviders()
+		c := catalog.New(logger, google, openLibrary)
+
+		result, err := c.Search(ctx, "dune", []string{"openlibrary"})
+
+		require.NoError(t, err)
+		assert.Len(t, result.Items, 2)
+		assert.Zero(t, google.calls.Load())
+		assert.EqualValues(t, 1, openLibrary.calls.Load())
+	})
+
+	t.Run("rejects unknown providers", func(t *testing.T) {
+		google, openLibrary := newProviders()
+		c := catalog.New(logger, google, openLibrary)
+
+		_, err := c.Search(ctx, "dune", []string{"amazon"})
+
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		assert.Zero(t, google.calls.Load()+openLibrary.calls.Load())
+	})
+
+	t.Run("reports a failing provider next to the others' results", func(t *testing.T) {
+		google, openLibrary := newProviders()
+		openLibrary.err = apperr.Unavailable("open library API request failed", errors.New("timeout"))
+		c := catalog.New(logger, google, openLibrary)
+
+		result, err := c.Search(ctx, "dune", nil)
+
+		require.NoError(t, err)
+		assert.Len(t, result.Items, 2)
+		assert.Equal(t, map[string]string{"openlibrary": "open library API request failed"}, result.Errors)
+	})
+
+	t.Run("fails when every provider fails", func(t *testing.T) {
+		google, openLibrary := newProviders()
+		google.err = apperr.Unavailable("google books API request failed", nil)
+		openLibrary.err = errors.New("boom")
+		c := catalog.New(logger, google, openLibrary)
+
+		_, err := c.Search(ctx, "dune", nil)
+
+		assert.ErrorIs(t, err, apperr.ErrUnavailable)
+	})
+
+	t.Run("returns the error of a single requested provider", func(t *testing.T) {
+		google, openLibrary := newProviders()
+		google.err = apperr.Unavailable("google books API request failed", nil)
+		c := catalog.New(logger, google, openLibrary)
+
+		_, err := c.Search(ctx, "dune", []string{"google"})
+
+		assert.Equal(t, "google books API request failed", apperr.Message(err))
+	})
+}
+
+func TestISBNs(t *testing.T) {
+	assert.Equal(t, []string{"9780441172719", "9780593099322"},
+		catalog.ISBNs([]string{"9780593099322", "0441172717", "978-0-441-17271-9", "garbage"}))
+	assert.Nil(t, catalog.ISBNs(nil))
+}
@@ -0,0 +1,263 @@
+// Package google is a client for the Google Books API volumes endpoint.
+package google
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience"
+)
+
+// ProviderName is the name of the Google Books catalog provider.
+const ProviderName = "google"
+
+// Config configures the Google Books API client.
+type Config struct {
+	BaseURL string
+	APIKey  string
+	Timeout time.Duration
+	// Transport sends the requests; nil means http.DefaultTransport. The
+	// client wraps it to propagate the W3C trace context.
+	Transport http.RoundTripper
+	// Resilience configures retries, the circuit breaker and the rate
+	// limiter. The zero value sends every request once.
+	Resilience resilience.Config
+}
+
+// DefaultConfig returns the public Google Books endpoint with a 10 second
+// timeout and no API key.
+func DefaultConfig() Config {
+	return Config{
+		BaseURL: "https://www.googleapis.com/books/v1/volumes",
+		Timeout: 10 * time.Second,
+	}
+}
+
+// Client calls the Google Books API. It implements catalog.Provider.
+type Client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+var _ catalog.Provider = (*Client)(nil)
+
+// New returns a client for cfg.
+func New(cfg Config) *Client {
+	return &Client{
+		baseURL: cfg.BaseURL,
+		apiKey:  cfg.APIKey,
+		http: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: otelhttp.NewTransport(resilience.NewTransport(cfg.Transport, cfg.Resilience)),
+		},
+	}
+}
+
+type searchResponse struct {
+	TotalItems int                   `json:"totalItems"`
+	Items      []entities.GoogleBook `json:"items"`
+}
+
+// Search returns one page of the volumes matching query, which should
+// have its defaults applied.
+func (c *Client) Search(ctx context.Context, query entities.GoogleBookQuery) (*entities.GoogleBookPage, error) {
+	var result searchResponse
+	if err := c.get(ctx, "", searchParams(query), &result); err != nil {
+		return nil, err
+	}
+	if result.Items == nil {
+		result.Items = []entities.GoogleBook{}
+	}
+	return &entities.GoogleBookPage{
+		Items:      result.Items,
+		TotalItems: result.TotalItems,
+		StartIndex: query.StartIndex,
+		MaxResults: query.MaxResults,
+	}, nil
+}
+
+// searchParams translates query into the parameters of the volumes
+// endpoint, turning the field searches into q qualifiers matching their
+// value as a phrase.
+func searchParams(query entities.GoogleBookQuery) url.Values {
+	var terms []string
+	if query.Query != "" {
+		terms = append(terms, query.Query)
+	}
+	for _, qualifier := range []struct{ name, value string }{
+		{"intitle", query.Title},
+		{"inauthor", query.Author},
+		{"isbn", query.ISBN},
+	} {
+		if value := qualifierValue(qualifier.value); value != "" {
+			terms = append(terms, qualifier.name+":"+value)
+		}
+	}
+
+	params := url.Values{"q": {strings.Join(terms, " ")}}
+	if query.StartIndex > 0 {
+		params.Set("startIndex", strconv.Itoa(query.StartIndex))
+	}
+	if query.MaxResults > 0 {
+		params.Set("maxResults", strconv.Itoa(query.MaxResults))
+	}
+	if query.LangRestrict != "" {
+		params.Set("langRestrict", query.LangRestrict)
+	}
+	if query.OrderBy != "" {
+		params.Set("orderBy", string(query.OrderBy))
+	}
+	return params
+}
+
+// qualifierValue returns value as the argument of a q qualifier. Google
+// Books reads only the first word after a qualifier, so a value of several
+// words is quoted as a phrase; quotes inside value, which would end the
+// phrase early, are dropped.
+func qualifierValue(value string) string {
+	value = strings.Join(strings.Fields(strings.ReplaceAll(value, `"`, "")), " ")
+	if strings.Contains(value, " ") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// Volume returns a single volume, or an apperr.ErrNotFound error when
+// Google Books does not know id.
+func (c *Client) Volume(ctx context.Context, id string) (*entities.GoogleBook, error) {
+	var volume entities.GoogleBook
+	err := c.get(ctx, "/"+url.PathEscape(id), nil, &volume)
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.status == http.StatusNotFound {
+		return nil, apperr.NotFound(fmt.Sprintf("google volume %s not found", id))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &volume, nil
+}
+
+// Check reports whether the API answers a minimal query. It is used by
+// the readiness probe.
+func (c *Client) Check(ctx context.Context) error {
+	return c.get(ctx, "", url.Values{"q": {"healthcheck"}, "maxResults": {"1"}}, &searchResponse{})
+}
+
+// Name implements catalog.Provider.
+func (c *Client) Name() string {
+	return ProviderName
+}
+
+// SearchBooks implements catalog.Provider.
+func (c *Client) SearchBooks(ctx context.Context, query string) ([]entities.ExternalBook, error) {
+	page, err := c.Search(ctx, entities.GoogleBookQuery{Query: query}.WithDefaults())
+	if err != nil {
+		return nil, err
+	}
+
+	books := make([]entities.ExternalBook, len(page.Items))
+	for i, v := range page.Items {
+		books[i] = ExternalBook(v)
+	}
+	return books, nil
+}
+
+// ExternalBook maps a volume onto the catalog-neutral book.
+func ExternalBook(v entities.GoogleBook) entities.ExternalBook {
+	var ids []string
+	for _, id := range v.VolumeInfo.IndustryIdentifiers {
+		if id.Type == entities.GoogleISBN10 || id.Type == entities.GoogleISBN13 {
+			ids = append(ids, id.Identifier)
+		}
+	}
+	return entities.ExternalBook{
+		Sources:       map[string]string{ProviderName: v.ID},
+		Title:         v.VolumeInfo.Title,
+		Authors:       v.VolumeInfo.Authors,
+		Description:   v.VolumeInfo.Description,
+		PublishedDate: v.VolumeInfo.PublishedDate,
+		ISBNs:         catalog.ISBNs(ids),
+	}
+}
+
+// statusError is an unexpected HTTP status from the API.
+type statusError struct {
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("google books API returned unexpected status code: %d", e.status)
+}
+
+// get sends a GET to the base URL plus path and decodes the JSON answer
+// into dst. Failures are classified for the HTTP layer: a 429 from Google
+// or our own rate limiter is an apperr.ErrRateLimited error, anything else
+// an apperr.ErrUnavailable error, both carrying a retry hint when known.
+func (c *Client) get(ctx context.Context, path string, params url.Values, dst any) error {
+	if c.apiKey != "" {
+		if params == nil {
+			params = url.Values{}
+		}
+		params.Set("key", c.apiKey)
+	}
+	endpoint := c.baseURL + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return requestError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("failed to decode Google Books API response: %w", err)
+	}
+	return nil
+}
+
+// requestError classifies an error returned before Google answered.
+func requestError(err error) error {
+	var limitErr *resilience.RateLimitError
+	if errors.As(err, &limitErr) {
+		return apperr.RateLimited("google books request rate limit exceeded", limitErr.RetryAfter, err)
+	}
+	var openErr *resilience.CircuitOpenError
+	if errors.As(err, &openErr) {
+		return &apperr.Error{Kind: apperr.ErrUnavailable, Message: "google books API is unavailable", Err: err, RetryAfter: openErr.RetryAfter}
+	}
+	return apperr.Unavailable("google books API request failed", err)
+}
+
+// responseError classifies an unexpected status from Google.
+func responseError(resp *http.Response) error {
+	err := &statusError{status: resp.StatusCode}
+	retryAfter, _ := resilience.RetryAfter(resp, time.Now())
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return apperr.RateLimited("google books API rate limit exceeded", retryAfter, err)
+	}
+	return &apperr.Error{Kind: apperr.ErrUnavailable, Message: err.Error(), Err: err, RetryAfter: retryAfter}
+}
@@ -0,0 +1,376 @@
+package google_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog/google"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience/resiliencetest"
+)
+
+const duneSearch = `{
+	"totalItems": 1,
+	"items": [{
+		"id": "B1hSG45JCX4C",
+		"volumeInfo": {
+			"title": "Dune",
+			"authors": ["Frank Herbert"],
+			"publishedDate": "1965-08",
+			"industryIdentifiers": [
+				{"type": "ISBN_10", "identifier": "0441172717"},
+				{"type": "ISBN_13", "identifier": "9780441172719"},
+				{"type": "OTHER", "identifier": "UOM:39015000439405"}
+			]
+		}
+	}]
+}`
+
+// newClient returns a client for a test server answering every request
+// with status and body, and a pointer to the last request's URL.
+func newClient(t *testing.T, apiKey string, status int, body string) (*google.Client, *url.URL) {
+	t.Helper()
+	var got url.URL
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = *r.URL
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return google.New(google.Config{BaseURL: server.URL + "/volumes", APIKey: apiKey}), &got
+}
+
+// duneQuery is a plain free-text search.
+var duneQuery = entities.GoogleBookQuery{Query: "dune"}
+
+func TestClient_Search(t *testing.T) {
+	client, got := newClient(t, "secret", http.StatusOK, duneSearch)
+
+	page, err := client.Search(context.Background(), entities.GoogleBookQuery{
+		Query:        "desert planet",
+		Title:        "dune",
+		Author:       "herbert",
+		ISBN:         "9780441172719",
+		StartIndex:   20,
+		MaxResults:   5,
+		LangRestrict: "en",
+		OrderBy:      entities.GoogleOrderNewest,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.TotalItems)
+	assert.Equal(t, 20, page.StartIndex)
+	assert.Equal(t, 5, page.MaxResults)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "B1hSG45JCX4C", page.Items[0].ID)
+	assert.Equal(t, "/volumes", got.Path)
+	assert.Equal(t, url.Values{
+		"q":            {"desert planet intitle:dune inauthor:herbert isbn:9780441172719"},
+		"startIndex":   {"20"},
+		"maxResults":   {"5"},
+		"langRestrict": {"en"},
+		"orderBy":      {"newest"},
+		"key":          {"secret"},
+	}, got.Query())
+}
+
+func TestClient_Search_Qualifiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		query entities.GoogleBookQuery
+		wantQ string
+	}{
+		{
+			name:  "single words are sent as they are",
+			query: entities.GoogleBookQuery{Title: "dune", Author: "herbert"},
+			wantQ: "intitle:dune inauthor:herbert",
+		},
+		{
+			name:  "several words are quoted as a phrase",
+			query: entities.GoogleBookQuery{Query: "fantasy", Title: "the hobbit", Author: "J. R. R. Tolkien"},
+			wantQ: `fantasy intitle:"the hobbit" inauthor:"J. R. R. Tolkien"`,
+		},
+		{
+			name:  "embedded quotes are dropped",
+			query: entities.GoogleBookQuery{Title: `the "lord" of the rings`, Author: `"tolkien"`},
+			wantQ: `intitle:"the lord of the rings" inauthor:tolkien`,
+		},
+		{
+			name:  "surrounding and repeated spaces are collapsed",
+			query: entities.GoogleBookQuery{Title: "  the   hobbit ", ISBN: " 9780547928227 "},
+			wantQ: `intitle:"the hobbit" isbn:9780547928227`,
+		},
+		{
+			name:  "a value of only quotes is left out",
+			query: entities.GoogleBookQuery{Query: "dune", Title: `""`},
+			wantQ: "dune",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, got := newClient(t, "", http.StatusOK, duneSearch)
+
+			_, err := client.Search(context.Background(), tt.query)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantQ, got.Query().Get("q"))
+		})
+	}
+}
+
+func TestClient_Search_DecodesVolumes(t *testing.T) {
+	client, _ := newClient(t, "", http.StatusOK, `{
+		"totalItems": 1,
+		"items": [{
+			"id": "B1hSG45JCX4C",
+			"volumeInfo": {
+				"title": "Dune",
+				"authors": ["Frank Herbert"],
+				"publisher": "Ace",
+				"publishedDate": "1990-09-01",
+				"industryIdentifiers": [
+					{"type": "ISBN_10", "identifier": "0441172717"},
+					{"type": "ISBN_13", "identifier": "9780441172719"}
+				],
+				"pageCount": 535,
+				"categories": ["Fiction"],
+				"imageLinks": {"smallThumbnail": "http://img/s", "thumbnail": "http://img/t"},
+				"language": "en"
+			},
+			"saleInfo": {
+				"saleability": "FOR_SALE",
+				"listPrice": {"amount": 9.99, "currencyCode": "USD"},
+				"retailPrice": {"amount": 7.99, "currencyCode": "USD"}
+			}
+		}]
+	}`)
+
+	page, err := client.Search(context.Background(), duneQuery)
+
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, entities.GoogleBook{
+		ID: "B1hSG45JCX4C",
+		VolumeInfo: entities.GoogleVolumeInfo{
+			Title:         "Dune",
+			Authors:       []string{"Frank Herbert"},
+			Publisher:     "Ace",
+			PublishedDate: "1990-09-01",
+			IndustryIdentifiers: []entities.GoogleIdentifier{
+				{Type: entities.GoogleISBN10, Identifier: "0441172717"},
+				{Type: entities.GoogleISBN13, Identifier: "9780441172719"},
+			},
+			PageCount:  535,
+			Categories: []string{"Fiction"},
+			ImageLinks: &entities.GoogleImageLinks{SmallThumbnail: "http://img/s", Thumbnail: "http://img/t"},
+			Language:   "en",
+		},
+		SaleInfo: entities.GoogleSaleInfo{
+			Saleability: "FOR_SALE",
+			ListPrice:   &entities.GooglePrice{Amount: 9.99, CurrencyCode: "USD"},
+			RetailPrice: &entities.GooglePrice{Amount: 7.99, CurrencyCode: "USD"},
+		},
+	}, page.Items[0])
+	assert.Equal(t, "9780441172719", page.Items[0].VolumeInfo.Identifier(entities.GoogleISBN13))
+}
+
+func TestClient_Search_NoMatches(t *testing.T) {
+	client, _ := newClient(t, "", http.StatusOK, `{"kind": "books#volumes", "totalItems": 0}`)
+
+	page, err := client.Search(context.Background(), duneQuery)
+
+	require.NoError(t, err)
+	assert.NotNil(t, page.Items)
+	assert.Empty(t, page.Items)
+}
+
+func TestClient_SearchBooks(t *testing.T) {
+	client, _ := newClient(t, "", http.StatusOK, duneSearch)
+
+	books, err := client.SearchBooks(context.Background(), "dune")
+
+	require.NoError(t, err)
+	assert.Equal(t, []entities.ExternalBook{{
+		Sources:       map[string]string{"google": "B1hSG45JCX4C"},
+		Title:         "Dune",
+		Authors:       []string{"Frank Herbert"},
+		PublishedDate: "1965-08",
+		ISBNs:         []string{"9780441172719"},
+	}}, books)
+}
+
+func TestClient_Volume(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		client, got := newClient(t, "", http.StatusOK, `{"id": "B1hSG45JCX4C", "volumeInfo": {"title": "Dune"}}`)
+
+		volume, err := client.Volume(context.Background(), "B1hSG45JCX4C")
+
+		require.NoError(t, err)
+		assert.Equal(t, "Dune", volume.VolumeInfo.Title)
+		assert.Equal(t, "/volumes/B1hSG45JCX4C", got.Path)
+		assert.Empty(t, got.RawQuery)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		client, _ := newClient(t, "", http.StatusNotFound, `{}`)
+
+		_, err := client.Volume(context.Background(), "missing")
+
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+	}{
+		{name: "server error", status: http.StatusInternalServerError, body: `{}`, kind: apperr.ErrUnavailable},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: `{}`, kind: apperr.ErrRateLimited},
+		{name: "malformed body", status: http.StatusOK, body: `{`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newClient(t, "", tt.status, tt.body)
+
+			_, err := client.Search(context.Background(), duneQuery)
+
+			require.Error(t, err)
+			if tt.kind != nil {
+				assert.ErrorIs(t, err, tt.kind)
+			}
+			assert.Error(t, client.Check(context.Background()))
+		})
+	}
+}
+
+func TestClient_Resilience(t *testing.T) {
+	ctx := context.Background()
+
+	// newResilientClient returns a client for a test server answering
+	// with statuses in turn, repeating the last one.
+	newResilientClient := func(t *testing.T, retryAfter string, statuses ...int) (*google.Client, *resiliencetest.Clock, *resilience.Breaker, *atomic.Int32) {
+		t.Helper()
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := int(calls.Add(1))
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statuses[min(n, len(statuses))-1])
+			w.Write([]byte(`{"items":[]}`))
+		}))
+		t.Cleanup(server.Close)
+
+		clock := resiliencetest.NewClock()
+		breaker := resilience.NewBreaker(3, time.Minute, clock, nil)
+		client := google.New(google.Config{BaseURL: server.URL, Resilience: resilience.Config{
+			MaxRetries: 2,
+			BaseDelay:  100 * time.Millisecond,
+			MaxDelay:   2 * time.Second,
+			Breaker:    breaker,
+			Clock:      clock,
+			Rand:       func() float64 { return 0 },
+		}})
+		return client, clock, breaker, &calls
+	}
+
+	t.Run("retries until Google recovers", func(t *testing.T) {
+		client, clock, _, calls := newResilientClient(t, "", 503, 200)
+
+		_, err := client.Search(ctx, duneQuery)
+
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, calls.Load())
+		assert.Equal(t, []time.Duration{50 * time.Millisecond}, clock.Sleeps())
+	})
+
+	t.Run("reports a persistent 429 as rate limited with its retry hint", func(t *testing.T) {
+		client, _, _, calls := newResilientClient(t, "30", 429)
+
+		_, err := client.Search(ctx, duneQuery)
+
+		assert.ErrorIs(t, err, apperr.ErrRateLimited)
+		assert.Equal(t, 30*time.Second, apperr.RetryAfter(err))
+		assert.EqualValues(t, 1, calls.Load(), "a Retry-After beyond the maximum delay is not waited for")
+	})
+
+	t.Run("fails fast while the breaker is open", func(t *testing.T) {
+		client, clock, breaker, calls := newResilientClient(t, "", 500)
+
+		_, err := client.Search(ctx, duneQuery)
+		assert.ErrorIs(t, err, apperr.ErrUnavailable)
+		require.Equal(t, resilience.StateOpen, breaker.State())
+		assert.EqualValues(t, 3, calls.Load())
+
+		clock.Advance(15 * time.Second)
+		_, err = client.Search(ctx, duneQuery)
+		assert.ErrorIs(t, err, apperr.ErrUnavailable)
+		assert.Equal(t, 45*time.Second, apperr.RetryAfter(err))
+		assert.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("reports the client-side limit as rate limited", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+		}))
+		defer server.Close()
+		deadlineCtx, cancel := context.WithTimeout(ctx, time.Hour)
+		defer cancel()
+		// Move the fake clock to just before the real deadline so that a
+		// token one second away comes too late.
+		clock := resiliencetest.NewClock()
+		deadline, _ := deadlineCtx.Deadline()
+		clock.Advance(deadline.Sub(clock.Now()) - 500*time.Millisecond)
+		client := google.New(google.Config{BaseURL: server.URL, Resilience: resilience.Config{
+			Limiter: resilience.NewLimiter(1, 0, clock),
+			Clock:   clock,
+		}})
+
+		_, err := client.Search(deadlineCtx, duneQuery)
+
+		assert.ErrorIs(t, err, apperr.ErrRateLimited)
+		assert.Equal(t, time.Second, apperr.RetryAfter(err))
+		assert.Zero(t, calls.Load())
+	})
+}
+
+func TestClient_PropagatesTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer server.Close()
+
+	client := google.New(google.Config{BaseURL: server.URL})
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	_, err := client.Search(ctx, duneQuery)
+
+	assert.NoError(t, err)
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+}
@@ -0,0 +1,124 @@
+// Package openlibrary is a client for the Open Library search API.
+package openlibrary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+)
+
+// ProviderName is the name of the Open Library catalog provider.
+const ProviderName = "openlibrary"
+
+// searchLimit caps the works requested per search, in line with the ten
+// volumes Google Books returns by default.
+const searchLimit = 10
+
+// searchFields are the work fields requested from the search API.
+const searchFields = "key,title,author_name,first_publish_year,isbn"
+
+// Config configures the Open Library client.
+type Config struct {
+	BaseURL string
+	Timeout time.Duration
+	// Transport sends the requests; nil means http.DefaultTransport. The
+	// client wraps it to propagate the W3C trace context.
+	Transport http.RoundTripper
+}
+
+// DefaultConfig returns the public Open Library endpoint with a 10 second
+// timeout.
+func DefaultConfig() Config {
+	return Config{
+		BaseURL: "https://openlibrary.org",
+		Timeout: 10 * time.Second,
+	}
+}
+
+// Client calls the Open Library API. It implements catalog.Provider.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+var _ catalog.Provider = (*Client)(nil)
+
+// New returns a client for cfg.
+func New(cfg Config) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(cfg.BaseURL, "/"),
+		http:    &http.Client{Timeout: cfg.Timeout, Transport: otelhttp.NewTransport(cfg.Transport)},
+	}
+}
+
+type searchResponse struct {
+	Docs []work `json:"docs"`
+}
+
+// work is a search result. Open Library groups editions into works; isbn
+// lists the ISBNs of every edition.
+type work struct {
+	Key              string   `json:"key"`
+	Title            string   `json:"title"`
+	AuthorName       []string `json:"author_name"`
+	FirstPublishYear int      `json:"first_publish_year"`
+	ISBN             []string `json:"isbn"`
+}
+
+// Name implements catalog.Provider.
+func (c *Client) Name() string {
+	return ProviderName
+}
+
+// SearchBooks implements catalog.Provider.
+func (c *Client) SearchBooks(ctx context.Context, query string) ([]entities.ExternalBook, error) {
+	params := url.Values{
+		"q":      {query},
+		"fields": {searchFields},
+		"limit":  {strconv.Itoa(searchLimit)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/search.json?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, apperr.Unavailable("open library API request failed", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperr.Unavailable(fmt.Sprintf("open library API returned unexpected status code: %d", resp.StatusCode), nil)
+	}
+
+	var result searchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode Open Library API response: %w", err)
+	}
+
+	books := make([]entities.ExternalBook, len(result.Docs))
+	for i, w := range result.Docs {
+		books[i] = entities.ExternalBook{
+			Sources: map[string]string{ProviderName: strings.TrimPrefix(w.Key, "/works/")},
+			Title:   w.Title,
+			Authors: w.AuthorName,
+			ISBNs:   catalog.ISBNs(w.ISBN),
+		}
+		if w.FirstPublishYear > 0 {
+			books[i].PublishedDate = strconv.Itoa(w.FirstPublishYear)
+		}
+	}
+	return books, nil
+}
@@ -0,0 +1,81 @@
+package openlibrary_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog/openlibrary"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+)
+
+const duneSearch = `{
+	"numFound": 2,
+	"docs": [
+		{
+			"key": "/works/OL893415W",
+			"title": "Dune",
+			"author_name": ["Frank Herbert"],
+			"first_publish_year": 1965,
+			"isbn": ["0441172717", "9780441172719", "not-an-isbn", "9780593099322"]
+		},
+		{"key": "/works/OL1W", "title": "Dune Messiah"}
+	]
+}`
+
+func TestClient_SearchBooks(t *testing.T) {
+	var got url.URL
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = *r.URL
+		w.Write([]byte(duneSearch))
+	}))
+	defer server.Close()
+
+	client := openlibrary.New(openlibrary.Config{BaseURL: server.URL + "/"})
+	books, err := client.SearchBooks(context.Background(), "dune")
+
+	require.NoError(t, err)
+	assert.Equal(t, "/search.json", got.Path)
+	assert.Equal(t, "dune", got.Query().Get("q"))
+	assert.Equal(t, []entities.ExternalBook{
+		{
+			Sources:       map[string]string{"openlibrary": "OL893415W"},
+			Title:         "Dune",
+			Authors:       []string{"Frank Herbert"},
+			PublishedDate: "1965",
+			ISBNs:         []string{"9780441172719", "9780593099322"},
+		},
+		{
+			Sources: map[string]string{"openlibrary": "OL1W"},
+			Title:   "Dune Messiah",
+		},
+	}, books)
+}
+
+func TestClient_SearchBooks_Errors(t *testing.T) {
+	t.Run("unexpected status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		_, err := openlibrary.New(openlibrary.Config{BaseURL: server.URL}).SearchBooks(context.Background(), "dune")
+
+		assert.ErrorIs(t, err, apperr.ErrUnavailable)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		_, err := openlibrary.New(openlibrary.Config{BaseURL: server.URL}).SearchBooks(context.Background(), "dune")
+
+		assert.ErrorIs(t, err, apperr.ErrUnavailable)
+	})
+}
@@ -0,0 +1,451 @@
+// Package config loads the application configuration.
+//
+// Settings are resolved in increasing order of precedence from built-in
+// defaults, an optional YAML file, environment variables and command-line
+// flags. Every setting is declared once on the Config structs: the yaml tag
+// names it in the file, the env tag in the environment and the flag tag on
+// the command line. Fields tagged secret:"true" are redacted when printed.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+)
+
+type Config struct {
+	Database    DatabaseConfig    `yaml:"database"`
+	Server      ServerConfig      `yaml:"server"`
+	Google      GoogleConfig      `yaml:"google"`
+	OpenLibrary OpenLibraryConfig `yaml:"openlibrary"`
+	Catalog     CatalogConfig     `yaml:"catalog"`
+	Cache       CacheConfig       `yaml:"cache"`
+	Health      HealthConfig      `yaml:"health"`
+	Admin       AdminConfig       `yaml:"admin"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Purge       PurgeConfig       `yaml:"purge"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+}
+
+type DatabaseConfig struct {
+	URL            string `yaml:"url" env:"DATABASE_URL" flag:"database-url" secret:"true" usage:"Postgres connection string"`
+	MigrateOnStart bool   `yaml:"migrate_on_start" env:"MIGRATE_ON_START" flag:"migrate-on-start" usage:"apply pending migrations before serving"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr" env:"SERVER_ADDR" flag:"addr" usage:"HTTP listen address"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" flag:"read-timeout" usage:"maximum duration for reading a request"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" flag:"write-timeout" usage:"maximum duration for writing a response"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" flag:"idle-timeout" usage:"keep-alive idle timeout"`
+	MaxHeaderBytes  int           `yaml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" flag:"max-header-bytes" usage:"maximum size of request headers"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"how long to drain connections on shutdown"`
+	DrainDelay      time.Duration `yaml:"drain_delay" env:"SERVER_DRAIN_DELAY" flag:"drain-delay" usage:"how long to keep serving with readiness failing before shutdown"`
+}
+
+type GoogleConfig struct {
+	BaseURL string        `yaml:"base_url" env:"GOOGLE_BOOKS_BASE_URL" flag:"google-base-url" usage:"Google Books volumes endpoint"`
+	APIKey  string        `yaml:"api_key" env:"GOOGLE_BOOKS_API_KEY" flag:"google-api-key" secret:"true" usage:"Google Books API key"`
+	Timeout time.Duration `yaml:"timeout" env:"GOOGLE_BOOKS_TIMEOUT" flag:"google-timeout" usage:"timeout of Google Books requests"`
+
+	MaxRetries         int           `yaml:"max_retries" env:"GOOGLE_BOOKS_MAX_RETRIES" flag:"google-max-retries" usage:"retries of failed Google Books requests"`
+	RetryBaseDelay     time.Duration `yaml:"retry_base_delay" env:"GOOGLE_BOOKS_RETRY_BASE_DELAY" flag:"google-retry-base-delay" usage:"backoff before the first retry, doubled for each further one"`
+	RetryMaxDelay      time.Duration `yaml:"retry_max_delay" env:"GOOGLE_BOOKS_RETRY_MAX_DELAY" flag:"google-retry-max-delay" usage:"longest backoff or Retry-After waited for"`
+	BreakerThreshold   int           `yaml:"breaker_threshold" env:"GOOGLE_BOOKS_BREAKER_THRESHOLD" flag:"google-breaker-threshold" usage:"consecutive failures that open the circuit breaker, 0 to disable"`
+	BreakerOpenTimeout time.Duration `yaml:"breaker_open_timeout" env:"GOOGLE_BOOKS_BREAKER_OPEN_TIMEOUT" flag:"google-breaker-open-timeout" usage:"how long the circuit breaker stays open"`
+	RateLimit          float64       `yaml:"rate_limit" env:"GOOGLE_BOOKS_RATE_LIMIT" flag:"google-rate-limit" usage:"Google Books requests per second, 0 for no limit"`
+	RateBurst          int           `yaml:"rate_burst" env:"GOOGLE_BOOKS_RATE_BURST" flag:"google-rate-burst" usage:"Google Books requests allowed in a burst"`
+}
+
+type OpenLibraryConfig struct {
+	BaseURL string        `yaml:"base_url" env:"OPENLIBRARY_BASE_URL" flag:"openlibrary-base-url" usage:"Open Library API root"`
+	Timeout time.Duration `yaml:"timeout" env:"OPENLIBRARY_TIMEOUT" flag:"openlibrary-timeout" usage:"timeout of Open Library requests"`
+}
+
+type CatalogConfig struct {
+	Providers string `yaml:"providers" env:"CATALOG_PROVIDERS" flag:"catalog-providers" usage:"external catalogs searched by /books/search/external, e.g. google,openlibrary"`
+}
+
+// catalogProviders are the provider names CatalogConfig.Providers accepts.
+var catalogProviders = []string{"google", "openlibrary"}
+
+// ProviderNames returns the configured catalog providers in order.
+func (c CatalogConfig) ProviderNames() []string {
+	var names []string
+	for _, name := range strings.Split(c.Providers, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+type CacheConfig struct {
+	Backend              string        `yaml:"backend" env:"CACHE_BACKEND" flag:"cache-backend" usage:"search cache backend: memory or none"`
+	TTL                  time.Duration `yaml:"ttl" env:"CACHE_TTL" flag:"cache-ttl" usage:"how long cached searches are served"`
+	MaxEntries           int           `yaml:"max_entries" env:"CACHE_MAX_ENTRIES" flag:"cache-max-entries" usage:"maximum number of cached searches"`
+	StaleWhileRevalidate time.Duration `yaml:"stale_while_revalidate" env:"CACHE_STALE_WHILE_REVALIDATE" flag:"cache-stale-while-revalidate" usage:"how long expired searches are served while refreshed, 0 to disable"`
+	LoadTimeout          time.Duration `yaml:"load_timeout" env:"CACHE_LOAD_TIMEOUT" flag:"cache-load-timeout" usage:"timeout of the upstream search shared by the requests waiting for it"`
+}
+
+type HealthConfig struct {
+	CheckTimeout time.Duration `yaml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" flag:"health-check-timeout" usage:"timeout of each readiness check"`
+	CheckGoogle  bool          `yaml:"check_google" env:"HEALTH_CHECK_GOOGLE" flag:"health-check-google" usage:"include Google Books in readiness"`
+}
+
+// AdminConfig identifies admins: requests bearing the token are made by
+// one. Nobody is an admin while it is empty.
+type AdminConfig struct {
+	Token string `yaml:"token" env:"ADMIN_TOKEN" flag:"admin-token" secret:"true" usage:"bearer token identifying admin requests"`
+}
+
+// AuthConfig names the callers recorded in the audit log. Requests are
+// expected to pass through an authenticating proxy that sets the identity
+// header, replacing any value the client sent; it is ignored while empty.
+type AuthConfig struct {
+	IdentityHeader string `yaml:"identity_header" env:"AUTH_IDENTITY_HEADER" flag:"auth-identity-header" usage:"header in which an authenticating proxy names the caller, e.g. X-Authenticated-User"`
+}
+
+type PurgeConfig struct {
+	Retention time.Duration `yaml:"retention" env:"PURGE_RETENTION" flag:"purge-retention" usage:"how long deleted books and authors can be restored before they are purged, 0 to never purge"`
+	Interval  time.Duration `yaml:"interval" env:"PURGE_INTERVAL" flag:"purge-interval" usage:"how often deleted books and authors are purged"`
+}
+
+type LogConfig struct {
+	Format string `yaml:"format" env:"LOG_FORMAT" flag:"log-format" usage:"log output format, text or json"`
+	Level  string `yaml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"default log level"`
+	Levels string `yaml:"levels" env:"LOG_LEVELS" flag:"log-levels" usage:"per-component log levels, e.g. storage=debug,service=warn"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" usage:"trace exporter: none, stdout or otlp"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_OTLP_ENDPOINT" flag:"tracing-endpoint" usage:"OTLP/HTTP collector URL"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" flag:"tracing-sample-ratio" usage:"fraction of new traces to record"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" flag:"tracing-service-name" usage:"service name reported in traces"`
+}
+
+// Default returns the configuration used when nothing overrides it.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    10 * time.Second,
+			IdleTimeout:     30 * time.Second,
+			MaxHeaderBytes:  1 << 20,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Google: GoogleConfig{
+			BaseURL:            "https://www.googleapis.com/books/v1/volumes",
+			Timeout:            10 * time.Second,
+			MaxRetries:         2,
+			RetryBaseDelay:     200 * time.Millisecond,
+			RetryMaxDelay:      2 * time.Second,
+			BreakerThreshold:   5,
+			BreakerOpenTimeout: 30 * time.Second,
+			RateLimit:          10,
+			RateBurst:          10,
+		},
+		OpenLibrary: OpenLibraryConfig{
+			BaseURL: "https://openlibrary.org",
+			Timeout: 10 * time.Second,
+		},
+		Catalog: CatalogConfig{
+			Providers: "google,openlibrary",
+		},
+		Cache: CacheConfig{
+			Backend:     "memory",
+			TTL:         5 * time.Minute,
+			MaxEntries:  1000,
+			LoadTimeout: 30 * time.Second,
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
+		},
+		Purge: PurgeConfig{
+			Retention: 30 * 24 * time.Hour,
+			Interval:  time.Hour,
+		},
+		Log: LogConfig{
+			Format: "text",
+			Level:  "info",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			Endpoint:    "http://localhost:4318",
+			SampleRatio: 1,
+			ServiceName: "bookapi",
+		},
+	}
+}
+
+// Load resolves the configuration from defaults, the YAML file named by
+// -config or CONFIG_FILE, the environment and the flags in args. It returns
+// the positional arguments left after the flags (the subcommand).
+//
+// Load does not validate the result; call Validate for that.
+func Load(args []string, getenv func(string) string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	configFile := fs.String("config", "", "path to a YAML configuration file")
+	flagValues := make(map[string]*flagValue)
+	for _, f := range fields(&cfg) {
+		if f.flag != "" {
+			flagValues[f.flag] = &flagValue{isBool: f.value.Kind() == reflect.Bool}
+			fs.Var(flagValues[f.flag], f.flag, f.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	path := *configFile
+	if path == "" {
+		path = getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(&cfg, path); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var errs []error
+	for _, f := range fields(&cfg) {
+		if raw := getenv(f.env); f.env != "" && raw != "" {
+			if err := f.set(raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+			}
+		}
+	}
+
+	// Only flags given on the command line override the other sources
+	set := make(map[string]bool)
+	fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+	for _, f := range fields(&cfg) {
+		if set[f.flag] {
+			if err := f.set(flagValues[f.flag].raw); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", f.flag, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return &cfg, fs.Args(), nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks the configuration and reports every problem at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Database.URL != "", "database.url (DATABASE_URL) is required")
+
+	check(c.Server.Addr != "", "server.addr must not be empty")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.MaxHeaderBytes > 0, "server.max_header_bytes must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	u, err := url.Parse(c.Google.BaseURL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		"google.base_url must be an absolute http(s) URL")
+	check(c.Google.Timeout > 0, "google.timeout must be positive")
+	check(c.Google.MaxRetries >= 0, "google.max_retries must not be negative")
+	if c.Google.MaxRetries > 0 {
+		check(c.Google.RetryBaseDelay > 0, "google.retry_base_delay must be positive")
+		check(c.Google.RetryMaxDelay >= c.Google.RetryBaseDelay, "google.retry_max_delay must not be less than google.retry_base_delay")
+	}
+	check(c.Google.BreakerThreshold >= 0, "google.breaker_threshold must not be negative")
+	if c.Google.BreakerThreshold > 0 {
+		check(c.Google.BreakerOpenTimeout > 0, "google.breaker_open_timeout must be positive")
+	}
+	check(c.Google.RateLimit >= 0, "google.rate_limit must not be negative")
+	if c.Google.RateLimit > 0 {
+		check(c.Google.RateBurst > 0, "google.rate_burst must be positive")
+	}
+
+	u, err = url.Parse(c.OpenLibrary.BaseURL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		"openlibrary.base_url must be an absolute http(s) URL")
+	check(c.OpenLibrary.Timeout > 0, "openlibrary.timeout must be positive")
+
+	seen := make(map[string]bool)
+	for _, name := range c.Catalog.ProviderNames() {
+		check(slices.Contains(catalogProviders, name), "catalog.providers: unknown provider %q, expected one of: %s", name, strings.Join(catalogProviders, ", "))
+		check(!seen[name], "catalog.providers: %q is listed twice", name)
+		seen[name] = true
+	}
+
+	switch c.Cache.Backend {
+	case "none":
+	case "memory":
+		check(c.Cache.TTL > 0, "cache.ttl must be positive")
+		check(c.Cache.MaxEntries > 0, "cache.max_entries must be positive")
+		check(c.Cache.StaleWhileRevalidate >= 0, "cache.stale_while_revalidate must not be negative")
+		check(c.Cache.LoadTimeout > 0, "cache.load_timeout must be positive")
+	default:
+		check(false, "cache.backend must be memory or none")
+	}
+
+	check(c.Server.DrainDelay >= 0, "server.drain_delay must not be negative")
+	check(c.Health.CheckTimeout > 0, "health.check_timeout must be positive")
+
+	check(c.Purge.Retention >= 0, "purge.retention must not be negative")
+	if c.Purge.Retention > 0 {
+		check(c.Purge.Interval > 0, "purge.interval must be positive")
+	}
+
+	check(c.Log.Format == "text" || c.Log.Format == "json", "log.format must be text or json")
+	_, err = logging.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level: %v", err)
+	_, err = logging.ParseLevels(c.Log.Levels)
+	check(err == nil, "log.levels: %v", err)
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		u, err := url.Parse(c.Tracing.Endpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"tracing.endpoint must be an absolute http(s) URL")
+	default:
+		check(false, "tracing.exporter must be none, stdout or otlp")
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+	check(c.Tracing.ServiceName != "", "tracing.service_name must not be empty")
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration with secrets masked.
+func (c Config) Redacted() Config {
+	for _, f := range fields(&c) {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString("REDACTED")
+		}
+	}
+	return c
+}
+
+// Print writes the redacted configuration as YAML.
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// field is one leaf setting of Config found by reflection.
+type field struct {
+	value  reflect.Value
+	env    string
+	flag   string
+	usage  string
+	secret bool
+}
+
+func fields(cfg *Config) []field {
+	var out []field
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if sf.Type.Kind() == reflect.Struct {
+				walk(v.Field(i))
+				continue
+			}
+			out = append(out, field{
+				value:  v.Field(i),
+				env:    sf.Tag.Get("env"),
+				flag:   sf.Tag.Get("flag"),
+				usage:  sf.Tag.Get("usage"),
+				secret: sf.Tag.Get("secret") == "true",
+			})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem())
+	return out
+}
+
+func (f field) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case f.value.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		f.value.SetString(raw)
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		f.value.SetBool(b)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		f.value.SetInt(int64(n))
+	case f.value.Kind() == reflect.Float64:
+		x, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		f.value.SetFloat(x)
+	default:
+		return fmt.Errorf("unsupported setting type %s", f.value.Type())
+	}
+	return nil
+}
+
+// flagValue records the raw value of a flag; it is parsed by field.set so
+// that flags, environment variables and files share one parser.
+type flagValue struct {
+	raw    string
+	isBool bool
+}
+
+func (v *flagValue) String() string     { return v.raw }
+func (v *flagValue) Set(s string) error { v.raw = s; return nil }
+func (v *flagValue) IsBoolFlag() bool   { return v.isBool }
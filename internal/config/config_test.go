@@ -0,0 +1,162 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/config"
+)
+
+func envFrom(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, `
+database:
+  url: postgres://file
+server:
+  addr: ":7000"
+  read_timeout: 3s
+google:
+  timeout: 4s
+`)
+
+	env := envFrom(map[string]string{
+		"CONFIG_FILE":          path,
+		"SERVER_ADDR":          ":9000",
+		"SERVER_IDLE_TIMEOUT":  "1m",
+		"TRACING_SAMPLE_RATIO": "0.25",
+		"CATALOG_PROVIDERS":    "openlibrary, google",
+	})
+
+	cfg, args, err := config.Load([]string{"-addr", ":9100", "-migrate-on-start", "migrate", "up"}, env)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"migrate", "up"}, args)
+	// flag > env > file > default
+	assert.Equal(t, ":9100", cfg.Server.Addr)
+	assert.Equal(t, time.Minute, cfg.Server.IdleTimeout)
+	assert.Equal(t, 3*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 10*time.Second, cfg.Server.WriteTimeout)
+	assert.Equal(t, "postgres://file", cfg.Database.URL)
+	assert.True(t, cfg.Database.MigrateOnStart)
+	assert.Equal(t, 4*time.Second, cfg.Google.Timeout)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+	assert.Equal(t, []string{"openlibrary", "google"}, cfg.Catalog.ProviderNames())
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoad_ConfigFlagOverridesEnvFile(t *testing.T) {
+	fromFlag := writeFile(t, "server:\n  addr: \":1111\"\n")
+
+	cfg, _, err := config.Load([]string{"-config", fromFlag}, envFrom(map[string]string{"CONFIG_FILE": "/does/not/exist.yaml"}))
+	require.NoError(t, err)
+	assert.Equal(t, ":1111", cfg.Server.Addr)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		env         map[string]string
+		file        string
+		expectedErr []string
+	}{
+		{
+			name:        "missing config file",
+			env:         map[string]string{"CONFIG_FILE": "/does/not/exist.yaml"},
+			expectedErr: []string{"failed to open config file"},
+		},
+		{
+			name:        "unknown key in file",
+			file:        "server:\n  port: 8080\n",
+			expectedErr: []string{"field port not found"},
+		},
+		{
+			name:        "every malformed value is reported",
+			args:        []string{"-max-header-bytes", "lots"},
+			env:         map[string]string{"SERVER_READ_TIMEOUT": "soon", "MIGRATE_ON_START": "maybe"},
+			expectedErr: []string{"SERVER_READ_TIMEOUT", "MIGRATE_ON_START", "-max-header-bytes"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env := tc.env
+			if tc.file != "" {
+				env = map[string]string{"CONFIG_FILE": writeFile(t, tc.file)}
+			}
+
+			_, _, err := config.Load(tc.args, envFrom(env))
+			require.Error(t, err)
+			for _, msg := range tc.expectedErr {
+				assert.ErrorContains(t, err, msg)
+			}
+		})
+	}
+}
+
+func TestValidate_ReportsEveryError(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Addr = ""
+	cfg.Server.ShutdownTimeout = 0
+	cfg.Google.BaseURL = "not a url"
+	cfg.Log.Format = "xml"
+	cfg.Log.Levels = "storage=loud"
+	cfg.Tracing.Exporter = "otlp"
+	cfg.Tracing.Endpoint = "localhost:4318"
+	cfg.Catalog.Providers = "google,amazon,google"
+	cfg.Cache.TTL = 0
+	cfg.Google.RetryMaxDelay = time.Millisecond
+	cfg.Google.RateBurst = 0
+	cfg.Purge.Interval = 0
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "database.url")
+	assert.ErrorContains(t, err, "server.addr")
+	assert.ErrorContains(t, err, "server.shutdown_timeout")
+	assert.ErrorContains(t, err, "google.base_url")
+	assert.ErrorContains(t, err, "log.format")
+	assert.ErrorContains(t, err, "log.levels")
+	assert.ErrorContains(t, err, "tracing.endpoint")
+	assert.ErrorContains(t, err, `unknown provider "amazon"`)
+	assert.ErrorContains(t, err, `"google" is listed twice`)
+	assert.ErrorContains(t, err, "cache.ttl")
+	assert.ErrorContains(t, err, "google.retry_max_delay")
+	assert.ErrorContains(t, err, "google.rate_burst")
+	assert.ErrorContains(t, err, "purge.interval")
+}
+
+func TestPrint_RedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.URL = "postgres://user:hunter2@db/books"
+	cfg.Google.APIKey = "AIza-secret"
+	cfg.Admin.Token = "s3cret-token"
+
+	var out bytes.Buffer
+	require.NoError(t, cfg.Print(&out))
+
+	assert.NotContains(t, out.String(), "hunter2")
+	assert.NotContains(t, out.String(), "AIza-secret")
+	assert.NotContains(t, out.String(), "s3cret-token")
+	assert.Contains(t, out.String(), "url: REDACTED")
+	assert.Contains(t, out.String(), "read_timeout: 10s")
+	// Printing must not modify the original
+	assert.Equal(t, "AIza-secret", cfg.Google.APIKey)
+}
@@ -2,26 +2,35 @@ package db
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewPostgresConnection(dsn string) *pgxpool.Pool {
+// NewPostgresConnection opens a connection pool and checks that the
+// database answers within 5 seconds. Queries are reported to tracer when
+// it is not nil.
+func NewPostgresConnection(dsn string, tracer pgx.QueryTracer) (*pgxpool.Pool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	pool, err := pgxpool.New(ctx, dsn)
+	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		log.Fatalf("Unable to connect to database: %v", err)
+		return nil, fmt.Errorf("invalid database URL: %w", err)
 	}
+	cfg.ConnConfig.Tracer = tracer
 
-	err = pool.Ping(ctx)
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
-		log.Fatalf("Unable to ping database: %v", err)
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
 	}
 
-	log.Println("Successfully connected to the database")
-	return pool
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("unable to ping database: %w", err)
+	}
+
+	return pool, nil
 }
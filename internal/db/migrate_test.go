@@ -0,0 +1,156 @@
+package db_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/db"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/db/migrations"
+)
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"0002_create_books.up.sql":     {Data: []byte("CREATE TABLE books ();")},
+		"0002_create_books.down.sql":   {Data: []byte("DROP TABLE books;")},
+		"0001_create_authors.up.sql":   {Data: []byte("CREATE TABLE authors ();")},
+		"0001_create_authors.down.sql": {Data: []byte("DROP TABLE authors;")},
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name        string
+		fsys        fstest.MapFS
+		expectedErr string
+	}{
+		{
+			name: "should order migrations by version",
+			fsys: testMigrations(),
+		},
+		{
+			name:        "should reject files without a direction",
+			fsys:        fstest.MapFS{"0001_create_authors.sql": {Data: []byte("")}},
+			expectedErr: "must end in .up.sql or .down.sql",
+		},
+		{
+			name:        "should reject files without a version",
+			fsys:        fstest.MapFS{"create_authors.up.sql": {Data: []byte("")}},
+			expectedErr: "invalid version",
+		},
+		{
+			name:        "should reject migrations without an up script",
+			fsys:        fstest.MapFS{"0001_create_authors.down.sql": {Data: []byte("DROP TABLE authors;")}},
+			expectedErr: "has no up script",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			loaded, err := db.LoadMigrations(tc.fsys)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, loaded, 2)
+			assert.Equal(t, int64(1), loaded[0].Version)
+			assert.Equal(t, "create_authors", loaded[0].Name)
+			assert.Equal(t, "DROP TABLE authors;", loaded[0].Down)
+			assert.Equal(t, int64(2), loaded[1].Version)
+		})
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	loaded, err := db.LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+	assert.NotEmpty(t, loaded)
+
+	for _, m := range loaded {
+		assert.NotEmpty(t, m.Down, "migration %d_%s should have a down script", m.Version, m.Name)
+	}
+}
+
+func TestEmbeddedMigrations_UniqueKeysIgnoreDeletedBooks(t *testing.T) {
+	loaded, err := db.LoadMigrations(migrations.FS)
+	require.NoError(t, err)
+
+	// The last migration creating each index decides its definition
+	for _, index := range []string{"books_isbn_key", "books_google_id_key"} {
+		var definition string
+		for _, m := range loaded {
+			for _, statement := range strings.Split(m.Up, ";") {
+				if strings.Contains(statement, "CREATE UNIQUE INDEX IF NOT EXISTS "+index+" ") {
+					definition = statement
+				}
+			}
+		}
+		assert.Contains(t, definition, "WHERE deleted_at IS NULL", "%s should leave deleted books out", index)
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	ctx := context.Background()
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	migrator, err := db.NewMigrator(mockPool, testMigrations())
+	require.NoError(t, err)
+
+	mockPool.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).
+		WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mockPool.ExpectQuery(`SELECT version, applied_at FROM schema_migrations`).
+		WillReturnRows(pgxmock.NewRows([]string{"version", "applied_at"}).AddRow(int64(1), time.Now()))
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(`CREATE TABLE books`).
+		WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mockPool.ExpectExec(`INSERT INTO schema_migrations \(version, name\) VALUES \(\$1, \$2\)`).
+		WithArgs(int64(2), "create_books").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+
+	applied, err := migrator.Up(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, int64(2), applied[0].Version)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestMigrator_Down(t *testing.T) {
+	ctx := context.Background()
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	migrator, err := db.NewMigrator(mockPool, testMigrations())
+	require.NoError(t, err)
+
+	mockPool.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).
+		WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mockPool.ExpectQuery(`SELECT version, applied_at FROM schema_migrations`).
+		WillReturnRows(pgxmock.NewRows([]string{"version", "applied_at"}).
+			AddRow(int64(1), time.Now()).
+			AddRow(int64(2), time.Now()))
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(`DROP TABLE books`).
+		WillReturnResult(pgxmock.NewResult("DROP", 0))
+	mockPool.ExpectExec(`DELETE FROM schema_migrations WHERE version = \$1`).
+		WithArgs(int64(2)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockPool.ExpectCommit()
+
+	rolledBack, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, rolledBack, 1)
+	assert.Equal(t, int64(2), rolledBack[0].Version)
+	assert.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS books (
+    id           SERIAL PRIMARY KEY,
+    title        TEXT NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    published_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    author_id    INTEGER NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+    price        NUMERIC(10, 2) NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS books_author_id_idx ON books (author_id);
+CREATE INDEX IF NOT EXISTS books_published_at_idx ON books (published_at DESC);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS books_price_id_idx;
+DROP INDEX IF EXISTS books_title_id_idx;
+DROP INDEX IF EXISTS books_published_at_id_idx;
+CREATE INDEX IF NOT EXISTS books_published_at_idx ON books (published_at DESC);
@@ -0,0 +1,5 @@
+-- Support keyset pagination of GET /books on every whitelisted sort field
+DROP INDEX IF EXISTS books_published_at_idx;
+CREATE INDEX IF NOT EXISTS books_published_at_id_idx ON books (published_at, id);
+CREATE INDEX IF NOT EXISTS books_title_id_idx ON books (title, id);
+CREATE INDEX IF NOT EXISTS books_price_id_idx ON books (price, id);
@@ -0,0 +1,16 @@
+-- Authors created by an import have no birthdate, and making up one would
+-- pass it off as real, so the rollback stops while there are any. Give
+-- them a birthdate or delete them first.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM authors WHERE birthdate IS NULL) THEN
+        RAISE EXCEPTION 'cannot roll back migration 0004: there are authors without a birthdate; set their birthdate or delete them first';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS authors_lower_name_idx;
+ALTER TABLE authors ALTER COLUMN birthdate SET NOT NULL;
+
+DROP INDEX IF EXISTS books_google_id_key;
+ALTER TABLE books DROP COLUMN IF EXISTS google_id;
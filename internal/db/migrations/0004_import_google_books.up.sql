@@ -0,0 +1,8 @@
+-- Books imported from Google Books remember their volume so that importing
+-- it again updates the book instead of creating a duplicate.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS google_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS books_google_id_key ON books (google_id);
+
+-- Authors created by an import have no known birthdate.
+ALTER TABLE authors ALTER COLUMN birthdate DROP NOT NULL;
+CREATE INDEX IF NOT EXISTS authors_lower_name_idx ON authors (lower(name));
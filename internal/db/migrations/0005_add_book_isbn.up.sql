@@ -0,0 +1,4 @@
+-- Books carry their ISBN-13, normalized without hyphens. ISBN-10s are
+-- converted before they are stored, so one column covers both.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS isbn TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS books_isbn_key ON books (isbn);
@@ -0,0 +1,2 @@
+-- books.author_id still holds every book's primary author.
+DROP TABLE IF EXISTS book_authors;
@@ -0,0 +1,18 @@
+-- Books may credit several authors, each in a role and at a position.
+-- books.author_id keeps the primary author during the deprecation window of
+-- the author_id field.
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id   INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    author_id INTEGER NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+    role      TEXT NOT NULL DEFAULT 'author'
+        CHECK (role IN ('author', 'editor', 'translator', 'illustrator')),
+    position  INTEGER NOT NULL,
+    PRIMARY KEY (book_id, position),
+    UNIQUE (book_id, author_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS book_authors_author_id_idx ON book_authors (author_id);
+
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT id, author_id, 'author', 0 FROM books
+ON CONFLICT DO NOTHING;
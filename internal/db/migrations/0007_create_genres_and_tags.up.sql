@@ -0,0 +1,32 @@
+-- Genres form a tree: a genre may have a parent genre. A genre with
+-- subgenres cannot be deleted; deleting a genre or a tag unfiles its books.
+CREATE TABLE IF NOT EXISTS genres (
+    id        SERIAL PRIMARY KEY,
+    slug      TEXT NOT NULL UNIQUE,
+    name      TEXT NOT NULL,
+    parent_id INTEGER REFERENCES genres (id) ON DELETE RESTRICT,
+    CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS genres_parent_id_idx ON genres (parent_id);
+
+CREATE TABLE IF NOT EXISTS book_genres (
+    book_id  INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    genre_id INTEGER NOT NULL REFERENCES genres (id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_genres_genre_id_idx ON book_genres (genre_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id   SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS book_tags (
+    book_id INTEGER NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    tag_id  INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (book_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS book_tags_tag_id_idx ON book_tags (tag_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS authors_name_trgm_idx;
+DROP INDEX IF EXISTS books_title_trgm_idx;
+ALTER TABLE authors DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE books DROP COLUMN IF EXISTS search_vector;
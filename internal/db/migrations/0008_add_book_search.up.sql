@@ -0,0 +1,18 @@
+-- Full-text search over the local catalog. Titles weigh more than
+-- descriptions; author names live in another table, so they get their own
+-- vector and are merged into the book's at query time.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', description), 'B')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS books_search_vector_idx ON books USING GIN (search_vector);
+
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', name)) STORED;
+CREATE INDEX IF NOT EXISTS authors_search_vector_idx ON authors USING GIN (search_vector);
+
+-- Trigram indexes back the fuzzy fallback for queries with typos.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS books_title_trgm_idx ON books USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS authors_name_trgm_idx ON authors USING GIN (name gin_trgm_ops);
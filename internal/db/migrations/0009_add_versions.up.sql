@@ -0,0 +1,4 @@
+-- Every write bumps the row's version, which the API exposes as its ETag
+-- so that clients can make updates conditional on it (If-Match).
+ALTER TABLE books ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,21 @@
+-- Soft-deleted rows would come back to life once deleted_at is dropped, so
+-- the rollback stops while there are any. Purge or restore them first.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM books WHERE deleted_at IS NOT NULL)
+        OR EXISTS (SELECT 1 FROM authors WHERE deleted_at IS NOT NULL) THEN
+        RAISE EXCEPTION 'cannot roll back migration 0010: there are deleted books or authors; purge or restore them first';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS authors_deleted_at_idx;
+DROP INDEX IF EXISTS books_deleted_at_idx;
+ALTER TABLE authors
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at;
+ALTER TABLE books
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,15 @@
+-- Books and authors record when they were created and last written.
+-- Deleting them only sets deleted_at; the purge job removes them for good
+-- once they have been deleted for longer than the retention period.
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE authors
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- The purge job looks up the deleted rows only
+CREATE INDEX IF NOT EXISTS books_deleted_at_idx ON books (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS authors_deleted_at_idx ON authors (deleted_at) WHERE deleted_at IS NOT NULL;
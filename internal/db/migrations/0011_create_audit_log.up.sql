@@ -0,0 +1,17 @@
+-- Every write to a book or author through the repositories records a row
+-- in the same transaction. revision is the version the write left the
+-- entity at, and changes maps each changed field to its value before and
+-- after. Rows are kept when the entity is purged, so there is no foreign
+-- key.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id         BIGSERIAL PRIMARY KEY,
+    entity     TEXT NOT NULL CHECK (entity IN ('book', 'author')),
+    entity_id  INTEGER NOT NULL,
+    revision   INTEGER NOT NULL,
+    action     TEXT NOT NULL,
+    actor      TEXT NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    changes    JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity, entity_id, id);
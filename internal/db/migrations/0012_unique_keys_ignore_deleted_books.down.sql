@@ -0,0 +1,22 @@
+-- The unique indexes would again count deleted books, so the rollback
+-- stops rather than dropping a deleted book that shares its ISBN or Google
+-- volume with another book. Purge or change those books first.
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1
+        FROM books deleted
+        JOIN books other ON other.id <> deleted.id
+            AND (other.isbn = deleted.isbn OR other.google_id = deleted.google_id)
+        WHERE deleted.deleted_at IS NOT NULL
+    ) THEN
+        RAISE EXCEPTION 'cannot roll back migration 0012: deleted books share an ISBN or Google volume with other books; purge them first';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS books_google_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS books_google_id_key ON books (google_id);
+
+DROP INDEX IF EXISTS books_isbn_key;
+CREATE UNIQUE INDEX IF NOT EXISTS books_isbn_key ON books (isbn);
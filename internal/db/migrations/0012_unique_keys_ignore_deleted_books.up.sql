@@ -0,0 +1,8 @@
+-- A deleted book no longer holds on to its ISBN or Google volume: another
+-- book can be created with them, and the deleted one can be restored only
+-- once that book is gone.
+DROP INDEX IF EXISTS books_isbn_key;
+CREATE UNIQUE INDEX IF NOT EXISTS books_isbn_key ON books (isbn) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS books_google_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS books_google_id_key ON books (google_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL schema migrations.
+//
+// Every migration is a pair of files named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, e.g. 0001_create_authors.up.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -3,8 +3,22 @@ package entities
 import "time"
 
 type Author struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Bio       string    `json:"bio"`
-	BirthDate time.Time `json:"birthdate"`
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Bio  string `json:"bio"`
+	// BirthDate is zero for authors created by an import; it is then
+	// omitted from JSON.
+	BirthDate time.Time `json:"birthdate,omitzero"`
+	// Version is bumped by every write. It is the author's ETag.
+	Version int `json:"version"`
+	// CreatedAt, UpdatedAt and DeletedAt are kept like the book's.
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// AuthorWithBooks is an author together with their bibliography.
+type AuthorWithBooks struct {
+	Author
+	Books []*Book `json:"books"`
 }
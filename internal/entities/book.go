@@ -7,6 +7,31 @@ type Book struct {
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
 	PublishedAt time.Time `json:"published_at"`
-	AuthorID    int       `json:"author_id"`
-	Price       float64   `json:"price"`
+	// AuthorID is the primary author, the first contributor credited as
+	// author.
+	//
+	// Deprecated: use Contributors. AuthorID is still accepted for books
+	// without contributors and still returned during the deprecation window.
+	AuthorID int     `json:"author_id"`
+	Price    float64 `json:"price"`
+	// ISBN13 is stored normalized, without hyphens. ISBN10 is derived from
+	// it and is empty for 979-prefixed ISBNs, which have no ISBN-10.
+	ISBN10 string `json:"isbn_10,omitempty"`
+	ISBN13 string `json:"isbn_13,omitempty"`
+	// Contributors lists the book's authors, editors, translators and
+	// illustrators in credit order.
+	Contributors []Contributor `json:"contributors"`
+	// Genres holds the slugs of the book's genres and Tags its tags, both
+	// sorted.
+	Genres []string `json:"genres"`
+	Tags   []string `json:"tags"`
+	// GoogleID is the Google Books volume the book was imported from.
+	GoogleID string `json:"google_id,omitempty"`
+	// Version is bumped by every write. It is the book's ETag.
+	Version int `json:"version"`
+	// CreatedAt and UpdatedAt are set by the repository. DeletedAt is set
+	// while the book is deleted and can still be restored.
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
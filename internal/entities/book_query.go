@@ -0,0 +1,78 @@
+package entities
+
+import "time"
+
+// BookSortField is a column GET /books may be sorted by.
+type BookSortField string
+
+const (
+	SortByTitle       BookSortField = "title"
+	SortByPrice       BookSortField = "price"
+	SortByPublishedAt BookSortField = "published_at"
+)
+
+const (
+	DefaultBookLimit = 20
+	MaxBookLimit     = 100
+)
+
+// Valid reports whether the field is one of the whitelisted sort fields.
+func (f BookSortField) Valid() bool {
+	switch f {
+	case SortByTitle, SortByPrice, SortByPublishedAt:
+		return true
+	}
+	return false
+}
+
+// BookQuery describes which page of books to return and how to filter
+// and order them. Zero values mean "no filter".
+type BookQuery struct {
+	Limit  int
+	Offset int
+	// Cursor is the opaque NextCursor of a previous page. When set it
+	// takes precedence over Offset.
+	Cursor string
+
+	Sort BookSortField
+	Desc bool
+
+	AuthorID        int
+	MinPrice        *float64
+	MaxPrice        *float64
+	PublishedAfter  *time.Time
+	PublishedBefore *time.Time
+
+	// Genre is a genre slug; books in its subgenres match too.
+	Genre string
+	// Tags lists tags that matching books all carry.
+	Tags []string
+}
+
+// WithDefaults fills in the default page size and ordering (newest first)
+// and clamps the limit to MaxBookLimit.
+func (q BookQuery) WithDefaults() BookQuery {
+	if q.Limit <= 0 {
+		q.Limit = DefaultBookLimit
+	}
+	if q.Limit > MaxBookLimit {
+		q.Limit = MaxBookLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	if q.Sort == "" {
+		q.Sort = SortByPublishedAt
+		q.Desc = true
+	}
+	return q
+}
+
+// BookPage is one page of a book listing.
+type BookPage struct {
+	Items      []*Book `json:"items"`
+	Total      int     `json:"total"`
+	Limit      int     `json:"limit"`
+	Offset     int     `json:"offset"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
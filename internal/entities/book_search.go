@@ -0,0 +1,45 @@
+package entities
+
+// BookSearchQuery is a full-text search over the local catalog.
+type BookSearchQuery struct {
+	// Query uses web search syntax: quoted phrases, OR and -excluded words.
+	Query  string
+	Limit  int
+	Offset int
+	// Fuzzy falls back to trigram matching on titles and author names when
+	// the full-text search finds nothing, e.g. because of a typo.
+	Fuzzy bool
+}
+
+// WithDefaults fills in the default page size and clamps the limit to
+// MaxBookLimit, like BookQuery.WithDefaults.
+func (q BookSearchQuery) WithDefaults() BookSearchQuery {
+	if q.Limit <= 0 {
+		q.Limit = DefaultBookLimit
+	}
+	if q.Limit > MaxBookLimit {
+		q.Limit = MaxBookLimit
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+	return q
+}
+
+// BookSearchHit is a book matching a search, with its relevance and an
+// excerpt in which the matching words are wrapped in <mark> tags.
+type BookSearchHit struct {
+	Book
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// BookSearchPage is one page of search hits, best first.
+type BookSearchPage struct {
+	Items  []*BookSearchHit `json:"items"`
+	Total  int              `json:"total"`
+	Limit  int              `json:"limit"`
+	Offset int              `json:"offset"`
+	// Fuzzy reports that the hits come from the trigram fallback.
+	Fuzzy bool `json:"fuzzy"`
+}
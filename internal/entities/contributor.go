@@ -0,0 +1,50 @@
+package entities
+
+import "slices"
+
+// ContributorRole is the part an author played in a book.
+type ContributorRole string
+
+const (
+	RoleAuthor      ContributorRole = "author"
+	RoleEditor      ContributorRole = "editor"
+	RoleTranslator  ContributorRole = "translator"
+	RoleIllustrator ContributorRole = "illustrator"
+)
+
+// Valid reports whether the role is one of the known roles.
+func (r ContributorRole) Valid() bool {
+	switch r {
+	case RoleAuthor, RoleEditor, RoleTranslator, RoleIllustrator:
+		return true
+	}
+	return false
+}
+
+// Contributor is an author credited on a book. Contributors are listed in
+// the order the book credits them.
+type Contributor struct {
+	AuthorID int `json:"author_id"`
+	// Name is filled in when books are read and ignored when written.
+	Name string          `json:"name,omitempty"`
+	Role ContributorRole `json:"role"`
+}
+
+// PrimaryAuthorID returns the first contributor credited as author, or 0
+// when there is none.
+func PrimaryAuthorID(contributors []Contributor) int {
+	for _, c := range contributors {
+		if c.Role == RoleAuthor {
+			return c.AuthorID
+		}
+	}
+	return 0
+}
+
+// SameCredits reports whether a and b credit the same authors in the same
+// roles and order. Names are not compared.
+func SameCredits(a, b []Contributor) bool {
+	return slices.EqualFunc(a, b, func(x, y Contributor) bool {
+		return x.AuthorID == y.AuthorID && x.Role == y.Role
+	})
+}
@@ -0,0 +1,25 @@
+package entities
+
+// ExternalBook is a book found in an external catalog such as Google Books
+// or Open Library. A book reported by several catalogs is merged into one
+// entry listing every source.
+type ExternalBook struct {
+	// Sources maps each catalog that reported the book to its ID there.
+	Sources     map[string]string `json:"sources"`
+	Title       string            `json:"title"`
+	Authors     []string          `json:"authors,omitempty"`
+	Description string            `json:"description,omitempty"`
+	// PublishedDate is "2006", "2006-01" or "2006-01-02".
+	PublishedDate string `json:"published_date,omitempty"`
+	// ISBNs are normalized to ISBN-13.
+	ISBNs []string `json:"isbns,omitempty"`
+}
+
+// ExternalSearchResult is the merged answer of the catalogs queried by an
+// external search.
+type ExternalSearchResult struct {
+	Items []ExternalBook `json:"items"`
+	// Errors maps each catalog that failed to the reason, so a slow or
+	// broken catalog does not hide the others' results.
+	Errors map[string]string `json:"errors,omitempty"`
+}
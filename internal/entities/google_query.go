@@ -0,0 +1,61 @@
+package entities
+
+// GoogleOrderBy is an order Google Books can sort search results in.
+type GoogleOrderBy string
+
+const (
+	GoogleOrderRelevance GoogleOrderBy = "relevance"
+	GoogleOrderNewest    GoogleOrderBy = "newest"
+)
+
+// Valid reports whether the order is one Google Books accepts.
+func (o GoogleOrderBy) Valid() bool {
+	return o == GoogleOrderRelevance || o == GoogleOrderNewest
+}
+
+const (
+	DefaultGoogleMaxResults = 10
+	// MaxGoogleMaxResults is the largest page Google Books serves.
+	MaxGoogleMaxResults = 40
+)
+
+// GoogleBookQuery is a Google Books search. Query is free text; Title,
+// Author and ISBN become Google's intitle:, inauthor: and isbn: qualifiers.
+// At least one of the four is required.
+type GoogleBookQuery struct {
+	Query  string
+	Title  string
+	Author string
+	ISBN   string
+
+	StartIndex int
+	MaxResults int
+	// LangRestrict limits results to an ISO 639-1 language, e.g. "en".
+	LangRestrict string
+	OrderBy      GoogleOrderBy
+}
+
+// WithDefaults fills in the default page size and clamps it to
+// MaxGoogleMaxResults. An empty OrderBy leaves Google's relevance order.
+func (q GoogleBookQuery) WithDefaults() GoogleBookQuery {
+	if q.MaxResults <= 0 {
+		q.MaxResults = DefaultGoogleMaxResults
+	}
+	if q.MaxResults > MaxGoogleMaxResults {
+		q.MaxResults = MaxGoogleMaxResults
+	}
+	if q.StartIndex < 0 {
+		q.StartIndex = 0
+	}
+	return q
+}
+
+// GoogleBookPage is one page of Google Books search results. Its fields
+// follow the Google Books naming, like the volumes it holds.
+type GoogleBookPage struct {
+	Items []GoogleBook `json:"items"`
+	// TotalItems is Google's estimate of the number of matches.
+	TotalItems int `json:"totalItems"`
+	StartIndex int `json:"startIndex"`
+	MaxResults int `json:"maxResults"`
+}
@@ -1,10 +1,64 @@
 package entities
 
 type GoogleBook struct {
-	ID         string `json:"id"`
-	VolumeInfo struct {
-		Title       string   `json:"title"`
-		Authors     []string `json:"authors"`
-		Description string   `json:"description"`
-	} `json:"volumeInfo"`
+	ID         string           `json:"id"`
+	VolumeInfo GoogleVolumeInfo `json:"volumeInfo"`
+	SaleInfo   GoogleSaleInfo   `json:"saleInfo"`
+}
+
+type GoogleVolumeInfo struct {
+	Title       string   `json:"title"`
+	Authors     []string `json:"authors"`
+	Publisher   string   `json:"publisher,omitempty"`
+	Description string   `json:"description"`
+	// PublishedDate is "2006", "2006-01" or "2006-01-02".
+	PublishedDate string `json:"publishedDate,omitempty"`
+	// IndustryIdentifiers lists the volume's ISBNs among other IDs.
+	IndustryIdentifiers []GoogleIdentifier `json:"industryIdentifiers,omitempty"`
+	PageCount           int                `json:"pageCount,omitempty"`
+	Categories          []string           `json:"categories,omitempty"`
+	ImageLinks          *GoogleImageLinks  `json:"imageLinks,omitempty"`
+	// Language is an ISO 639-1 code such as "en".
+	Language string `json:"language,omitempty"`
+}
+
+// Identifier types Google Books reports in IndustryIdentifiers.
+const (
+	GoogleISBN10 = "ISBN_10"
+	GoogleISBN13 = "ISBN_13"
+)
+
+type GoogleIdentifier struct {
+	// Type is "ISBN_10", "ISBN_13", "ISSN" or "OTHER".
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+}
+
+// Identifier returns the first identifier of the given type, or "".
+func (v GoogleVolumeInfo) Identifier(kind string) string {
+	for _, id := range v.IndustryIdentifiers {
+		if id.Type == kind {
+			return id.Identifier
+		}
+	}
+	return ""
+}
+
+type GoogleImageLinks struct {
+	SmallThumbnail string `json:"smallThumbnail,omitempty"`
+	Thumbnail      string `json:"thumbnail,omitempty"`
+}
+
+type GoogleSaleInfo struct {
+	// Saleability is e.g. "FOR_SALE", "FREE" or "NOT_FOR_SALE".
+	Saleability string `json:"saleability,omitempty"`
+	// ListPrice and RetailPrice are missing for volumes that are not for
+	// sale.
+	ListPrice   *GooglePrice `json:"listPrice,omitempty"`
+	RetailPrice *GooglePrice `json:"retailPrice,omitempty"`
+}
+
+type GooglePrice struct {
+	Amount       float64 `json:"amount"`
+	CurrencyCode string  `json:"currencyCode"`
 }
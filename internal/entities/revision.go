@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction is the kind of write a revision records.
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+	// AuditRevert is an update taking the entity back to an earlier
+	// revision.
+	AuditRevert AuditAction = "revert"
+	// AuditPurge removes a deleted entity for good.
+	AuditPurge AuditAction = "purge"
+)
+
+// Revision is a recorded write of a book or author: who made it, when and
+// what it changed. Revision is the version the write left the entity at.
+type Revision struct {
+	Entity    string      `json:"entity"`
+	EntityID  int         `json:"entity_id"`
+	Revision  int         `json:"revision"`
+	Action    AuditAction `json:"action"`
+	Actor     string      `json:"actor"`
+	ChangedAt time.Time   `json:"changed_at"`
+	// Changes holds the fields the write changed, by their JSON name. It
+	// is empty for deletes, restores and purges.
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+// FieldChange is the JSON value of a field before and after a write. A
+// field without a value, like any field before a create, is null.
+type FieldChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
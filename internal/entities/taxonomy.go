@@ -0,0 +1,23 @@
+package entities
+
+// Genre is a node of the genre taxonomy. Books are filed under genres by
+// slug; a book in a subgenre also counts as being in its ancestors.
+type Genre struct {
+	ID   int    `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+	// ParentID is nil for top-level genres.
+	ParentID *int `json:"parent_id"`
+	// BookCount is the number of books in the genre or any of its
+	// subgenres. It is filled in when genres are read.
+	BookCount int `json:"book_count"`
+}
+
+// Tag is a free-form label on books, e.g. "award-winner".
+type Tag struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// BookCount is the number of books with the tag. It is filled in when
+	// tags are read.
+	BookCount int `json:"book_count"`
+}
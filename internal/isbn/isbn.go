@@ -0,0 +1,109 @@
+// Package isbn validates International Standard Book Numbers and converts
+// them to a canonical ISBN-13 form so that editions reported by different
+// catalogs can be compared.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned for strings that are not a valid ISBN-10 or
+// ISBN-13, including ones whose check digit does not match.
+var ErrInvalid = errors.New("invalid ISBN")
+
+// Normalize returns s as a 13-digit ISBN without separators. Hyphens and
+// spaces are ignored and ISBN-10s are converted to their 978-prefixed
+// ISBN-13.
+func Normalize(s string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ':
+			return -1
+		case 'x':
+			return 'X'
+		}
+		return r
+	}, s)
+
+	switch len(digits) {
+	case 10:
+		if !validISBN10(digits) {
+			return "", ErrInvalid
+		}
+		base := "978" + digits[:9]
+		return base + string(checkDigit13(base)), nil
+	case 13:
+		if !validISBN13(digits) {
+			return "", ErrInvalid
+		}
+		return digits, nil
+	}
+	return "", ErrInvalid
+}
+
+// Valid reports whether s is a valid ISBN-10 or ISBN-13.
+func Valid(s string) bool {
+	_, err := Normalize(s)
+	return err == nil
+}
+
+// ToISBN10 returns the ISBN-10 of a normalized ISBN-13. Only 978-prefixed
+// ISBNs have one; ok is false for the others.
+func ToISBN10(isbn13 string) (isbn10 string, ok bool) {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return "", false
+	}
+	base := isbn13[3:12]
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += (10 - i) * int(base[i]-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return base + "X", true
+	}
+	return base + string(byte('0'+check)), true
+}
+
+func validISBN10(s string) bool {
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var d int
+		switch c := s[i]; {
+		case c >= '0' && c <= '9':
+			d = int(c - '0')
+		case c == 'X' && i == 9:
+			d = 10
+		default:
+			return false
+		}
+		sum += (10 - i) * d
+	}
+	return sum%11 == 0
+}
+
+func validISBN13(s string) bool {
+	if !strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979") {
+		return false
+	}
+	for i := 0; i < 13; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return checkDigit13(s[:12]) == s[12]
+}
+
+// checkDigit13 returns the ISBN-13 check digit for the first 12 digits.
+func checkDigit13(s string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(s[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,64 @@
+package isbn_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/isbn"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "isbn-13", input: "9780441172719", want: "9780441172719"},
+		{name: "isbn-13 with hyphens", input: "978-0-441-17271-9", want: "9780441172719"},
+		{name: "isbn-10 converted", input: "0441172717", want: "9780441172719"},
+		{name: "isbn-10 with X check digit", input: "0-8044-2957-x", want: "9780804429573"},
+		{name: "979 prefix", input: "979-10-90636-07-1", want: "9791090636071"},
+		{name: "wrong isbn-13 check digit", input: "9780441172710", wantErr: true},
+		{name: "wrong isbn-10 check digit", input: "0441172718", wantErr: true},
+		{name: "X not in last place", input: "04411727X7", wantErr: true},
+		{name: "unknown prefix", input: "9770441172719", wantErr: true},
+		{name: "wrong length", input: "12345", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isbn.Normalize(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, isbn.ErrInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToISBN10(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{name: "978 prefix", input: "9780441172719", want: "0441172717", wantOK: true},
+		{name: "X check digit", input: "9780804429573", want: "080442957X", wantOK: true},
+		{name: "979 prefix has none", input: "9791090636071"},
+		{name: "not normalized", input: "978-0-441-17271-9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := isbn.ToISBN10(tt.input)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
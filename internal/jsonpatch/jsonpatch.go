@@ -0,0 +1,106 @@
+// Package jsonpatch applies the two patch formats clients may send to
+// change part of a resource: JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902). Both operate on the JSON representation of the resource.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Media types of the supported patch formats.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupportedType is returned by Parse for any media type other
+	// than MergePatchType and JSONPatchType.
+	ErrUnsupportedType = errors.New("unsupported patch media type")
+	// ErrInvalid means the patch document itself is malformed.
+	ErrInvalid = errors.New("invalid patch")
+	// ErrCannotApply means the patch is well-formed but does not fit the
+	// document, e.g. it names a path that does not exist or a test
+	// operation failed.
+	ErrCannotApply = errors.New("patch cannot be applied")
+)
+
+// Patch changes a JSON document.
+type Patch interface {
+	// Apply returns the patched document. doc is not modified.
+	Apply(doc []byte) ([]byte, error)
+}
+
+// Parse decodes a patch document of the given media type.
+func Parse(mediaType string, data []byte) (Patch, error) {
+	switch mediaType {
+	case MergePatchType:
+		return ParseMergePatch(data)
+	case JSONPatchType:
+		return ParseOperations(data)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnsupportedType, mediaType)
+}
+
+// MergePatch is a JSON Merge Patch: an object whose members replace those
+// of the document, recursively, with null removing a member.
+type MergePatch struct {
+	patch any
+}
+
+// ParseMergePatch decodes a JSON Merge Patch.
+func ParseMergePatch(data []byte) (*MergePatch, error) {
+	patch, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return &MergePatch{patch: patch}, nil
+}
+
+func (p *MergePatch) Apply(doc []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, p.patch))
+}
+
+// merge implements the MergePatch algorithm of RFC 7396, section 2.
+func merge(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = make(map[string]any, len(members))
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = merge(object[name], value)
+		}
+	}
+	return object
+}
+
+// decode decodes a single JSON value, keeping numbers as json.Number so
+// that they survive a round trip unchanged.
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.Decode(&struct{}{}) != io.EOF {
+		return nil, errors.New("trailing data after JSON value")
+	}
+	return v, nil
+}
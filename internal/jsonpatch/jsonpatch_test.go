@@ -0,0 +1,205 @@
+package jsonpatch_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples of RFC 7396, appendix A
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+	}{
+		{name: "replace member", doc: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "add member", doc: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{name: "remove member", doc: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{name: "remove one of two", doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{name: "array replaced", doc: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{name: "nested merge", doc: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{name: "arrays are not merged", doc: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{name: "non-object patch replaces", doc: `{"a":"foo"}`, patch: `"bar"`, want: `"bar"`},
+		{name: "nulls inside new objects are dropped", doc: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+		{name: "numbers kept as written", doc: `{"price":12.50,"n":1}`, patch: `{"n":2}`, want: `{"price":12.50,"n":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := jsonpatch.Parse(jsonpatch.MergePatchType, []byte(tt.patch))
+			require.NoError(t, err)
+
+			got, err := patch.Apply([]byte(tt.doc))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "add member",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:  `{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			name:  "add into array",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "append to array",
+			doc:   `{"tags":["a"]}`,
+			patch: `[{"op":"add","path":"/tags/-","value":"b"}]`,
+			want:  `{"tags":["a","b"]}`,
+		},
+		{
+			name:  "remove array element",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "replace member with null",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/baz","value":null}]`,
+			want:  `{"baz":null,"foo":"bar"}`,
+		},
+		{
+			name:  "move member",
+			doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:  "move array element",
+			doc:   `{"foo":["all","grass","cows","eat"]}`,
+			patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want:  `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:  "copy is not shared",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:  `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name:  "test then replace",
+			doc:   `{"price":10}`,
+			patch: `[{"op":"test","path":"/price","value":10.0},{"op":"replace","path":"/price","value":12}]`,
+			want:  `{"price":12}`,
+		},
+		{
+			name:  "escaped tokens",
+			doc:   `{"a/b":1,"m~n":2}`,
+			patch: `[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/m~0n","value":3}]`,
+			want:  `{"m~n":3}`,
+		},
+		{
+			name:  "replace the whole document",
+			doc:   `{"a":1}`,
+			patch: `[{"op":"replace","path":"","value":{"b":2}}]`,
+			want:  `{"b":2}`,
+		},
+		{
+			name:    "failed test",
+			doc:     `{"baz":"qux"}`,
+			patch:   `[{"op":"test","path":"/baz","value":"bar"}]`,
+			wantErr: jsonpatch.ErrCannotApply,
+		},
+		{
+			name:    "missing member",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"replace","path":"/baz","value":1}]`,
+			wantErr: jsonpatch.ErrCannotApply,
+		},
+		{
+			name:    "missing parent",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			wantErr: jsonpatch.ErrCannotApply,
+		},
+		{
+			name:    "index out of bounds",
+			doc:     `{"foo":["bar"]}`,
+			patch:   `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+			wantErr: jsonpatch.ErrCannotApply,
+		},
+		{
+			name:    "index with leading zero",
+			doc:     `{"foo":["bar","baz"]}`,
+			patch:   `[{"op":"remove","path":"/foo/01"}]`,
+			wantErr: jsonpatch.ErrCannotApply,
+		},
+		{
+			name:    "later failure undoes earlier operations",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"replace","path":"/a","value":2},{"op":"remove","path":"/b"}]`,
+			wantErr: jsonpatch.ErrCannotApply,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := jsonpatch.Parse(jsonpatch.JSONPatchType, []byte(tt.patch))
+			require.NoError(t, err)
+
+			doc := []byte(tt.doc)
+			got, err := patch.Apply(doc)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, tt.doc, string(doc))
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType string
+		patch     string
+		wantErr   error
+	}{
+		{name: "merge patch", mediaType: jsonpatch.MergePatchType, patch: `{"a":1}`},
+		{name: "malformed merge patch", mediaType: jsonpatch.MergePatchType, patch: `{"a":`, wantErr: jsonpatch.ErrInvalid},
+		{name: "trailing data", mediaType: jsonpatch.MergePatchType, patch: `{} {}`, wantErr: jsonpatch.ErrInvalid},
+		{name: "json patch", mediaType: jsonpatch.JSONPatchType, patch: `[{"op":"remove","path":"/a"}]`},
+		{name: "not an array", mediaType: jsonpatch.JSONPatchType, patch: `{"op":"remove","path":"/a"}`, wantErr: jsonpatch.ErrInvalid},
+		{name: "unknown op", mediaType: jsonpatch.JSONPatchType, patch: `[{"op":"frobnicate","path":"/a"}]`, wantErr: jsonpatch.ErrInvalid},
+		{name: "missing value", mediaType: jsonpatch.JSONPatchType, patch: `[{"op":"add","path":"/a"}]`, wantErr: jsonpatch.ErrInvalid},
+		{name: "null value", mediaType: jsonpatch.JSONPatchType, patch: `[{"op":"add","path":"/a","value":null}]`},
+		{name: "relative path", mediaType: jsonpatch.JSONPatchType, patch: `[{"op":"remove","path":"a"}]`, wantErr: jsonpatch.ErrInvalid},
+		{name: "bad escape", mediaType: jsonpatch.JSONPatchType, patch: `[{"op":"remove","path":"/a~2"}]`, wantErr: jsonpatch.ErrInvalid},
+		{name: "move into itself", mediaType: jsonpatch.JSONPatchType, patch: `[{"op":"move","from":"/a","path":"/a/b"}]`, wantErr: jsonpatch.ErrInvalid},
+		{name: "plain json", mediaType: "application/json", patch: `{"a":1}`, wantErr: jsonpatch.ErrUnsupportedType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jsonpatch.Parse(tt.mediaType, []byte(tt.patch))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
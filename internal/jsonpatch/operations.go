@@ -0,0 +1,339 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Operation is one step of a JSON Patch.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+
+	path, from pointer
+	value      any
+}
+
+// Operations is a JSON Patch: a list of operations applied in order. If
+// one fails, none of them is applied.
+type Operations []Operation
+
+// ParseOperations decodes a JSON Patch and checks that every operation is
+// known and has the members it requires.
+func ParseOperations(data []byte) (Operations, error) {
+	var ops Operations
+	if err := json.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	for i := range ops {
+		op := &ops[i]
+		var err error
+		if op.path, err = parsePointer(op.Path); err != nil {
+			return nil, fmt.Errorf("%w: operation %d: path: %v", ErrInvalid, i, err)
+		}
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d: %s requires a value", ErrInvalid, i, op.Op)
+			}
+			if op.value, err = decode(op.Value); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: value: %v", ErrInvalid, i, err)
+			}
+		case "move", "copy":
+			if op.from, err = parsePointer(op.From); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: from: %v", ErrInvalid, i, err)
+			}
+			if op.Op == "move" && op.from.isProperPrefixOf(op.path) {
+				return nil, fmt.Errorf("%w: operation %d: cannot move %q into itself", ErrInvalid, i, op.From)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w: operation %d: unknown op %q", ErrInvalid, i, op.Op)
+		}
+	}
+	return ops, nil
+}
+
+func (ops Operations) Apply(doc []byte) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		if root, err = op.apply(root); err != nil {
+			return nil, fmt.Errorf("%w: operation %d (%s %s): %v", ErrCannotApply, i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+// apply applies the operation to root and returns the new root, which is
+// only different from root when the operation replaces the whole document.
+func (op Operation) apply(root any) (any, error) {
+	switch op.Op {
+	case "add":
+		return add(root, op.path, clone(op.value))
+	case "remove":
+		root, _, err := remove(root, op.path)
+		return root, err
+	case "replace":
+		if len(op.path) == 0 {
+			return clone(op.value), nil
+		}
+		root, _, err := remove(root, op.path)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, clone(op.value))
+	case "move":
+		root, value, err := remove(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, value)
+	case "copy":
+		value, err := get(root, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return add(root, op.path, clone(value))
+	case "test":
+		value, err := get(root, op.path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.value) {
+			return nil, fmt.Errorf("value is not %s", op.Value)
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// get returns the value at p.
+func get(root any, p pointer) (any, error) {
+	node := root
+	for i, token := range p {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", p[:i+1])
+			}
+			node = child
+		case []any:
+			index, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", p[:i+1], err)
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("%s is not an object or array", p[:i])
+		}
+	}
+	return node, nil
+}
+
+// add inserts value at p: it sets an object member, or inserts into an
+// array before the given index or, with "-", at its end.
+func add(root any, p pointer, value any) (any, error) {
+	if len(p) == 0 {
+		return value, nil
+	}
+	return update(root, p, func(parent any, token string) (any, error) {
+		switch n := parent.(type) {
+		case map[string]any:
+			n[token] = value
+			return n, nil
+		case []any:
+			if token == "-" {
+				return append(n, value), nil
+			}
+			index, err := arrayIndex(token, len(n))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", p, err)
+			}
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = value
+			return n, nil
+		}
+		return nil, fmt.Errorf("%s is not an object or array", p[:len(p)-1])
+	})
+}
+
+// remove deletes the value at p and returns it.
+func remove(root any, p pointer) (any, any, error) {
+	if len(p) == 0 {
+		return nil, nil, fmt.Errorf("the whole document cannot be removed")
+	}
+	var removed any
+	root, err := update(root, p, func(parent any, token string) (any, error) {
+		switch n := parent.(type) {
+		case map[string]any:
+			value, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", p)
+			}
+			removed = value
+			delete(n, token)
+			return n, nil
+		case []any:
+			index, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", p, err)
+			}
+			removed = n[index]
+			return append(n[:index], n[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("%s is not an object or array", p[:len(p)-1])
+	})
+	return root, removed, err
+}
+
+// update walks to the parent of the last token of p, which must not be
+// empty, and replaces it with what change returns. Arrays change length,
+// so each container on the way is stored again in its own parent.
+func update(node any, p pointer, change func(parent any, token string) (any, error)) (any, error) {
+	if len(p) == 1 {
+		return change(node, p[0])
+	}
+
+	child, err := get(node, p[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = update(child, p[1:], change)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := node.(type) {
+	case map[string]any:
+		n[p[0]] = child
+	case []any:
+		index, _ := arrayIndex(p[0], len(n)-1)
+		n[index] = child
+	}
+	return node, nil
+}
+
+// arrayIndex parses an array index token, which must be at most max.
+func arrayIndex(token string, max int) (int, error) {
+	// Leading zeros are not allowed, and neither are signs
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%q is not an array index", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > max {
+		return 0, fmt.Errorf("index %s is out of bounds", token)
+	}
+	return index, nil
+}
+
+// equal compares two decoded JSON values. Numbers are equal when their
+// values are, however they are written.
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// clone deep-copies a decoded JSON value, so that a value added twice,
+// or copied, is not shared between two places in the document.
+func clone(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for name, value := range v {
+			c[name] = clone(value)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, value := range v {
+			c[i] = clone(value)
+		}
+		return c
+	}
+	return v
+}
+
+// pointer is a JSON Pointer (RFC 6901) split into its unescaped tokens.
+// The empty pointer refers to the whole document.
+type pointer []string
+
+func parsePointer(s string) (pointer, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, fmt.Errorf("%q must be empty or start with /", s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		// ~0 must be unescaped last, so that ~01 becomes ~1 and not /
+		unescaped := strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if strings.Count(token, "~") != strings.Count(token, "~0")+strings.Count(token, "~1") {
+			return nil, fmt.Errorf("%q has an invalid escape", s)
+		}
+		tokens[i] = unescaped
+	}
+	return tokens, nil
+}
+
+func (p pointer) isProperPrefixOf(other pointer) bool {
+	return len(p) < len(other) && reflect.DeepEqual([]string(p), []string(other[:len(p)]))
+}
+
+func (p pointer) String() string {
+	var b strings.Builder
+	for _, token := range p {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	if b.Len() == 0 {
+		return "the document"
+	}
+	return b.String()
+}
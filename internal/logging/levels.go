@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Levels holds the default log level and per-component overrides. It is
+// safe for concurrent use, so levels can be changed while serving.
+type Levels struct {
+	mu         sync.RWMutex
+	fallback   slog.Level
+	components map[string]slog.Level
+}
+
+// NewLevels returns levels logging at fallback for every component.
+func NewLevels(fallback slog.Level) *Levels {
+	return &Levels{fallback: fallback, components: make(map[string]slog.Level)}
+}
+
+// Level returns the level of component, or the default level when it has
+// no override.
+func (l *Levels) Level(component string) slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if level, ok := l.components[component]; ok {
+		return level
+	}
+	return l.fallback
+}
+
+// SetDefault changes the level of components without an override.
+func (l *Levels) SetDefault(level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fallback = level
+}
+
+// Set overrides the level of component.
+func (l *Levels) Set(component string, level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components[component] = level
+}
+
+// Reset removes the override of component.
+func (l *Levels) Reset(component string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.components, component)
+}
+
+// Snapshot returns the default level and a copy of the overrides.
+func (l *Levels) Snapshot() (slog.Level, map[string]slog.Level) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	components := make(map[string]slog.Level, len(l.components))
+	for name, level := range l.components {
+		components[name] = level
+	}
+	return l.fallback, components
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN".
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// ParseLevels parses per-component levels written as
+// "storage=debug,service=warn".
+func ParseLevels(spec string) (map[string]slog.Level, error) {
+	out := make(map[string]slog.Level)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid component level %q (want component=level)", part)
+		}
+		level, err := ParseLevel(raw)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", name, err)
+		}
+		out[name] = level
+	}
+	return out, nil
+}
@@ -0,0 +1,105 @@
+// Package logging builds the application's slog loggers.
+//
+// Every logger created by New filters records by the level of its
+// component, set with Component, so that e.g. the storage layer can log at
+// debug level while everything else stays at info. Levels can be changed at
+// runtime through Levels. Records logged with a context carrying a request
+// ID (see WithRequestID) include it as request_id, and records logged
+// within a span include its trace_id.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ComponentKey is the attribute naming the package a logger belongs to.
+const ComponentKey = "component"
+
+// RequestIDKey is the attribute holding the request ID of a record.
+const RequestIDKey = "request_id"
+
+// TraceIDKey is the attribute holding the trace ID of a record logged
+// within a span.
+const TraceIDKey = "trace_id"
+
+// lowest lets every record through the wrapped handler; filtering is done
+// by the component levels.
+const lowest = slog.Level(-1 << 10)
+
+// New returns a logger writing to w in the given format ("text" or "json")
+// and filtering records with levels.
+func New(w io.Writer, format string, levels *Levels) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: lowest}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "text", "":
+		h = slog.NewTextHandler(w, opts)
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want text or json)", format)
+	}
+
+	return slog.New(&handler{next: h, levels: levels}), nil
+}
+
+// Component returns a logger for the named component (e.g. "storage").
+func Component(logger *slog.Logger, name string) *slog.Logger {
+	return logger.With(ComponentKey, name)
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// handler filters records by the level of its component and adds the
+// request ID from the context.
+type handler struct {
+	next      slog.Handler
+	levels    *Levels
+	component string
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.levels.Level(h.component)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String(TraceIDKey, sc.TraceID().String()))
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	component := h.component
+	for _, a := range attrs {
+		if a.Key == ComponentKey {
+			component = a.Value.String()
+		}
+	}
+	return &handler{next: h.next.WithAttrs(attrs), levels: h.levels, component: component}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), levels: h.levels, component: h.component}
+}
@@ -0,0 +1,93 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+)
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		out = append(out, rec)
+	}
+	return out
+}
+
+func TestLogger_ComponentLevels(t *testing.T) {
+	var buf bytes.Buffer
+	levels := logging.NewLevels(slog.LevelInfo)
+	levels.Set("storage", slog.LevelDebug)
+	logger, err := logging.New(&buf, "json", levels)
+	require.NoError(t, err)
+
+	storage := logging.Component(logger, "storage")
+	service := logging.Component(logger, "service")
+
+	storage.Debug("storage debug")
+	service.Debug("service debug")
+	service.Info("service info")
+
+	// Levels apply to loggers that already exist
+	levels.Reset("storage")
+	levels.Set("service", slog.LevelError)
+	storage.Debug("storage debug after reset")
+	service.Info("service info after change")
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 2)
+	assert.Equal(t, "storage debug", recs[0]["msg"])
+	assert.Equal(t, "storage", recs[0][logging.ComponentKey])
+	assert.Equal(t, "service info", recs[1]["msg"])
+}
+
+func TestLogger_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", logging.NewLevels(slog.LevelInfo))
+	require.NoError(t, err)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	logger.InfoContext(ctx, "with id")
+	logger.Info("without id")
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 2)
+	assert.Equal(t, "req-1", recs[0][logging.RequestIDKey])
+	assert.Equal(t, traceID.String(), recs[0][logging.TraceIDKey])
+	assert.NotContains(t, recs[1], logging.RequestIDKey)
+	assert.NotContains(t, recs[1], logging.TraceIDKey)
+}
+
+func TestNew_UnknownFormat(t *testing.T) {
+	_, err := logging.New(&bytes.Buffer{}, "xml", logging.NewLevels(slog.LevelInfo))
+	assert.Error(t, err)
+}
+
+func TestParseLevels(t *testing.T) {
+	levels, err := logging.ParseLevels("storage=debug, service=WARN,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]slog.Level{"storage": slog.LevelDebug, "service": slog.LevelWarn}, levels)
+
+	for _, spec := range []string{"storage", "=debug", "storage=loud"} {
+		_, err := logging.ParseLevels(spec)
+		assert.Error(t, err, spec)
+	}
+}
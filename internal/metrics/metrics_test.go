@@ -0,0 +1,94 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/metrics"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestMiddleware_LabelsByRoutePattern(t *testing.T) {
+	m := metrics.New()
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	for _, path := range []string{"/books/1", "/books/2", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `bookapi_http_requests_total{method="GET",route="/books/{id}",status="404"} 2`)
+	assert.Contains(t, out, `bookapi_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, out, `bookapi_http_request_duration_seconds_count{method="GET",route="/books/{id}",status="404"} 2`)
+	assert.NotContains(t, out, `route="/books/1"`)
+}
+
+func TestObserveQuery(t *testing.T) {
+	m := metrics.New()
+
+	m.ObserveQuery("books.find_by_id", 2*time.Millisecond, nil)
+	m.ObserveQuery("books.find_by_id", time.Millisecond, errors.New("boom"))
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `bookapi_db_query_duration_seconds_count{outcome="success",query="books.find_by_id"} 1`)
+	assert.Contains(t, out, `bookapi_db_query_duration_seconds_count{outcome="error",query="books.find_by_id"} 1`)
+}
+
+func TestObserveCache(t *testing.T) {
+	m := metrics.New()
+
+	m.ObserveCache("google_search", "miss")
+	m.ObserveCache("google_search", "hit")
+	m.ObserveCache("google_search", "hit")
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `bookapi_cache_requests_total{cache="google_search",result="hit"} 2`)
+	assert.Contains(t, out, `bookapi_cache_requests_total{cache="google_search",result="miss"} 1`)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestInstrumentTransport(t *testing.T) {
+	m := metrics.New()
+	status := http.StatusOK
+	client := &http.Client{Transport: m.InstrumentTransport("google_books", roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/fail" {
+			return nil, errors.New("dial failed")
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(http.NoBody), Request: r}, nil
+	}))}
+
+	_, err := client.Get("http://upstream/ok")
+	require.NoError(t, err)
+	status = http.StatusTooManyRequests
+	_, err = client.Get("http://upstream/limited")
+	require.NoError(t, err)
+	_, err = client.Get("http://upstream/fail")
+	require.Error(t, err)
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `bookapi_upstream_request_duration_seconds_count{upstream="google_books"} 3`)
+	assert.Contains(t, out, `bookapi_upstream_request_errors_total{reason="429",upstream="google_books"} 1`)
+	assert.Contains(t, out, `bookapi_upstream_request_errors_total{reason="transport",upstream="google_books"} 1`)
+}
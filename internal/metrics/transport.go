@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Transport records the latency and failures of outbound requests to one
+// upstream. A response with status >= 400 counts as an error labelled with
+// its status code; a failed round trip is labelled "transport".
+type Transport struct {
+	upstream string
+	next     http.RoundTripper
+	metrics  *Metrics
+}
+
+// InstrumentTransport wraps next (http.DefaultTransport when nil).
+func (m *Metrics) InstrumentTransport(upstream string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Transport{upstream: upstream, next: next, metrics: m}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.metrics.upstreamDuration.WithLabelValues(t.upstream).Observe(time.Since(start).Seconds())
+
+	switch {
+	case err != nil:
+		t.metrics.upstreamErrors.WithLabelValues(t.upstream, "transport").Inc()
+	case resp.StatusCode >= 400:
+		t.metrics.upstreamErrors.WithLabelValues(t.upstream, strconv.Itoa(resp.StatusCode)).Inc()
+	}
+
+	return resp, err
+}
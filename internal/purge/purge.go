@@ -0,0 +1,86 @@
+// Package purge removes soft-deleted books and authors for good once they
+// have been deleted for longer than the retention period. Until then they
+// can be restored.
+package purge
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+)
+
+// Actor is who the audit log records as purging the rows.
+const Actor = "purge"
+
+// Purger removes the rows deleted before deletedBefore and returns how
+// many there were. The book and author repositories are Purgers.
+type Purger interface {
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// Target is a Purger and the name its purges are logged under.
+type Target struct {
+	Name   string
+	Purger Purger
+}
+
+// Job purges its targets periodically.
+type Job struct {
+	retention time.Duration
+	interval  time.Duration
+	targets   []Target
+	logger    *slog.Logger
+}
+
+// New returns a job purging the targets, in order, of the rows deleted
+// longer than retention ago. Targets whose rows are referenced by those of
+// others, like authors by books, must come after them.
+func New(retention, interval time.Duration, logger *slog.Logger, targets ...Target) *Job {
+	return &Job{
+		retention: retention,
+		interval:  interval,
+		targets:   targets,
+		logger:    logging.Component(logger, "purge"),
+	}
+}
+
+// Run purges right away and then every interval until ctx is done.
+// Failures are logged and retried at the next interval.
+func (j *Job) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.Once(ctx); err != nil && ctx.Err() == nil {
+			j.logger.ErrorContext(ctx, "purge failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Once purges every target once. It stops at the first failure, so that
+// rows still referenced by unpurged ones are not attempted. The purges are
+// recorded in the audit log as made by Actor.
+func (j *Job) Once(ctx context.Context) error {
+	ctx = auth.WithActor(ctx, Actor)
+	deletedBefore := time.Now().Add(-j.retention)
+	for _, t := range j.targets {
+		n, err := t.Purger.Purge(ctx, deletedBefore)
+		if err != nil {
+			return fmt.Errorf("failed to purge %s: %w", t.Name, err)
+		}
+		if n > 0 {
+			j.logger.InfoContext(ctx, "purged deleted rows", "target", t.Name, "count", n, "deleted_before", deletedBefore)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package purge_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/purge"
+)
+
+// purgerFunc adapts a function to purge.Purger.
+type purgerFunc func(ctx context.Context, deletedBefore time.Time) (int64, error)
+
+func (f purgerFunc) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return f(ctx, deletedBefore)
+}
+
+func TestJob_Once(t *testing.T) {
+	errPurge := errors.New("connection reset")
+
+	tests := []struct {
+		name      string
+		booksErr  error
+		wantCalls []string
+		wantErr   error
+	}{
+		{name: "books before authors", wantCalls: []string{"books", "authors"}},
+		{name: "stops at the first failure", booksErr: errPurge, wantCalls: []string{"books"}, wantErr: errPurge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			var cutoffs []time.Time
+			target := func(name string, err error) purge.Target {
+				return purge.Target{Name: name, Purger: purgerFunc(func(ctx context.Context, deletedBefore time.Time) (int64, error) {
+					assert.Equal(t, purge.Actor, auth.Actor(ctx))
+					calls = append(calls, name)
+					cutoffs = append(cutoffs, deletedBefore)
+					return 1, err
+				})}
+			}
+			job := purge.New(24*time.Hour, time.Hour, slog.New(slog.DiscardHandler),
+				target("books", tt.booksErr),
+				target("authors", nil),
+			)
+
+			err := job.Once(context.Background())
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+			for _, cutoff := range cutoffs {
+				assert.WithinDuration(t, time.Now().Add(-24*time.Hour), cutoff, time.Minute)
+			}
+		})
+	}
+}
+
+func TestJob_RunStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	purged := make(chan struct{}, 1)
+	job := purge.New(time.Hour, time.Hour, slog.New(slog.DiscardHandler), purge.Target{
+		Name: "books",
+		Purger: purgerFunc(func(ctx context.Context, deletedBefore time.Time) (int64, error) {
+			purged <- struct{}{}
+			return 0, nil
+		}),
+	})
+
+	done := make(chan struct{})
+	go func() {
+		job.Run(ctx)
+		close(done)
+	}()
+
+	// Run purges right away, without waiting for the first tick
+	select {
+	case <-purged:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not purge right away")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was canceled")
+	}
+}
@@ -0,0 +1,134 @@
+package resilience
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is matched by the errors returned while a breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError reports a call refused by an open breaker.
+type CircuitOpenError struct {
+	// RetryAfter is how long until the breaker lets a probe through.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%v, retry in %v", ErrCircuitOpen, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// State is the state of a Breaker.
+type State string
+
+const (
+	// StateClosed lets every call through.
+	StateClosed State = "closed"
+	// StateOpen refuses every call until the open timeout passes.
+	StateOpen State = "open"
+	// StateHalfOpen lets a single probe through; its outcome closes or
+	// reopens the breaker.
+	StateHalfOpen State = "half-open"
+)
+
+// Breaker is a circuit breaker. It opens after a number of consecutive
+// failures, refuses calls for a while, then lets one probe through.
+type Breaker struct {
+	mu          sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	clock       Clock
+	onChange    func(from, to State)
+
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker returns a closed breaker that opens after threshold
+// consecutive failures and stays open for openTimeout. onChange, if not
+// nil, is called on every state change with the breaker locked.
+func NewBreaker(threshold int, openTimeout time.Duration, clock Clock, onChange func(from, to State)) *Breaker {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &Breaker{threshold: threshold, openTimeout: openTimeout, clock: clock, onChange: onChange, state: StateClosed}
+}
+
+// State returns the current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may proceed. A nil error obliges the caller
+// to report the outcome with Success, Failure or Cancel.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		remaining := b.openTimeout - b.clock.Now().Sub(b.openedAt)
+		if remaining > 0 {
+			return &CircuitOpenError{RetryAfter: remaining}
+		}
+		b.setState(StateHalfOpen)
+		b.probing = true
+		return nil
+	case StateHalfOpen:
+		if b.probing {
+			return &CircuitOpenError{RetryAfter: b.openTimeout}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success records a successful call and closes the breaker.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures, b.probing = 0, false
+	if b.state != StateClosed {
+		b.setState(StateClosed)
+	}
+}
+
+// Failure records a failed call. It opens the breaker after threshold
+// consecutive failures, or at once when the call was a half-open probe.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.state == StateHalfOpen || (b.state == StateClosed && b.failures >= b.threshold) {
+		b.openedAt = b.clock.Now()
+		b.setState(StateOpen)
+	}
+}
+
+// Cancel records a call that ended without telling whether the upstream
+// is healthy, e.g. because the caller gave up. A half-open breaker lets
+// the next call probe instead.
+func (b *Breaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *Breaker) setState(to State) {
+	from := b.state
+	b.state = to
+	if b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
@@ -0,0 +1,78 @@
+package resilience_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience/resiliencetest"
+)
+
+func TestBreaker(t *testing.T) {
+	newBreaker := func() (*resilience.Breaker, *resiliencetest.Clock, *[]string) {
+		clock := resiliencetest.NewClock()
+		var changes []string
+		b := resilience.NewBreaker(3, time.Minute, clock, func(from, to resilience.State) {
+			changes = append(changes, string(from)+"->"+string(to))
+		})
+		return b, clock, &changes
+	}
+
+	t.Run("opens after consecutive failures only", func(t *testing.T) {
+		b, _, _ := newBreaker()
+		for _, ok := range []bool{false, false, true, false, false} {
+			require.NoError(t, b.Allow())
+			if ok {
+				b.Success()
+			} else {
+				b.Failure()
+			}
+		}
+		assert.Equal(t, resilience.StateClosed, b.State())
+
+		require.NoError(t, b.Allow())
+		b.Failure()
+		assert.Equal(t, resilience.StateOpen, b.State())
+		assert.ErrorIs(t, b.Allow(), resilience.ErrCircuitOpen)
+	})
+
+	t.Run("lets a single probe through once the timeout passes", func(t *testing.T) {
+		b, clock, changes := newBreaker()
+		for range 3 {
+			require.NoError(t, b.Allow())
+			b.Failure()
+		}
+
+		clock.Advance(time.Minute)
+		require.NoError(t, b.Allow())
+		assert.Equal(t, resilience.StateHalfOpen, b.State())
+		assert.ErrorIs(t, b.Allow(), resilience.ErrCircuitOpen, "only one probe at a time")
+
+		b.Failure()
+		assert.Equal(t, resilience.StateOpen, b.State(), "a failed probe reopens")
+
+		clock.Advance(time.Minute)
+		require.NoError(t, b.Allow())
+		b.Success()
+		assert.Equal(t, resilience.StateClosed, b.State())
+		assert.Equal(t, []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}, *changes)
+	})
+
+	t.Run("a cancelled probe frees the slot", func(t *testing.T) {
+		b, clock, _ := newBreaker()
+		for range 3 {
+			require.NoError(t, b.Allow())
+			b.Failure()
+		}
+		clock.Advance(time.Minute)
+		require.NoError(t, b.Allow())
+
+		b.Cancel()
+
+		assert.NoError(t, b.Allow())
+		assert.Equal(t, resilience.StateHalfOpen, b.State())
+	})
+}
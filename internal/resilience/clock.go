@@ -0,0 +1,33 @@
+// Package resilience protects calls to upstream HTTP APIs with retries,
+// a circuit breaker and a client-side rate limiter. Time is read through a
+// Clock so that all of it can be tested without real waiting.
+package resilience
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and waits.
+type Clock interface {
+	Now() time.Time
+	// Sleep waits for d or until ctx is done, whichever comes first, and
+	// returns ctx's error in the latter case.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// systemClock is the real time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,93 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is matched by the errors returned when a Limiter cannot
+// grant a token before the caller's deadline.
+var ErrRateLimited = errors.New("client-side rate limit exceeded")
+
+// RateLimitError reports a call refused by a Limiter.
+type RateLimitError struct {
+	// RetryAfter is how long until a token would have been available.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v, retry in %v", ErrRateLimited, e.RetryAfter)
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Limiter is a token bucket: it holds up to burst tokens and refills at
+// rate tokens per second. Each call takes a token, waiting for one when
+// the bucket is empty.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	clock  Clock
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a full bucket. A rate of zero or less disables
+// limiting.
+func NewLimiter(rate float64, burst int, clock Clock) *Limiter {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &Limiter{rate: rate, burst: float64(burst), clock: clock, tokens: float64(burst), last: clock.Now()}
+}
+
+// Wait takes a token, sleeping until one is available. It fails at once
+// with a *RateLimitError when the token would come after ctx's deadline.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	wait := l.reserve()
+	if wait <= 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && l.clock.Now().Add(wait).After(deadline) {
+		l.unreserve()
+		return &RateLimitError{RetryAfter: wait}
+	}
+	if err := l.clock.Sleep(ctx, wait); err != nil {
+		l.unreserve()
+		return err
+	}
+	return nil
+}
+
+// reserve takes a token, letting the bucket go negative, and returns how
+// long the caller must wait for the token to exist.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// unreserve returns a token taken by a caller that will not use it.
+func (l *Limiter) unreserve() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
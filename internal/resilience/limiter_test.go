@@ -0,0 +1,72 @@
+package resilience_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience/resiliencetest"
+)
+
+func TestLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("serves a burst then paces at the rate", func(t *testing.T) {
+		clock := resiliencetest.NewClock()
+		l := resilience.NewLimiter(10, 3, clock)
+
+		for range 5 {
+			require.NoError(t, l.Wait(ctx))
+		}
+
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 100 * time.Millisecond}, clock.Sleeps())
+	})
+
+	t.Run("refills while idle, up to the burst", func(t *testing.T) {
+		clock := resiliencetest.NewClock()
+		l := resilience.NewLimiter(1, 2, clock)
+		require.NoError(t, l.Wait(ctx))
+		require.NoError(t, l.Wait(ctx))
+
+		clock.Advance(time.Hour)
+		for range 2 {
+			require.NoError(t, l.Wait(ctx))
+		}
+		require.NoError(t, l.Wait(ctx))
+
+		assert.Equal(t, []time.Duration{time.Second}, clock.Sleeps())
+	})
+
+	t.Run("fails fast when the token would come after the deadline", func(t *testing.T) {
+		clock := resiliencetest.NewClock()
+		l := resilience.NewLimiter(1, 1, clock)
+		require.NoError(t, l.Wait(ctx))
+
+		deadlineCtx, cancel := context.WithDeadline(ctx, clock.Now().Add(500*time.Millisecond))
+		defer cancel()
+		err := l.Wait(deadlineCtx)
+
+		assert.ErrorIs(t, err, resilience.ErrRateLimited)
+		var limitErr *resilience.RateLimitError
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, time.Second, limitErr.RetryAfter)
+		assert.Empty(t, clock.Sleeps())
+
+		// The refused token was handed back.
+		require.NoError(t, l.Wait(ctx))
+		assert.Equal(t, []time.Duration{time.Second}, clock.Sleeps())
+	})
+
+	t.Run("a zero rate disables limiting", func(t *testing.T) {
+		clock := resiliencetest.NewClock()
+		l := resilience.NewLimiter(0, 0, clock)
+		for range 100 {
+			require.NoError(t, l.Wait(ctx))
+		}
+		assert.Empty(t, clock.Sleeps())
+	})
+}
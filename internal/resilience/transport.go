@@ -0,0 +1,163 @@
+package resilience
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Config configures a resilient transport.
+type Config struct {
+	// MaxRetries is how many times a failed request is retried.
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry; it doubles with
+	// every further retry up to MaxDelay. A Retry-After longer than
+	// MaxDelay is not waited for.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Breaker, if set, fails requests fast while the upstream is down.
+	Breaker *Breaker
+	// Limiter, if set, bounds the rate of requests, retries included.
+	Limiter *Limiter
+	// Clock defaults to the real time.
+	Clock Clock
+	// Rand returns a number in [0, 1) for jitter; it defaults to
+	// math/rand/v2.Float64.
+	Rand func() float64
+}
+
+// transport retries failed requests with exponential backoff.
+type transport struct {
+	next http.RoundTripper
+	cfg  Config
+}
+
+// NewTransport wraps next so that every request passes the limiter and
+// the breaker, and GET and HEAD requests without a body are retried on
+// transport errors, 429 and 5xx responses. After the last attempt the
+// upstream's response or error is returned as is for the caller to
+// classify; requests refused by the breaker or limiter fail with a
+// *CircuitOpenError or *RateLimitError. next defaults to
+// http.DefaultTransport.
+func NewTransport(next http.RoundTripper, cfg Config) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = systemClock{}
+	}
+	if cfg.Rand == nil {
+		cfg.Rand = rand.Float64
+	}
+	return &transport{next: next, cfg: cfg}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if t.cfg.Limiter != nil {
+			if err := t.cfg.Limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if t.cfg.Breaker != nil {
+			if err := t.cfg.Breaker.Allow(); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		t.record(req, resp, err)
+
+		if attempt >= t.cfg.MaxRetries || !retryable(req, resp, err) {
+			return resp, err
+		}
+		delay, ok := t.delay(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && t.cfg.Clock.Now().Add(delay).After(deadline) {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		if err := t.cfg.Clock.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// record reports the outcome of an attempt to the breaker. Only transport
+// errors and 5xx responses count as failures; a 429 says the upstream is
+// up.
+func (t *transport) record(req *http.Request, resp *http.Response, err error) {
+	b := t.cfg.Breaker
+	switch {
+	case b == nil:
+	case err != nil && req.Context().Err() != nil:
+		b.Cancel()
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		b.Failure()
+	default:
+		b.Success()
+	}
+}
+
+// delay returns the wait before retry number attempt+1: the jittered
+// backoff, or the upstream's Retry-After when that is longer. It reports
+// false when the upstream asks for a longer wait than MaxDelay.
+func (t *transport) delay(attempt int, resp *http.Response) (time.Duration, bool) {
+	backoff := min(t.cfg.MaxDelay, t.cfg.BaseDelay<<attempt)
+	if backoff <= 0 {
+		backoff = t.cfg.MaxDelay
+	}
+	// Equal jitter: half the backoff plus a random part of the other half.
+	delay := backoff/2 + time.Duration(t.cfg.Rand()*float64(backoff/2))
+
+	if retryAfter, ok := RetryAfter(resp, t.cfg.Clock.Now()); ok {
+		if retryAfter > t.cfg.MaxDelay {
+			return 0, false
+		}
+		delay = max(delay, retryAfter)
+	}
+	return delay, true
+}
+
+// retryable reports whether an attempt failed in a way a retry may fix,
+// for a request that is safe to send again.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// RetryAfter parses the Retry-After header of resp, given in seconds or
+// as an HTTP date, relative to now.
+func RetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(0, at.Sub(now)), true
+	}
+	return 0, false
+}
@@ -0,0 +1,271 @@
+package resilience_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/resilience/resiliencetest"
+)
+
+// upstream is a test server answering with the given responses in turn,
+// repeating the last one. It counts the requests it receives.
+type upstream struct {
+	*httptest.Server
+	calls atomic.Int32
+}
+
+type response struct {
+	status     int
+	retryAfter string
+}
+
+func newUpstream(t *testing.T, responses ...response) *upstream {
+	t.Helper()
+	u := &upstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(u.calls.Add(1))
+		resp := responses[min(n, len(responses))-1]
+		if resp.retryAfter != "" {
+			w.Header().Set("Retry-After", resp.retryAfter)
+		}
+		w.WriteHeader(resp.status)
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+// newClient returns a client retrying twice with a 100ms base delay and
+// no jitter, and the clock it sleeps on.
+func newClient(cfg resilience.Config) (*http.Client, *resiliencetest.Clock) {
+	clock := resiliencetest.NewClock()
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+	cfg.BaseDelay, cfg.MaxDelay = 100*time.Millisecond, 2*time.Second
+	cfg.Clock = clock
+	cfg.Rand = func() float64 { return 0 }
+	return &http.Client{Transport: resilience.NewTransport(nil, cfg)}, clock
+}
+
+func get(t *testing.T, client *http.Client, ctx context.Context, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func TestTransport_Retries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries server errors with exponential backoff", func(t *testing.T) {
+		u := newUpstream(t, response{status: 503}, response{status: 502}, response{status: 200})
+		client, clock := newClient(resilience.Config{})
+
+		resp, err := get(t, client, ctx, u.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.EqualValues(t, 3, u.calls.Load())
+		assert.Equal(t, []time.Duration{50 * time.Millisecond, 100 * time.Millisecond}, clock.Sleeps())
+	})
+
+	t.Run("adds jitter up to the full backoff", func(t *testing.T) {
+		u := newUpstream(t, response{status: 500}, response{status: 200})
+		clock := resiliencetest.NewClock()
+		client := &http.Client{Transport: resilience.NewTransport(nil, resilience.Config{
+			MaxRetries: 1, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second,
+			Clock: clock, Rand: func() float64 { return 0.5 },
+		})}
+
+		_, err := get(t, client, ctx, u.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{75 * time.Millisecond}, clock.Sleeps())
+	})
+
+	t.Run("waits as long as Retry-After asks", func(t *testing.T) {
+		u := newUpstream(t, response{status: 429, retryAfter: "1"}, response{status: 200})
+		client, clock := newClient(resilience.Config{})
+
+		resp, err := get(t, client, ctx, u.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []time.Duration{time.Second}, clock.Sleeps())
+	})
+
+	t.Run("accepts Retry-After as an HTTP date", func(t *testing.T) {
+		clock := resiliencetest.NewClock()
+		at := clock.Now().Add(1500 * time.Millisecond).UTC().Format(http.TimeFormat)
+		u := newUpstream(t, response{status: 503, retryAfter: at}, response{status: 200})
+		client := &http.Client{Transport: resilience.NewTransport(nil, resilience.Config{
+			MaxRetries: 1, BaseDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second,
+			Clock: clock, Rand: func() float64 { return 0 },
+		})}
+
+		_, err := get(t, client, ctx, u.URL)
+
+		require.NoError(t, err)
+		// HTTP dates have a resolution of one second.
+		assert.Equal(t, []time.Duration{time.Second}, clock.Sleeps())
+	})
+
+	t.Run("gives up when Retry-After is longer than the maximum delay", func(t *testing.T) {
+		u := newUpstream(t, response{status: 429, retryAfter: "60"})
+		client, clock := newClient(resilience.Config{})
+
+		resp, err := get(t, client, ctx, u.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+		assert.EqualValues(t, 1, u.calls.Load())
+		assert.Empty(t, clock.Sleeps())
+	})
+
+	t.Run("returns the last response after the final retry", func(t *testing.T) {
+		u := newUpstream(t, response{status: 503})
+		client, _ := newClient(resilience.Config{})
+
+		resp, err := get(t, client, ctx, u.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.EqualValues(t, 3, u.calls.Load())
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		u := newUpstream(t, response{status: 404})
+		client, _ := newClient(resilience.Config{})
+
+		resp, err := get(t, client, ctx, u.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.EqualValues(t, 1, u.calls.Load())
+	})
+
+	t.Run("does not retry requests with a body", func(t *testing.T) {
+		u := newUpstream(t, response{status: 503})
+		client, _ := newClient(resilience.Config{})
+
+		resp, err := client.Post(u.URL, "text/plain", strings.NewReader("x"))
+
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.EqualValues(t, 1, u.calls.Load())
+	})
+
+	t.Run("does not sleep past the request deadline", func(t *testing.T) {
+		u := newUpstream(t, response{status: 503})
+		client, clock := newClient(resilience.Config{})
+		ctx, cancel := context.WithDeadline(ctx, time.Now().Add(time.Hour))
+		defer cancel()
+		// The fake clock is years behind the real one, so move it close
+		// to the deadline.
+		deadline, _ := ctx.Deadline()
+		clock.Advance(deadline.Sub(clock.Now()) - 10*time.Millisecond)
+
+		resp, err := get(t, client, ctx, u.URL)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.EqualValues(t, 1, u.calls.Load())
+		assert.Empty(t, clock.Sleeps())
+	})
+
+	t.Run("retries transport errors", func(t *testing.T) {
+		u := newUpstream(t, response{status: 200})
+		u.Close()
+		client, clock := newClient(resilience.Config{})
+
+		_, err := get(t, client, ctx, u.URL)
+
+		assert.Error(t, err)
+		assert.Len(t, clock.Sleeps(), 2)
+	})
+}
+
+func TestTransport_Breaker(t *testing.T) {
+	ctx := context.Background()
+	clock := resiliencetest.NewClock()
+	breaker := resilience.NewBreaker(2, 30*time.Second, clock, nil)
+	u := newUpstream(t, response{status: 500}, response{status: 500}, response{status: 200})
+	client := &http.Client{Transport: resilience.NewTransport(nil, resilience.Config{Breaker: breaker, Clock: clock})}
+
+	for range 2 {
+		resp, err := get(t, client, ctx, u.URL)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+	assert.Equal(t, resilience.StateOpen, breaker.State())
+
+	// While open, requests fail without reaching the upstream.
+	clock.Advance(10 * time.Second)
+	_, err := get(t, client, ctx, u.URL)
+	assert.ErrorIs(t, err, resilience.ErrCircuitOpen)
+	var openErr *resilience.CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, 20*time.Second, openErr.RetryAfter)
+	assert.EqualValues(t, 2, u.calls.Load())
+
+	// After the open timeout a probe goes through and closes it.
+	clock.Advance(20 * time.Second)
+	resp, err := get(t, client, ctx, u.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, resilience.StateClosed, breaker.State())
+}
+
+func TestTransport_Limiter(t *testing.T) {
+	u := newUpstream(t, response{status: 200})
+	clock := resiliencetest.NewClock()
+	limiter := resilience.NewLimiter(2, 1, clock)
+	client := &http.Client{Transport: resilience.NewTransport(nil, resilience.Config{Limiter: limiter, Clock: clock})}
+
+	for range 3 {
+		_, err := get(t, client, context.Background(), u.URL)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}, clock.Sleeps())
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	header := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": {v}}}
+	}
+
+	d, ok := resilience.RetryAfter(header("120"), now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, d)
+
+	d, ok = resilience.RetryAfter(header("Mon, 01 Jan 2024 00:00:30 GMT"), now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, d)
+
+	d, ok = resilience.RetryAfter(header("Sun, 31 Dec 2023 23:00:00 GMT"), now)
+	assert.True(t, ok)
+	assert.Zero(t, d)
+
+	_, ok = resilience.RetryAfter(header("soon"), now)
+	assert.False(t, ok)
+	_, ok = resilience.RetryAfter(&http.Response{Header: http.Header{}}, now)
+	assert.False(t, ok)
+	_, ok = resilience.RetryAfter(nil, now)
+	assert.False(t, ok)
+}
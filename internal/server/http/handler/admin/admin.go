@@ -0,0 +1,101 @@
+// Package admin serves operational endpoints that change the running
+// server, such as the log levels.
+package admin
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+)
+
+type Handler struct {
+	levels *logging.Levels
+	logger *slog.Logger
+}
+
+func NewHandler(levels *logging.Levels, logger *slog.Logger) *Handler {
+	return &Handler{levels: levels, logger: logging.Component(logger, "http")}
+}
+
+// LogLevels is the body of GET /admin/log-levels.
+type LogLevels struct {
+	Default    string            `json:"default"`
+	Components map[string]string `json:"components"`
+}
+
+// LogLevelsUpdate is the body of PATCH /admin/log-levels. Omitted fields
+// are left unchanged; a component set to null falls back to the default.
+type LogLevelsUpdate struct {
+	Default    *string            `json:"default"`
+	Components map[string]*string `json:"components"`
+}
+
+func (h *Handler) GetLogLevels(w http.ResponseWriter, r *http.Request) {
+	h.writeLevels(w)
+}
+
+func (h *Handler) UpdateLogLevels(w http.ResponseWriter, r *http.Request) {
+	var update LogLevelsUpdate
+	if !httpx.DecodeJSON(w, r, &update) {
+		return
+	}
+
+	// Check every level before changing any of them
+	var fields []apperr.FieldError
+	parse := func(field, raw string) slog.Level {
+		level, err := logging.ParseLevel(raw)
+		if err != nil {
+			fields = append(fields, apperr.FieldError{Field: field, Rule: "log_level", Message: err.Error()})
+		}
+		return level
+	}
+
+	var fallback slog.Level
+	if update.Default != nil {
+		fallback = parse("default", *update.Default)
+	}
+	components := make(map[string]slog.Level)
+	for name, raw := range update.Components {
+		if raw != nil {
+			components[name] = parse("components."+name, *raw)
+		}
+	}
+	if len(fields) > 0 {
+		httpx.WriteError(w, r, h.logger, &apperr.ValidationError{Fields: fields})
+		return
+	}
+
+	if update.Default != nil {
+		h.levels.SetDefault(fallback)
+	}
+	for name, raw := range update.Components {
+		if raw == nil {
+			h.levels.Reset(name)
+		} else {
+			h.levels.Set(name, components[name])
+		}
+	}
+	h.logger.InfoContext(r.Context(), "log levels changed", "default", update.Default != nil, "components", len(update.Components))
+
+	h.writeLevels(w)
+}
+
+func (h *Handler) writeLevels(w http.ResponseWriter) {
+	fallback, components := h.levels.Snapshot()
+	resp := LogLevels{Default: levelName(fallback), Components: make(map[string]string, len(components))}
+	for name, level := range components {
+		resp.Components[name] = levelName(level)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
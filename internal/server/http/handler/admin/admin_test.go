@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+)
+
+// setupRouter serves the admin endpoints to requests made by an admin.
+func setupRouter(levels *logging.Levels) http.Handler {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(auth.WithAdmin(r.Context())))
+		})
+	})
+	r.Route("/", func(r chi.Router) {
+		RegisterRoutes(r, NewHandler(levels, slog.New(slog.DiscardHandler)))
+	})
+	return r
+}
+
+func TestGetLogLevels(t *testing.T) {
+	levels := logging.NewLevels(slog.LevelInfo)
+	levels.Set("storage", slog.LevelDebug)
+
+	req := httptest.NewRequest(http.MethodGet, "/log-levels", nil)
+	rec := httptest.NewRecorder()
+	setupRouter(levels).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"default":"info","components":{"storage":"debug"}}`, rec.Body.String())
+}
+
+func TestUpdateLogLevels(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "change default and components",
+			body:           `{"default":"warn","components":{"service":"debug","storage":null}}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"default":"warn","components":{"service":"debug"}}`,
+		},
+		{
+			name:           "invalid level changes nothing",
+			body:           `{"default":"warn","components":{"service":"loud"}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			levels := logging.NewLevels(slog.LevelInfo)
+			levels.Set("storage", slog.LevelDebug)
+
+			req := httptest.NewRequest(http.MethodPatch, "/log-levels", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			setupRouter(levels).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+			} else {
+				assert.Contains(t, rec.Body.String(), "components.service")
+				assert.Equal(t, slog.LevelInfo, levels.Level("service"))
+				assert.Equal(t, slog.LevelInfo, levels.Level("other"))
+			}
+		})
+	}
+}
+
+func TestLogLevels_RequireAdmin(t *testing.T) {
+	levels := logging.NewLevels(slog.LevelInfo)
+	r := chi.NewRouter()
+	RegisterRoutes(r, NewHandler(levels, slog.New(slog.DiscardHandler)))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/log-levels", nil),
+		httptest.NewRequest(http.MethodPatch, "/log-levels", strings.NewReader(`{"default":"debug"}`)),
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusForbidden, rec.Code, req.Method)
+	}
+	assert.Equal(t, slog.LevelInfo, levels.Level("service"))
+}
@@ -0,0 +1,15 @@
+package admin
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+)
+
+// RegisterRoutes registers the admin endpoints, which answer 403 to
+// requests not made by an admin.
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Use(httpx.RequireAdmin)
+	r.Get("/log-levels", h.GetLogLevels)
+	r.Patch("/log-levels", h.UpdateLogLevels)
+}
@@ -2,51 +2,262 @@ package author
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/service"
 	"github.com/go-chi/chi/v5"
 )
 
 type Handler struct {
 	AuthorService service.AuthorService
+	logger        *slog.Logger
 }
 
-func NewHandler(authorService service.AuthorService) *Handler {
-	return &Handler{AuthorService: authorService}
+func NewHandler(authorService service.AuthorService, logger *slog.Logger) *Handler {
+	return &Handler{AuthorService: authorService, logger: logging.Component(logger, "http")}
+}
+
+// GetAllAuthors answers every author. Deleted authors are left out unless
+// an admin asks for them with include_deleted=true, which the other reads
+// honor too.
+func (h *Handler) GetAllAuthors(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := httpx.IncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	authors, err := h.AuthorService.GetAllAuthors(ctx)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(authors)
 }
 
 func (h *Handler) RegisterAuthor(w http.ResponseWriter, r *http.Request) {
 	var author entities.Author
 
-	if err := json.NewDecoder(r.Body).Decode(&author); err != nil {
-		http.Error(w, "Invalid payload", http.StatusBadRequest)
+	if !httpx.DecodeJSON(w, r, &author) {
 		return
 	}
 
 	if err := h.AuthorService.RegisterAuthor(r.Context(), &author); err != nil {
-		http.Error(w, "Failed to register author", http.StatusInternalServerError)
+		httpx.WriteError(w, r, h.logger, err)
 		return
 	}
 
+	w.Header().Set("ETag", httpx.ETag(author.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(author)
 }
+
+// GetAuthorByID answers the author with their version as ETag, or 304 Not
+// Modified when If-None-Match names that version. The response with
+// ?include=books has no ETag, since it changes with the books too.
 func (h *Handler) GetAuthorByID(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	ctx, ok := httpx.IncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	// ?include=books embeds the author's bibliography in the response
+	if r.URL.Query().Get("include") == "books" {
+		author, err := h.AuthorService.GetAuthorWithBooks(ctx, id)
+		if err != nil {
+			httpx.WriteError(w, r, h.logger, err)
+			return
+		}
+
+		json.NewEncoder(w).Encode(author)
+		return
+	}
+
+	author, err := h.AuthorService.GetAuthorByID(ctx, id)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+	if httpx.NotModified(w, r, author.Version) {
+		return
+	}
+
+	json.NewEncoder(w).Encode(author)
+}
+
+func (h *Handler) GetAuthorBooks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	ctx, ok := httpx.IncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	books, err := h.AuthorService.GetAuthorBooks(ctx, id)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(books)
+}
+
+// UpdateAuthor overwrites the author. With If-Match, it only does so while
+// the author is still at that version and answers 412 Precondition Failed
+// otherwise; the version in the body is ignored.
+func (h *Handler) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var author entities.Author
+	if !httpx.DecodeJSON(w, r, &author) {
+		return
+	}
+	author.ID = id
+	author.Version = version
+
+	if err := h.AuthorService.UpdateAuthor(r.Context(), &author); err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("ETag", httpx.ETag(author.Version))
+	json.NewEncoder(w).Encode(author)
+}
+
+// PatchAuthor applies a JSON Merge Patch or JSON Patch to the author,
+// honoring If-Match like UpdateAuthor.
+func (h *Handler) PatchAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := httpx.DecodePatch(w, r)
+	if !ok {
+		return
+	}
+
+	author, err := h.AuthorService.PatchAuthor(r.Context(), id, version, patch)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("ETag", httpx.ETag(author.Version))
+	json.NewEncoder(w).Encode(author)
+}
+
+// DeleteAuthor deletes the author, honoring If-Match like UpdateAuthor.
+func (h *Handler) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.AuthorService.RemoveAuthor(r.Context(), id, version); err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreAuthor undoes the deletion of the author and answers them,
+// honoring If-Match like UpdateAuthor.
+func (h *Handler) RestoreAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	author, err := h.AuthorService.RestoreAuthor(r.Context(), id, version)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("ETag", httpx.ETag(author.Version))
+	json.NewEncoder(w).Encode(author)
+}
+
+// GetAuthorHistory answers the recorded revisions of the author, newest first.
+// They are kept after the author is deleted and even purged.
+func (h *Handler) GetAuthorHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	history, err := h.AuthorService.GetAuthorHistory(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(history)
+}
+
+// RevertAuthor takes the author back to the revision in the path and answers
+// them, honoring If-Match like UpdateAuthor. It is routed for admins only.
+func (h *Handler) RevertAuthor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	revision, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || revision < 1 {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid revision")
+		return
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
 		return
 	}
 
-	author, err := h.AuthorService.GetAuthorByID(r.Context(), id)
+	author, err := h.AuthorService.RevertAuthor(r.Context(), id, revision, version)
 	if err != nil {
-		http.Error(w, "Author not found", http.StatusNotFound)
+		httpx.WriteError(w, r, h.logger, err)
 		return
 	}
 
+	w.Header().Set("ETag", httpx.ETag(author.Version))
 	json.NewEncoder(w).Encode(author)
 }
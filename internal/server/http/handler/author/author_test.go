@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -17,7 +21,7 @@ import (
 
 func TestRegisterAuthor(t *testing.T) {
 	mockService := new(domain.MockAuthorService)
-	handler := NewHandler(mockService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
 
 	author := entities.Author{ID: 1, Name: "Test Author"}
 
@@ -45,7 +49,7 @@ func TestRegisterAuthor(t *testing.T) {
 
 func TestGetAuthorByID(t *testing.T) {
 	mockService := new(domain.MockAuthorService)
-	handler := NewHandler(mockService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
 
 	author := &entities.Author{ID: 1, Name: "Test Author"}
 
@@ -72,9 +76,9 @@ func TestGetAuthorByID(t *testing.T) {
 
 func TestGetAuthorByID_NotFound(t *testing.T) {
 	mockService := new(domain.MockAuthorService)
-	handler := NewHandler(mockService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
 
-	mockService.On("GetAuthorByID", mock.Anything, 42).Return((*entities.Author)(nil), errors.New("not found"))
+	mockService.On("GetAuthorByID", mock.Anything, 42).Return((*entities.Author)(nil), apperr.NotFound("author with ID 42 not found"))
 
 	req := httptest.NewRequest(http.MethodGet, "/authors/42", nil)
 	rec := httptest.NewRecorder()
@@ -87,3 +91,340 @@ func TestGetAuthorByID_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, rec.Code)
 	mockService.AssertExpectations(t)
 }
+
+func TestGetAllAuthors(t *testing.T) {
+	mockService := new(domain.MockAuthorService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+	authors := []*entities.Author{{ID: 1, Name: "First"}, {ID: 2, Name: "Second"}}
+
+	mockService.On("GetAllAuthors", mock.Anything).Return(authors, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/authors", nil)
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/authors", handler.GetAllAuthors)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var result []entities.Author
+	err := json.Unmarshal(rec.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateAuthor(t *testing.T) {
+	mockService := new(domain.MockAuthorService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+	// The ID is taken from the path, not the body
+	mockService.On("UpdateAuthor", mock.Anything, &entities.Author{ID: 7, Name: "Renamed"}).Return(nil)
+
+	body, _ := json.Marshal(entities.Author{Name: "Renamed"})
+	req := httptest.NewRequest(http.MethodPut, "/authors/7", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Put("/authors/{id}", handler.UpdateAuthor)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateAuthor_IfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    string
+		serviceErr error
+		expectCode int
+	}{
+		{name: "matching version", ifMatch: `"2"`, expectCode: http.StatusOK},
+		{name: "stale version", ifMatch: `"2"`, serviceErr: apperr.Conflict("author with ID 7 is at version 3, not 2", storage.ErrVersionMismatch), expectCode: http.StatusPreconditionFailed},
+		{name: "several versions", ifMatch: `"2", "3"`, expectCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(domain.MockAuthorService)
+			handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+			if tc.expectCode != http.StatusBadRequest {
+				mockService.On("UpdateAuthor", mock.Anything, &entities.Author{ID: 7, Name: "Renamed", Version: 2}).
+					Return(tc.serviceErr).
+					Run(func(args mock.Arguments) { args.Get(1).(*entities.Author).Version = 3 })
+			}
+
+			body, _ := json.Marshal(entities.Author{Name: "Renamed"})
+			req := httptest.NewRequest(http.MethodPut, "/authors/7", bytes.NewReader(body))
+			req.Header.Set("If-Match", tc.ifMatch)
+			rec := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Put("/authors/{id}", handler.UpdateAuthor)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			if tc.expectCode == http.StatusOK {
+				assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPatchAuthor(t *testing.T) {
+	mockService := new(domain.MockAuthorService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+	mockService.On("PatchAuthor", mock.Anything, 7, 2, mock.Anything).
+		Return(&entities.Author{ID: 7, Name: "Renamed", Version: 3}, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/authors/7", bytes.NewReader([]byte(`{"name":"Renamed"}`)))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"2"`)
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Patch("/authors/{id}", handler.PatchAuthor)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteAuthor(t *testing.T) {
+	tests := []struct {
+		name       string
+		serviceErr error
+		expectCode int
+	}{
+		{name: "deleted", serviceErr: nil, expectCode: http.StatusNoContent},
+		{name: "author still has books", serviceErr: apperr.Conflict("author with ID 3 still has books", storage.ErrAuthorHasBooks), expectCode: http.StatusConflict},
+		{name: "database failure", serviceErr: errors.New("db down"), expectCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(domain.MockAuthorService)
+			handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+			mockService.On("RemoveAuthor", mock.Anything, 3, 0).Return(tc.serviceErr)
+
+			req := httptest.NewRequest(http.MethodDelete, "/authors/3", nil)
+			rec := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Delete("/authors/{id}", handler.DeleteAuthor)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRestoreAuthor(t *testing.T) {
+	tests := []struct {
+		name       string
+		serviceErr error
+		expectCode int
+	}{
+		{name: "restored", expectCode: http.StatusOK},
+		{name: "stale version", serviceErr: apperr.Conflict("author with ID 3 is at version 4, not 2", storage.ErrVersionMismatch), expectCode: http.StatusPreconditionFailed},
+		{name: "not found", serviceErr: apperr.NotFound("author with ID 3 not found"), expectCode: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(domain.MockAuthorService)
+			handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+			var restored *entities.Author
+			if tc.serviceErr == nil {
+				restored = &entities.Author{ID: 3, Name: "Test Author", Version: 3}
+			}
+			mockService.On("RestoreAuthor", mock.Anything, 3, 2).Return(restored, tc.serviceErr)
+
+			req := httptest.NewRequest(http.MethodPost, "/authors/3/restore", nil)
+			req.Header.Set("If-Match", `"2"`)
+			rec := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Post("/authors/{id}/restore", handler.RestoreAuthor)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			if tc.serviceErr == nil {
+				assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetAllAuthors_IncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name       string
+		admin      bool
+		expectCode int
+	}{
+		{name: "admin", admin: true, expectCode: http.StatusOK},
+		{name: "not an admin", expectCode: http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(domain.MockAuthorService)
+			handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+			if tc.admin {
+				mockService.On("GetAllAuthors", mock.MatchedBy(storage.IncludesDeleted)).Return([]*entities.Author{{ID: 1, Name: "Test Author"}}, nil).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/authors?include_deleted=true", nil)
+			if tc.admin {
+				req = req.WithContext(auth.WithAdmin(req.Context()))
+			}
+			rec := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Get("/authors", handler.GetAllAuthors)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetAuthorByID_IncludeBooks(t *testing.T) {
+	mockService := new(domain.MockAuthorService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+	author := &entities.AuthorWithBooks{
+		Author: entities.Author{ID: 1, Name: "Test Author"},
+		Books:  []*entities.Book{{ID: 5, Title: "First Book", AuthorID: 1}},
+	}
+
+	mockService.On("GetAuthorWithBooks", mock.Anything, 1).Return(author, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/authors/1?include=books", nil)
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/authors/{id}", handler.GetAuthorByID)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var result entities.AuthorWithBooks
+	err := json.Unmarshal(rec.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Author", result.Name)
+	assert.Len(t, result.Books, 1)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetAuthorBooks(t *testing.T) {
+	mockService := new(domain.MockAuthorService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+	books := []*entities.Book{{ID: 5, Title: "First Book", AuthorID: 1}}
+
+	mockService.On("GetAuthorBooks", mock.Anything, 1).Return(books, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/authors/1/books", nil)
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/authors/{id}/books", handler.GetAuthorBooks)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var result []entities.Book
+	err := json.Unmarshal(rec.Body.Bytes(), &result)
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "First Book", result[0].Title)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetAuthorHistory(t *testing.T) {
+	mockService := new(domain.MockAuthorService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+	history := []*entities.Revision{{Entity: "author", EntityID: 3, Revision: 1, Action: entities.AuditCreate, Actor: auth.ActorAdmin}}
+	mockService.On("GetAuthorHistory", mock.Anything, 3).Return(history, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/authors/3/history", nil)
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Route("/authors", func(r chi.Router) { RegisterRoutes(r, handler) })
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"entity":"author","entity_id":3,"revision":1,"action":"create","actor":"admin","changed_at":"0001-01-01T00:00:00Z","changes":null}]`, rec.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestRevertAuthor(t *testing.T) {
+	tests := []struct {
+		name       string
+		admin      bool
+		serviceErr error
+		expectCode int
+	}{
+		{name: "reverted", admin: true, expectCode: http.StatusOK},
+		{name: "not an admin", expectCode: http.StatusForbidden},
+		{name: "history incomplete", admin: true, serviceErr: apperr.Conflict("author with ID 3 cannot be reverted to revision 1", storage.ErrHistoryIncomplete), expectCode: http.StatusConflict},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(domain.MockAuthorService)
+			handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+			if tc.admin {
+				var reverted *entities.Author
+				if tc.serviceErr == nil {
+					reverted = &entities.Author{ID: 3, Name: "Test Author", Version: 4}
+				}
+				mockService.On("RevertAuthor", mock.Anything, 3, 1, 0).Return(reverted, tc.serviceErr)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/authors/3/history/1/revert", nil)
+			if tc.admin {
+				req = req.WithContext(auth.WithAdmin(req.Context()))
+			}
+			rec := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Route("/authors", func(r chi.Router) { RegisterRoutes(r, handler) })
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			if tc.serviceErr == nil && tc.admin {
+				assert.Equal(t, `"4"`, rec.Header().Get("ETag"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -1,8 +1,20 @@
 package author
 
-import "github.com/go-chi/chi/v5"
+import (
+	"github.com/go-chi/chi/v5"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+)
 
 func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Get("/", h.GetAllAuthors)
 	r.Post("/", h.RegisterAuthor)
 	r.Get("/{id}", h.GetAuthorByID)
+	r.Get("/{id}/books", h.GetAuthorBooks)
+	r.Put("/{id}", h.UpdateAuthor)
+	r.Patch("/{id}", h.PatchAuthor)
+	r.Delete("/{id}", h.DeleteAuthor)
+	r.Post("/{id}/restore", h.RestoreAuthor)
+	r.Get("/{id}/history", h.GetAuthorHistory)
+	r.With(httpx.RequireAdmin).Post("/{id}/history/{revision}/revert", h.RevertAuthor)
 }
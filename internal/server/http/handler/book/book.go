@@ -2,36 +2,102 @@ package book
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/service"
 	"github.com/go-chi/chi/v5"
 )
 
 type Handler struct {
 	BookService service.BookService
+	logger      *slog.Logger
 }
 
-func NewHandler(service service.BookService) *Handler {
-	return &Handler{BookService: service}
+func NewHandler(service service.BookService, logger *slog.Logger) *Handler {
+	return &Handler{BookService: service, logger: logging.Component(logger, "http")}
 }
 
+// GetAllBooks answers one page of books. Deleted books are left out unless
+// an admin asks for them with include_deleted=true.
 func (h *Handler) GetAllBooks(w http.ResponseWriter, r *http.Request) {
-	books, err := h.BookService.GetAllBooks(r.Context())
+	query, err := parseBookQuery(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Failed to get books", http.StatusInternalServerError)
+		httpx.WriteProblem(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
-	json.NewEncoder(w).Encode(books)
+	ctx, ok := httpx.IncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.BookService.GetAllBooks(ctx, query)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+	json.NewEncoder(w).Encode(page)
 }
 
+// GetBookByID answers the book with its version as ETag, or 304 Not
+// Modified when If-None-Match names that version. A deleted book is only
+// found with include_deleted=true, like in GetAllBooks.
 func (h *Handler) GetBookByID(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
-	book, err := h.BookService.GetBookByID(r.Context(), id)
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	ctx, ok := httpx.IncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := h.BookService.GetBookByID(ctx, id)
 	if err != nil {
-		http.Error(w, "Book not found", http.StatusNotFound)
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+	if httpx.NotModified(w, r, book.Version) {
+		return
+	}
+	json.NewEncoder(w).Encode(book)
+}
+
+// GetBookByISBN answers the local book with the given ISBN-10 or ISBN-13.
+// With external=true, a book missing locally is looked up in the external
+// catalogs and answered as their merged record instead.
+func (h *Handler) GetBookByISBN(w http.ResponseWriter, r *http.Request) {
+	external := false
+	if value := r.URL.Query().Get("external"); value != "" {
+		var err error
+		if external, err = strconv.ParseBool(value); err != nil {
+			httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid external query parameter")
+			return
+		}
+	}
+
+	isbn := chi.URLParam(r, "isbn")
+	book, err := h.BookService.GetBookByISBN(r.Context(), isbn)
+	if errors.Is(err, apperr.ErrNotFound) && external {
+		externalBook, err := h.BookService.LookupExternalISBN(r.Context(), isbn)
+		if err != nil {
+			httpx.WriteError(w, r, h.logger, err)
+			return
+		}
+		json.NewEncoder(w).Encode(externalBook)
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
 		return
 	}
 	json.NewEncoder(w).Encode(book)
@@ -39,58 +105,263 @@ func (h *Handler) GetBookByID(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) AddBook(w http.ResponseWriter, r *http.Request) {
 	var book entities.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+	if !httpx.DecodeJSON(w, r, &book) {
 		return
 	}
 
 	if err := h.BookService.AddBook(r.Context(), &book); err != nil {
-		http.Error(w, "Failed to add book", http.StatusInternalServerError)
+		httpx.WriteError(w, r, h.logger, err)
 		return
 	}
 
+	w.Header().Set("ETag", httpx.ETag(book.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(book)
 }
 
+// UpdateBook overwrites the book at /books/{id}; an ID in the body must be
+// the same. The deprecated PUT /books/ takes the ID from the body. With
+// If-Match, it only does so while the book is still at that version and
+// answers 412 Precondition Failed otherwise; the version in the body is
+// ignored.
 func (h *Handler) UpdateBook(w http.ResponseWriter, r *http.Request) {
+	var id int
+	if param := chi.URLParam(r, "id"); param != "" {
+		var err error
+		if id, err = strconv.Atoi(param); err != nil {
+			httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+			return
+		}
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	var book entities.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+	if !httpx.DecodeJSON(w, r, &book) {
 		return
 	}
+	if id != 0 {
+		if book.ID != 0 && book.ID != id {
+			httpx.WriteProblem(w, r, http.StatusBadRequest, fmt.Sprintf("body ID %d does not match the path ID %d", book.ID, id))
+			return
+		}
+		book.ID = id
+	}
+	book.Version = version
 
 	if err := h.BookService.UpdateBook(r.Context(), &book); err != nil {
-		http.Error(w, "Failed to update book", http.StatusInternalServerError)
+		httpx.WriteError(w, r, h.logger, err)
 		return
 	}
 
+	w.Header().Set("ETag", httpx.ETag(book.Version))
 	json.NewEncoder(w).Encode(book)
 }
 
+// PatchBook applies a JSON Merge Patch or JSON Patch to the book, honoring
+// If-Match like UpdateBook.
+func (h *Handler) PatchBook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := httpx.DecodePatch(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := h.BookService.PatchBook(r.Context(), id, version, patch)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("ETag", httpx.ETag(book.Version))
+	json.NewEncoder(w).Encode(book)
+}
+
+// DeleteBook soft-deletes the book, honoring If-Match like UpdateBook.
 func (h *Handler) DeleteBook(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
+		return
+	}
 
-	if err := h.BookService.RemoveBook(r.Context(), id); err != nil {
-		http.Error(w, "Failed to delete book", http.StatusInternalServerError)
+	if err := h.BookService.RemoveBook(r.Context(), id, version); err != nil {
+		httpx.WriteError(w, r, h.logger, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// RestoreBook undoes the deletion of the book and answers it, honoring
+// If-Match like UpdateBook.
+func (h *Handler) RestoreBook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := h.BookService.RestoreBook(r.Context(), id, version)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("ETag", httpx.ETag(book.Version))
+	json.NewEncoder(w).Encode(book)
+}
+
+// GetBookHistory answers the recorded revisions of the book, newest first.
+// They are kept after the book is deleted and even purged.
+func (h *Handler) GetBookHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	history, err := h.BookService.GetBookHistory(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(history)
+}
+
+// RevertBook takes the book back to the revision in the path and answers
+// it, honoring If-Match like UpdateBook. It is routed for admins only.
+func (h *Handler) RevertBook(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	revision, err := strconv.Atoi(chi.URLParam(r, "revision"))
+	if err != nil || revision < 1 {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid revision")
+		return
+	}
+	version, ok := httpx.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := h.BookService.RevertBook(r.Context(), id, revision, version)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("ETag", httpx.ETag(book.Version))
+	json.NewEncoder(w).Encode(book)
+}
+
+// SearchBooks runs a full-text search over the local catalog. q accepts
+// web search syntax; fuzzy=true retries a query without hits with
+// trigram matching.
+func (h *Handler) SearchBooks(w http.ResponseWriter, r *http.Request) {
+	query, err := parseBookSearchQuery(r.URL.Query())
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.BookService.SearchBooks(r.Context(), query)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(page)
+}
+
+// SearchGoogleBooks answers one page of Google Books search results.
 func (h *Handler) SearchGoogleBooks(w http.ResponseWriter, r *http.Request) {
-	title := r.URL.Query().Get("title")
-	if title == "" {
-		http.Error(w, "Missing title query parameter", http.StatusBadRequest)
+	query, err := parseGoogleBookQuery(r.URL.Query())
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.BookService.SearchGoogleBooks(r.Context(), query)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(page)
+}
+
+// SearchExternalBooks searches the external catalogs for q. The optional
+// provider parameter, repeated or comma-separated, narrows the search to
+// the named catalogs.
+func (h *Handler) SearchExternalBooks(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	query := params.Get("q")
+	if query == "" {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Missing q query parameter")
 		return
 	}
 
-	results, err := h.BookService.SearchGoogleBooks(r.Context(), title)
+	var providers []string
+	for _, value := range params["provider"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				providers = append(providers, name)
+			}
+		}
+	}
+
+	result, err := h.BookService.SearchExternalBooks(r.Context(), query, providers)
 	if err != nil {
-		http.Error(w, "Failed to search books", http.StatusInternalServerError)
+		httpx.WriteError(w, r, h.logger, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(results)
+	json.NewEncoder(w).Encode(result)
+}
+
+// importGoogleRequest is the body of POST /books/import/google.
+type importGoogleRequest struct {
+	VolumeID string `json:"volume_id"`
+}
+
+// ImportGoogleBook stores a Google Books volume as a local book. It answers
+// 201 when the book is new and 200 when an earlier import was updated.
+func (h *Handler) ImportGoogleBook(w http.ResponseWriter, r *http.Request) {
+	var req importGoogleRequest
+	if !httpx.DecodeJSON(w, r, &req) {
+		return
+	}
+
+	book, created, err := h.BookService.ImportGoogleBook(r.Context(), req.VolumeID)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(book)
 }
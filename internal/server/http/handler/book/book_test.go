@@ -3,13 +3,20 @@ package book_test
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
 	book "github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/book"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -23,29 +30,39 @@ func setupRouter(h *book.Handler) *chi.Mux {
 
 func TestBookHandlers(t *testing.T) {
 	mockService := new(domain.MockBookService)
-	h := book.NewHandler(mockService)
+	h := book.NewHandler(mockService, slog.New(slog.DiscardHandler))
 	r := setupRouter(h)
 
 	mockBook := &entities.Book{ID: 1, Title: "Go 101"}
-	mockBookList := []*entities.Book{mockBook}
-	mockGoogleBooks := []entities.GoogleBook{{
-		ID: "g1",
-		VolumeInfo: struct {
-			Title       string   `json:"title"`
-			Authors     []string `json:"authors"`
-			Description string   `json:"description"`
-		}{
-			Title:       "Go by Google",
-			Authors:     []string{"Google Inc."},
-			Description: "A great book by Google",
-		},
-	}}
+	versionedBook := &entities.Book{ID: 1, Title: "Go 101", Version: 3}
+	staleVersion := apperr.Conflict("book with ID 1 is at version 4, not 3", storage.ErrVersionMismatch)
+	mockBookPage := &entities.BookPage{Items: []*entities.Book{mockBook}, Total: 1, Limit: entities.DefaultBookLimit}
+	minPrice := 10.0
+	mockGooglePage := &entities.GoogleBookPage{
+		Items: []entities.GoogleBook{{
+			ID: "g1",
+			VolumeInfo: entities.GoogleVolumeInfo{
+				Title:       "Go by Google",
+				Authors:     []string{"Google Inc."},
+				Description: "A great book by Google",
+			},
+		}},
+		TotalItems: 1,
+		MaxResults: entities.DefaultGoogleMaxResults,
+	}
+
+	externalResult := &entities.ExternalSearchResult{Items: []entities.ExternalBook{{
+		Sources: map[string]string{"google": "g1", "openlibrary": "OL1W"},
+		Title:   "Dune",
+		ISBNs:   []string{"9780441172719"},
+	}}}
 
 	tests := []struct {
 		name       string
 		method     string
 		url        string
 		body       interface{}
+		header     map[string]string
 		mockSetup  func()
 		expectCode int
 	}{
@@ -54,10 +71,61 @@ func TestBookHandlers(t *testing.T) {
 			method: http.MethodGet,
 			url:    "/",
 			mockSetup: func() {
-				mockService.On("GetAllBooks", mock.Anything).Return(mockBookList, nil).Once()
+				mockService.On("GetAllBooks", mock.Anything, entities.BookQuery{}).Return(mockBookPage, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "GetAllBooks - paging, sorting and filters",
+			method: http.MethodGet,
+			url:    "/?limit=5&offset=10&sort=-price&author_id=3&min_price=10",
+			mockSetup: func() {
+				mockService.On("GetAllBooks", mock.Anything, entities.BookQuery{
+					Limit:    5,
+					Offset:   10,
+					Sort:     entities.SortByPrice,
+					Desc:     true,
+					AuthorID: 3,
+					MinPrice: &minPrice,
+				}).Return(mockBookPage, nil).Once()
 			},
 			expectCode: http.StatusOK,
 		},
+		{
+			name:   "GetAllBooks - genre and tags",
+			method: http.MethodGet,
+			url:    "/?genre=fantasy&tag=award-winner&tag=classic",
+			mockSetup: func() {
+				mockService.On("GetAllBooks", mock.Anything, entities.BookQuery{
+					Genre: "fantasy",
+					Tags:  []string{"award-winner", "classic"},
+				}).Return(mockBookPage, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "GetAllBooks - unknown sort field",
+			method:     http.MethodGet,
+			url:        "/?sort=isbn",
+			mockSetup:  func() {},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:       "GetAllBooks - malformed date filter",
+			method:     http.MethodGet,
+			url:        "/?published_after=yesterday",
+			mockSetup:  func() {},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:   "GetAllBooks - invalid cursor",
+			method: http.MethodGet,
+			url:    "/?cursor=garbage",
+			mockSetup: func() {
+				mockService.On("GetAllBooks", mock.Anything, entities.BookQuery{Cursor: "garbage"}).Return(nil, apperr.Validation("invalid cursor", storage.ErrInvalidCursor)).Once()
+			},
+			expectCode: http.StatusBadRequest,
+		},
 		{
 			name:   "GetBookByID - found",
 			method: http.MethodGet,
@@ -67,6 +135,86 @@ func TestBookHandlers(t *testing.T) {
 			},
 			expectCode: http.StatusOK,
 		},
+		{
+			name:   "GetBookByID - not modified",
+			method: http.MethodGet,
+			url:    "/1",
+			header: map[string]string{"If-None-Match": `"3"`},
+			mockSetup: func() {
+				mockService.On("GetBookByID", mock.Anything, 1).Return(versionedBook, nil).Once()
+			},
+			expectCode: http.StatusNotModified,
+		},
+		{
+			name:   "GetBookByID - not found",
+			method: http.MethodGet,
+			url:    "/2",
+			mockSetup: func() {
+				mockService.On("GetBookByID", mock.Anything, 2).Return((*entities.Book)(nil), apperr.NotFound("book with ID 2 not found")).Once()
+			},
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:   "GetBookByID - database unavailable",
+			method: http.MethodGet,
+			url:    "/3",
+			mockSetup: func() {
+				mockService.On("GetBookByID", mock.Anything, 3).Return((*entities.Book)(nil), apperr.Unavailable("failed to find book by ID 3", errors.New("connection refused"))).Once()
+			},
+			expectCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "GetBookByID - invalid ID",
+			method:     http.MethodGet,
+			url:        "/abc",
+			mockSetup:  func() {},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:   "GetBookByISBN - found",
+			method: http.MethodGet,
+			url:    "/isbn/978-0-441-17271-9",
+			mockSetup: func() {
+				mockService.On("GetBookByISBN", mock.Anything, "978-0-441-17271-9").Return(&entities.Book{ID: 1, ISBN13: "9780441172719"}, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "GetBookByISBN - not found without external lookup",
+			method: http.MethodGet,
+			url:    "/isbn/9780441172719",
+			mockSetup: func() {
+				mockService.On("GetBookByISBN", mock.Anything, "9780441172719").Return(nil, apperr.NotFound("book with ISBN 9780441172719 not found")).Once()
+			},
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:   "GetBookByISBN - external fallback",
+			method: http.MethodGet,
+			url:    "/isbn/9780441172719?external=true",
+			mockSetup: func() {
+				mockService.On("GetBookByISBN", mock.Anything, "9780441172719").Return(nil, apperr.NotFound("book with ISBN 9780441172719 not found")).Once()
+				mockService.On("LookupExternalISBN", mock.Anything, "9780441172719").Return(&externalResult.Items[0], nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "GetBookByISBN - invalid ISBN",
+			method: http.MethodGet,
+			url:    "/isbn/123?external=true",
+			mockSetup: func() {
+				mockService.On("GetBookByISBN", mock.Anything, "123").
+					Return(nil, &apperr.ValidationError{Fields: []apperr.FieldError{{Field: "isbn", Rule: "format"}}}).Once()
+			},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:       "GetBookByISBN - malformed external flag",
+			method:     http.MethodGet,
+			url:        "/isbn/9780441172719?external=maybe",
+			mockSetup:  func() {},
+			expectCode: http.StatusBadRequest,
+		},
 		{
 			name:   "AddBook - success",
 			method: http.MethodPost,
@@ -87,31 +235,316 @@ func TestBookHandlers(t *testing.T) {
 			},
 			expectCode: http.StatusOK,
 		},
+		{
+			name:   "UpdateBook - matching If-Match",
+			method: http.MethodPut,
+			url:    "/",
+			body:   mockBook,
+			header: map[string]string{"If-Match": `"3"`},
+			mockSetup: func() {
+				mockService.On("UpdateBook", mock.Anything, versionedBook).Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "UpdateBook - stale If-Match",
+			method: http.MethodPut,
+			url:    "/",
+			body:   mockBook,
+			header: map[string]string{"If-Match": `"3"`},
+			mockSetup: func() {
+				mockService.On("UpdateBook", mock.Anything, versionedBook).Return(staleVersion).Once()
+			},
+			expectCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:       "UpdateBook - weak If-Match",
+			method:     http.MethodPut,
+			url:        "/",
+			body:       mockBook,
+			header:     map[string]string{"If-Match": `W/"3"`},
+			mockSetup:  func() {},
+			expectCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "UpdateBook - ID from the path",
+			method: http.MethodPut,
+			url:    "/1",
+			body:   &entities.Book{Title: "Go 101"},
+			mockSetup: func() {
+				mockService.On("UpdateBook", mock.Anything, mockBook).Return(nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "UpdateBook - body ID does not match the path",
+			method:     http.MethodPut,
+			url:        "/2",
+			body:       mockBook,
+			mockSetup:  func() {},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:   "PatchBook - merge patch",
+			method: http.MethodPatch,
+			url:    "/1",
+			body:   map[string]any{"price": 12.5},
+			header: map[string]string{"Content-Type": "application/merge-patch+json", "If-Match": `"3"`},
+			mockSetup: func() {
+				mockService.On("PatchBook", mock.Anything, 1, 3, mock.Anything).Return(versionedBook, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "PatchBook - failed test operation",
+			method: http.MethodPatch,
+			url:    "/1",
+			body:   []map[string]any{{"op": "test", "path": "/price", "value": 1}},
+			header: map[string]string{"Content-Type": "application/json-patch+json"},
+			mockSetup: func() {
+				mockService.On("PatchBook", mock.Anything, 1, 0, mock.Anything).
+					Return(nil, apperr.Conflict("patch cannot be applied", jsonpatch.ErrCannotApply)).Once()
+			},
+			expectCode: http.StatusConflict,
+		},
+		{
+			name:       "PatchBook - plain JSON",
+			method:     http.MethodPatch,
+			url:        "/1",
+			body:       map[string]any{"price": 12.5},
+			mockSetup:  func() {},
+			expectCode: http.StatusUnsupportedMediaType,
+		},
 		{
 			name:   "DeleteBook - success",
 			method: http.MethodDelete,
 			url:    "/1",
 			mockSetup: func() {
-				mockService.On("RemoveBook", mock.Anything, 1).Return(nil).Once()
+				mockService.On("RemoveBook", mock.Anything, 1, 0).Return(nil).Once()
 			},
 			expectCode: http.StatusNoContent,
 		},
+		{
+			name:   "DeleteBook - stale If-Match",
+			method: http.MethodDelete,
+			url:    "/1",
+			header: map[string]string{"If-Match": `"3"`},
+			mockSetup: func() {
+				mockService.On("RemoveBook", mock.Anything, 1, 3).Return(staleVersion).Once()
+			},
+			expectCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "DeleteBook - not found",
+			method: http.MethodDelete,
+			url:    "/999",
+			mockSetup: func() {
+				mockService.On("RemoveBook", mock.Anything, 999, 0).Return(apperr.NotFound("book with ID 999 not found for delete")).Once()
+			},
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:   "RestoreBook - success",
+			method: http.MethodPost,
+			url:    "/1/restore",
+			header: map[string]string{"If-Match": `"2"`},
+			mockSetup: func() {
+				mockService.On("RestoreBook", mock.Anything, 1, 2).Return(versionedBook, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "RestoreBook - stale If-Match",
+			method: http.MethodPost,
+			url:    "/1/restore",
+			header: map[string]string{"If-Match": `"3"`},
+			mockSetup: func() {
+				mockService.On("RestoreBook", mock.Anything, 1, 3).Return(nil, staleVersion).Once()
+			},
+			expectCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "RestoreBook - ISBN taken by another book",
+			method: http.MethodPost,
+			url:    "/1/restore",
+			mockSetup: func() {
+				mockService.On("RestoreBook", mock.Anything, 1, 0).Return(nil, apperr.Conflict("book with ID 1 cannot be restored: another book has its ISBN", nil)).Once()
+			},
+			expectCode: http.StatusConflict,
+		},
+		{
+			name:   "RestoreBook - not found",
+			method: http.MethodPost,
+			url:    "/999/restore",
+			mockSetup: func() {
+				mockService.On("RestoreBook", mock.Anything, 999, 0).Return(nil, apperr.NotFound("book with ID 999 not found")).Once()
+			},
+			expectCode: http.StatusNotFound,
+		},
+		{
+			name:       "GetAllBooks - deleted books for a non-admin",
+			method:     http.MethodGet,
+			url:        "/?include_deleted=true",
+			mockSetup:  func() {},
+			expectCode: http.StatusForbidden,
+		},
+		{
+			name:       "GetBookByID - malformed include_deleted",
+			method:     http.MethodGet,
+			url:        "/1?include_deleted=maybe",
+			mockSetup:  func() {},
+			expectCode: http.StatusBadRequest,
+		},
 		{
 			name:   "SearchGoogleBooks - success",
 			method: http.MethodGet,
 			url:    "/search/google?title=go",
 			mockSetup: func() {
-				mockService.On("SearchGoogleBooks", mock.Anything, "go").Return(mockGoogleBooks, nil).Once()
+				mockService.On("SearchGoogleBooks", mock.Anything, entities.GoogleBookQuery{Title: "go"}).Return(mockGooglePage, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "SearchGoogleBooks - qualifiers and paging",
+			method: http.MethodGet,
+			url:    "/search/google?q=spice&author=herbert&isbn=0441172717&startIndex=20&maxResults=40&langRestrict=en&orderBy=newest",
+			mockSetup: func() {
+				mockService.On("SearchGoogleBooks", mock.Anything, entities.GoogleBookQuery{
+					Query:        "spice",
+					Author:       "herbert",
+					ISBN:         "0441172717",
+					StartIndex:   20,
+					MaxResults:   40,
+					LangRestrict: "en",
+					OrderBy:      entities.GoogleOrderNewest,
+				}).Return(mockGooglePage, nil).Once()
 			},
 			expectCode: http.StatusOK,
 		},
 		{
-			name:       "SearchGoogleBooks - missing title",
+			name:       "SearchGoogleBooks - malformed paging",
 			method:     http.MethodGet,
-			url:        "/search/google",
+			url:        "/search/google?q=go&startIndex=-1",
 			mockSetup:  func() {},
 			expectCode: http.StatusBadRequest,
 		},
+		{
+			name:   "SearchGoogleBooks - missing search terms",
+			method: http.MethodGet,
+			url:    "/search/google",
+			mockSetup: func() {
+				mockService.On("SearchGoogleBooks", mock.Anything, entities.GoogleBookQuery{}).
+					Return(nil, &apperr.ValidationError{Fields: []apperr.FieldError{{Field: "q", Rule: "required"}}}).Once()
+			},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:   "SearchGoogleBooks - rate limited",
+			method: http.MethodGet,
+			url:    "/search/google?q=go",
+			mockSetup: func() {
+				mockService.On("SearchGoogleBooks", mock.Anything, entities.GoogleBookQuery{Query: "go"}).
+					Return(nil, apperr.RateLimited("google books API rate limit exceeded", 0, nil)).Once()
+			},
+			expectCode: http.StatusTooManyRequests,
+		},
+		{
+			name:   "SearchExternalBooks - all providers",
+			method: http.MethodGet,
+			url:    "/search/external?q=dune",
+			mockSetup: func() {
+				mockService.On("SearchExternalBooks", mock.Anything, "dune", []string(nil)).Return(externalResult, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "SearchExternalBooks - selected providers",
+			method: http.MethodGet,
+			url:    "/search/external?q=dune&provider=google,+openlibrary&provider=google",
+			mockSetup: func() {
+				mockService.On("SearchExternalBooks", mock.Anything, "dune", []string{"google", "openlibrary", "google"}).Return(externalResult, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "SearchExternalBooks - unknown provider",
+			method: http.MethodGet,
+			url:    "/search/external?q=dune&provider=amazon",
+			mockSetup: func() {
+				mockService.On("SearchExternalBooks", mock.Anything, "dune", []string{"amazon"}).
+					Return(nil, apperr.Validation(`unknown catalog provider "amazon"`, nil)).Once()
+			},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:   "SearchBooks - success",
+			method: http.MethodGet,
+			url:    "/search?q=%22left+hand%22+-darkness&limit=5&offset=5&fuzzy=true",
+			mockSetup: func() {
+				mockService.On("SearchBooks", mock.Anything, entities.BookSearchQuery{
+					Query:  `"left hand" -darkness`,
+					Limit:  5,
+					Offset: 5,
+					Fuzzy:  true,
+				}).Return(&entities.BookSearchPage{Items: []*entities.BookSearchHit{}}, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:       "SearchBooks - malformed fuzzy flag",
+			method:     http.MethodGet,
+			url:        "/search?q=dune&fuzzy=maybe",
+			mockSetup:  func() {},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:   "SearchBooks - missing query",
+			method: http.MethodGet,
+			url:    "/search",
+			mockSetup: func() {
+				mockService.On("SearchBooks", mock.Anything, entities.BookSearchQuery{}).
+					Return(nil, &apperr.ValidationError{Fields: []apperr.FieldError{{Field: "q", Rule: "required", Message: "q is required"}}}).Once()
+			},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:       "SearchExternalBooks - missing query",
+			method:     http.MethodGet,
+			url:        "/search/external?provider=google",
+			mockSetup:  func() {},
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:   "ImportGoogleBook - created",
+			method: http.MethodPost,
+			url:    "/import/google",
+			body:   map[string]string{"volume_id": "g1"},
+			mockSetup: func() {
+				mockService.On("ImportGoogleBook", mock.Anything, "g1").Return(mockBook, true, nil).Once()
+			},
+			expectCode: http.StatusCreated,
+		},
+		{
+			name:   "ImportGoogleBook - updated",
+			method: http.MethodPost,
+			url:    "/import/google",
+			body:   map[string]string{"volume_id": "g1"},
+			mockSetup: func() {
+				mockService.On("ImportGoogleBook", mock.Anything, "g1").Return(mockBook, false, nil).Once()
+			},
+			expectCode: http.StatusOK,
+		},
+		{
+			name:   "ImportGoogleBook - unknown volume",
+			method: http.MethodPost,
+			url:    "/import/google",
+			body:   map[string]string{"volume_id": "nope"},
+			mockSetup: func() {
+				mockService.On("ImportGoogleBook", mock.Anything, "nope").
+					Return(nil, false, apperr.NotFound("google volume nope not found")).Once()
+			},
+			expectCode: http.StatusNotFound,
+		},
 	}
 
 	for _, tc := range tests {
@@ -127,6 +560,9 @@ func TestBookHandlers(t *testing.T) {
 			} else {
 				req = httptest.NewRequest(tc.method, tc.url, nil)
 			}
+			for key, value := range tc.header {
+				req.Header.Set(key, value)
+			}
 
 			rec := httptest.NewRecorder()
 			r.ServeHTTP(rec, req)
@@ -135,3 +571,99 @@ func TestBookHandlers(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBookByID_IncludeDeleted(t *testing.T) {
+	mockService := new(domain.MockBookService)
+	r := setupRouter(book.NewHandler(mockService, slog.New(slog.DiscardHandler)))
+
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	// Only a context asking for deleted books finds this one
+	mockService.On("GetBookByID", mock.MatchedBy(storage.IncludesDeleted), 1).
+		Return(&entities.Book{ID: 1, Title: "Go 101", Version: 2, DeletedAt: &deletedAt}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/1?include_deleted=true", nil)
+	req = req.WithContext(auth.WithAdmin(req.Context()))
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var got entities.Book
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, &deletedAt, got.DeletedAt)
+	mockService.AssertExpectations(t)
+}
+
+func TestGetBookHistory(t *testing.T) {
+	mockService := new(domain.MockBookService)
+	r := setupRouter(book.NewHandler(mockService, slog.New(slog.DiscardHandler)))
+
+	history := []*entities.Revision{
+		{Entity: "book", EntityID: 1, Revision: 2, Action: entities.AuditUpdate, Actor: auth.ActorAnonymous,
+			Changes: map[string]entities.FieldChange{"title": {Before: json.RawMessage(`"Go"`), After: json.RawMessage(`"Go 101"`)}}},
+		{Entity: "book", EntityID: 1, Revision: 1, Action: entities.AuditCreate, Actor: auth.ActorAnonymous},
+	}
+	mockService.On("GetBookHistory", mock.Anything, 1).Return(history, nil).Once()
+	mockService.On("GetBookHistory", mock.Anything, 2).Return(nil, apperr.NotFound("book with ID 2 not found")).Once()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/1/history", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var got []entities.Revision
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Len(t, got, 2)
+	assert.JSONEq(t, `"Go"`, string(got[0].Changes["title"].Before))
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/2/history", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRevertBook(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		admin        bool
+		callsService bool
+		serviceErr   error
+		expectCode   int
+	}{
+		{name: "reverted", url: "/1/history/2/revert", admin: true, callsService: true, expectCode: http.StatusOK},
+		{name: "not an admin", url: "/1/history/2/revert", expectCode: http.StatusForbidden},
+		{name: "invalid revision", url: "/1/history/first/revert", admin: true, expectCode: http.StatusBadRequest},
+		{name: "unknown revision", url: "/1/history/2/revert", admin: true, callsService: true,
+			serviceErr: apperr.NotFound("book with ID 1 has no revision 2"), expectCode: http.StatusNotFound},
+		{name: "stale version", url: "/1/history/2/revert", admin: true, callsService: true,
+			serviceErr: apperr.Conflict("book with ID 1 is at version 5, not 4", storage.ErrVersionMismatch), expectCode: http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(domain.MockBookService)
+			r := setupRouter(book.NewHandler(mockService, slog.New(slog.DiscardHandler)))
+			if tc.callsService {
+				var reverted *entities.Book
+				if tc.serviceErr == nil {
+					reverted = &entities.Book{ID: 1, Title: "Go", Version: 5}
+				}
+				mockService.On("RevertBook", mock.Anything, 1, 2, 4).Return(reverted, tc.serviceErr).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, tc.url, nil)
+			req.Header.Set("If-Match", `"4"`)
+			if tc.admin {
+				req = req.WithContext(auth.WithAdmin(req.Context()))
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			if tc.expectCode == http.StatusOK {
+				assert.Equal(t, `"5"`, rec.Header().Get("ETag"))
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
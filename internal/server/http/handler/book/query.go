@@ -0,0 +1,135 @@
+package book
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+)
+
+// parseBookQuery reads the paging, sorting and filtering parameters of
+// GET /books. Sorting is `sort=price` for ascending and `sort=-price` for
+// descending order. tag may be repeated to require several tags.
+func parseBookQuery(values url.Values) (entities.BookQuery, error) {
+	var q entities.BookQuery
+	var err error
+
+	if q.Limit, err = parseInt(values, "limit"); err != nil {
+		return q, err
+	}
+	if q.Offset, err = parseInt(values, "offset"); err != nil {
+		return q, err
+	}
+	if q.AuthorID, err = parseInt(values, "author_id"); err != nil {
+		return q, err
+	}
+	q.Cursor = values.Get("cursor")
+	q.Genre = values.Get("genre")
+	q.Tags = values["tag"]
+
+	if sort := values.Get("sort"); sort != "" {
+		q.Desc = strings.HasPrefix(sort, "-")
+		q.Sort = entities.BookSortField(strings.TrimPrefix(sort, "-"))
+		if !q.Sort.Valid() {
+			return q, fmt.Errorf("sort must be one of title, price, published_at")
+		}
+	}
+
+	if q.MinPrice, err = parseFloat(values, "min_price"); err != nil {
+		return q, err
+	}
+	if q.MaxPrice, err = parseFloat(values, "max_price"); err != nil {
+		return q, err
+	}
+	if q.PublishedAfter, err = parseTime(values, "published_after"); err != nil {
+		return q, err
+	}
+	if q.PublishedBefore, err = parseTime(values, "published_before"); err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+// parseBookSearchQuery reads the parameters of GET /books/search.
+func parseBookSearchQuery(values url.Values) (entities.BookSearchQuery, error) {
+	q := entities.BookSearchQuery{Query: values.Get("q")}
+	var err error
+
+	if q.Limit, err = parseInt(values, "limit"); err != nil {
+		return q, err
+	}
+	if q.Offset, err = parseInt(values, "offset"); err != nil {
+		return q, err
+	}
+	if raw := values.Get("fuzzy"); raw != "" {
+		if q.Fuzzy, err = strconv.ParseBool(raw); err != nil {
+			return q, fmt.Errorf("fuzzy must be true or false")
+		}
+	}
+	return q, nil
+}
+
+// parseGoogleBookQuery reads the parameters of GET /books/search/google.
+// They follow the Google Books names; q is free text while title, author
+// and isbn search those fields.
+func parseGoogleBookQuery(values url.Values) (entities.GoogleBookQuery, error) {
+	q := entities.GoogleBookQuery{
+		Query:        values.Get("q"),
+		Title:        values.Get("title"),
+		Author:       values.Get("author"),
+		ISBN:         values.Get("isbn"),
+		LangRestrict: values.Get("langRestrict"),
+		OrderBy:      entities.GoogleOrderBy(values.Get("orderBy")),
+	}
+	var err error
+
+	if q.StartIndex, err = parseInt(values, "startIndex"); err != nil {
+		return q, err
+	}
+	if q.MaxResults, err = parseInt(values, "maxResults"); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+func parseInt(values url.Values, key string) (int, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", key)
+	}
+	return n, nil
+}
+
+func parseFloat(values url.Values, key string) (*float64, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", key)
+	}
+	return &f, nil
+}
+
+// parseTime accepts either a full RFC 3339 timestamp or a plain date.
+func parseTime(values url.Values, key string) (*time.Time, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be a date (2006-01-02) or RFC 3339 timestamp", key)
+}
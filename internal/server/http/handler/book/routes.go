@@ -1,13 +1,27 @@
 package book
 
-import "github.com/go-chi/chi/v5"
+import (
+	"github.com/go-chi/chi/v5"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+)
 
 func RegisterRoutes(r chi.Router, h *Handler) {
 	r.Get("/", h.GetAllBooks)
 	r.Get("/{id}", h.GetBookByID)
+	r.Get("/isbn/{isbn}", h.GetBookByISBN)
 	r.Post("/", h.AddBook)
+	r.Put("/{id}", h.UpdateBook)
+	r.Patch("/{id}", h.PatchBook)
+	// Deprecated: PUT /{id} takes the ID from the path
 	r.Put("/", h.UpdateBook)
 	r.Delete("/{id}", h.DeleteBook)
+	r.Post("/{id}/restore", h.RestoreBook)
+	r.Get("/{id}/history", h.GetBookHistory)
+	r.With(httpx.RequireAdmin).Post("/{id}/history/{revision}/revert", h.RevertBook)
 
+	r.Get("/search", h.SearchBooks)
 	r.Get("/search/google", h.SearchGoogleBooks)
+	r.Get("/search/external", h.SearchExternalBooks)
+	r.Post("/import/google", h.ImportGoogleBook)
 }
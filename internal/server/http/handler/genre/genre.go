@@ -0,0 +1,100 @@
+package genre
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	GenreService service.GenreService
+	logger       *slog.Logger
+}
+
+func NewHandler(genreService service.GenreService, logger *slog.Logger) *Handler {
+	return &Handler{GenreService: genreService, logger: logging.Component(logger, "http")}
+}
+
+func (h *Handler) GetAllGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := h.GenreService.GetAllGenres(r.Context())
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(genres)
+}
+
+func (h *Handler) AddGenre(w http.ResponseWriter, r *http.Request) {
+	var genre entities.Genre
+	if !httpx.DecodeJSON(w, r, &genre) {
+		return
+	}
+
+	if err := h.GenreService.AddGenre(r.Context(), &genre); err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(genre)
+}
+
+func (h *Handler) GetGenreByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	genre, err := h.GenreService.GetGenreByID(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(genre)
+}
+
+func (h *Handler) UpdateGenre(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var genre entities.Genre
+	if !httpx.DecodeJSON(w, r, &genre) {
+		return
+	}
+	genre.ID = id
+
+	if err := h.GenreService.UpdateGenre(r.Context(), &genre); err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(genre)
+}
+
+func (h *Handler) DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.GenreService.RemoveGenre(r.Context(), id); err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
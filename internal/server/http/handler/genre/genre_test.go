@@ -0,0 +1,140 @@
+package genre
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetAllGenres(t *testing.T) {
+	mockService := new(domain.MockGenreService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+	fantasy := 1
+	genres := []*entities.Genre{
+		{ID: 1, Slug: "fantasy", Name: "Fantasy", BookCount: 3},
+		{ID: 2, Slug: "high-fantasy", Name: "High fantasy", ParentID: &fantasy, BookCount: 1},
+	}
+	mockService.On("GetAllGenres", mock.Anything).Return(genres, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/genres", nil)
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/genres", handler.GetAllGenres)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[
+		{"id": 1, "slug": "fantasy", "name": "Fantasy", "parent_id": null, "book_count": 3},
+		{"id": 2, "slug": "high-fantasy", "name": "High fantasy", "parent_id": 1, "book_count": 1}
+	]`, rec.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestAddGenre(t *testing.T) {
+	mockService := new(domain.MockGenreService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+	mockService.On("AddGenre", mock.Anything, &entities.Genre{Name: "Fantasy"}).
+		Run(func(args mock.Arguments) {
+			genre := args.Get(1).(*entities.Genre)
+			genre.ID = 1
+			genre.Slug = "fantasy"
+		}).
+		Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/genres", bytes.NewBufferString(`{"name": "Fantasy"}`))
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Post("/genres", handler.AddGenre)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var result entities.Genre
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, "fantasy", result.Slug)
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateGenre(t *testing.T) {
+	parent := 4
+
+	tests := []struct {
+		name       string
+		serviceErr error
+		expectCode int
+	}{
+		{name: "updated", serviceErr: nil, expectCode: http.StatusOK},
+		{name: "parent is a subgenre", serviceErr: apperr.Validation("genre 2 cannot be moved below its own subgenre 4", storage.ErrGenreCycle), expectCode: http.StatusBadRequest},
+		{name: "unknown genre", serviceErr: apperr.NotFound("genre with ID 2 not found"), expectCode: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(domain.MockGenreService)
+			handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+			// The ID is taken from the path, not the body
+			mockService.On("UpdateGenre", mock.Anything, &entities.Genre{ID: 2, Name: "Epic", ParentID: &parent}).Return(tc.serviceErr)
+
+			req := httptest.NewRequest(http.MethodPut, "/genres/2", bytes.NewBufferString(`{"name": "Epic", "parent_id": 4}`))
+			rec := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Put("/genres/{id}", handler.UpdateGenre)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteGenre(t *testing.T) {
+	tests := []struct {
+		name       string
+		serviceErr error
+		expectCode int
+	}{
+		{name: "deleted", serviceErr: nil, expectCode: http.StatusNoContent},
+		{name: "genre still has subgenres", serviceErr: apperr.Conflict("genre with ID 3 still has subgenres", storage.ErrGenreHasSubgenres), expectCode: http.StatusConflict},
+		{name: "database failure", serviceErr: errors.New("db down"), expectCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(domain.MockGenreService)
+			handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+			mockService.On("RemoveGenre", mock.Anything, 3).Return(tc.serviceErr)
+
+			req := httptest.NewRequest(http.MethodDelete, "/genres/3", nil)
+			rec := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Delete("/genres/{id}", handler.DeleteGenre)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package genre
+
+import "github.com/go-chi/chi/v5"
+
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Get("/", h.GetAllGenres)
+	r.Post("/", h.AddGenre)
+	r.Get("/{id}", h.GetGenreByID)
+	r.Put("/{id}", h.UpdateGenre)
+	r.Delete("/{id}", h.DeleteGenre)
+}
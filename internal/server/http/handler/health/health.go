@@ -0,0 +1,125 @@
+// Package health serves the liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFail     = "fail"
+	StatusDraining = "draining"
+)
+
+// DefaultTimeout bounds a check that does not set its own timeout.
+const DefaultTimeout = 2 * time.Second
+
+// Check is a readiness dependency such as the database.
+type Check struct {
+	Name    string
+	Timeout time.Duration
+	Fn      func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of one Check in a readiness response.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Response is the body of /healthz and /readyz.
+type Response struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type Handler struct {
+	checks   []Check
+	draining atomic.Bool
+}
+
+func NewHandler(checks ...Check) *Handler {
+	return &Handler{checks: checks}
+}
+
+// StartDraining makes readiness fail from now on so that load balancers stop
+// routing new traffic while the server shuts down.
+func (h *Handler) StartDraining() {
+	h.draining.Store(true)
+}
+
+// Liveness reports that the process is up. It never checks dependencies so
+// that a database outage does not get the process restarted.
+func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Response{Status: StatusOK})
+}
+
+// Readiness runs every check concurrently, each under its own timeout, and
+// answers 503 if any of them fails or the server is draining.
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	results := make(map[string]CheckResult, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := run(r.Context(), check)
+			mu.Lock()
+			results[check.Name] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	resp := Response{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			resp.Status = StatusFail
+		}
+	}
+	if h.draining.Load() {
+		resp.Status = StatusDraining
+	}
+
+	status := http.StatusOK
+	if resp.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+func run(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Fn(ctx)
+	result := CheckResult{
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
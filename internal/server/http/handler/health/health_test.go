@@ -0,0 +1,113 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/health"
+)
+
+func setupRouter(h *health.Handler) *chi.Mux {
+	r := chi.NewRouter()
+	health.RegisterRoutes(r, h)
+	return r
+}
+
+func get(t *testing.T, r http.Handler, url string) (int, health.Response) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+
+	var resp health.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	return rec.Code, resp
+}
+
+func ok(ctx context.Context) error { return nil }
+
+func TestLiveness_IgnoresDependencies(t *testing.T) {
+	h := health.NewHandler(health.Check{Name: "postgres", Fn: func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}})
+
+	code, resp := get(t, setupRouter(h), "/healthz")
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, resp.Status)
+}
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name           string
+		checks         []health.Check
+		draining       bool
+		expectedCode   int
+		expectedStatus string
+		expectedChecks map[string]string
+	}{
+		{
+			name:           "all dependencies healthy",
+			checks:         []health.Check{{Name: "postgres", Fn: ok}, {Name: "google_books", Fn: ok}},
+			expectedCode:   http.StatusOK,
+			expectedStatus: health.StatusOK,
+			expectedChecks: map[string]string{"postgres": health.StatusOK, "google_books": health.StatusOK},
+		},
+		{
+			name: "database down",
+			checks: []health.Check{
+				{Name: "postgres", Fn: func(ctx context.Context) error { return errors.New("connection refused") }},
+				{Name: "google_books", Fn: ok},
+			},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedStatus: health.StatusFail,
+			expectedChecks: map[string]string{"postgres": health.StatusFail, "google_books": health.StatusOK},
+		},
+		{
+			name: "check exceeding its timeout",
+			checks: []health.Check{{Name: "postgres", Timeout: 10 * time.Millisecond, Fn: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}}},
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedStatus: health.StatusFail,
+			expectedChecks: map[string]string{"postgres": health.StatusFail},
+		},
+		{
+			name:           "draining",
+			checks:         []health.Check{{Name: "postgres", Fn: ok}},
+			draining:       true,
+			expectedCode:   http.StatusServiceUnavailable,
+			expectedStatus: health.StatusDraining,
+			expectedChecks: map[string]string{"postgres": health.StatusOK},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := health.NewHandler(tc.checks...)
+			if tc.draining {
+				h.StartDraining()
+			}
+
+			code, resp := get(t, setupRouter(h), "/readyz")
+
+			assert.Equal(t, tc.expectedCode, code)
+			assert.Equal(t, tc.expectedStatus, resp.Status)
+			require.Len(t, resp.Checks, len(tc.expectedChecks))
+			for name, status := range tc.expectedChecks {
+				assert.Equal(t, status, resp.Checks[name].Status, name)
+				assert.GreaterOrEqual(t, resp.Checks[name].LatencyMS, 0.0)
+			}
+		})
+	}
+}
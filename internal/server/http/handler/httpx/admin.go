@@ -0,0 +1,19 @@
+package httpx
+
+import (
+	"net/http"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+)
+
+// RequireAdmin is a middleware answering 403 Forbidden to requests not
+// made by an admin.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !auth.IsAdmin(r.Context()) {
+			WriteProblem(w, r, http.StatusForbidden, "only admins may do this")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
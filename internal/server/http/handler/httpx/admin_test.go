@@ -0,0 +1,41 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name           string
+		admin          bool
+		expectedStatus int
+	}{
+		{name: "admin", admin: true, expectedStatus: http.StatusNoContent},
+		{name: "anyone else", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/books/1/history/2/revert", nil)
+			if tc.admin {
+				req = req.WithContext(auth.WithAdmin(req.Context()))
+			}
+			rec := httptest.NewRecorder()
+
+			httpx.RequireAdmin(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
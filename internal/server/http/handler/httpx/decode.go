@@ -0,0 +1,54 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxBodyBytes bounds the size of JSON request bodies.
+const MaxBodyBytes = 1 << 20 // 1 MiB
+
+// DecodeJSON decodes a single JSON object from the request body into dst.
+// Unknown fields, trailing data and bodies over MaxBodyBytes are rejected.
+// On failure it writes the problem response and returns false.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodyBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err == nil && dec.Decode(&struct{}{}) != io.EOF {
+		err = errors.New("body must contain a single JSON object")
+	}
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		WriteProblem(w, r, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit))
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		WriteProblem(w, r, http.StatusBadRequest, "request body contains malformed JSON")
+	case errors.As(err, &typeErr):
+		WriteProblem(w, r, http.StatusBadRequest,
+			fmt.Sprintf("field %q must be of type %s", typeErr.Field, typeErr.Type))
+	case errors.Is(err, io.EOF):
+		WriteProblem(w, r, http.StatusBadRequest, "request body must not be empty")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields
+		WriteProblem(w, r, http.StatusBadRequest,
+			"request body contains unknown field "+strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		WriteProblem(w, r, http.StatusBadRequest, err.Error())
+	}
+	return false
+}
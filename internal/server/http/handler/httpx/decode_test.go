@@ -0,0 +1,50 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type payload struct {
+		Title string  `json:"title"`
+		Price float64 `json:"price"`
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectOK       bool
+		expectedStatus int
+	}{
+		{name: "valid object", body: `{"title":"Go","price":10}`, expectOK: true},
+		{name: "unknown field", body: `{"title":"Go","isbn":"123"}`, expectedStatus: http.StatusBadRequest},
+		{name: "wrong type", body: `{"price":"ten"}`, expectedStatus: http.StatusBadRequest},
+		{name: "malformed JSON", body: `{"title":`, expectedStatus: http.StatusBadRequest},
+		{name: "empty body", body: ``, expectedStatus: http.StatusBadRequest},
+		{name: "trailing data", body: `{"title":"Go"}{"title":"Again"}`, expectedStatus: http.StatusBadRequest},
+		{name: "oversized body", body: `{"title":"` + strings.Repeat("a", httpx.MaxBodyBytes) + `"}`, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+
+			var dst payload
+			ok := httpx.DecodeJSON(rec, req, &dst)
+
+			assert.Equal(t, tc.expectOK, ok)
+			if !tc.expectOK {
+				assert.Equal(t, tc.expectedStatus, rec.Code)
+				assert.Equal(t, httpx.ProblemContentType, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
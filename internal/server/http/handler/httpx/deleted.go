@@ -0,0 +1,36 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+// IncludeDeleted returns the context to serve a read in: one in which
+// deleted books and authors are found too when the request asks for them
+// with include_deleted=true. Only admins may ask; others are answered 403
+// Forbidden, and an invalid value 400 Bad Request. ok is then false.
+func IncludeDeleted(w http.ResponseWriter, r *http.Request) (ctx context.Context, ok bool) {
+	ctx = r.Context()
+	value := r.URL.Query().Get("include_deleted")
+	if value == "" {
+		return ctx, true
+	}
+
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		WriteProblem(w, r, http.StatusBadRequest, "Invalid include_deleted query parameter")
+		return nil, false
+	}
+	if !include {
+		return ctx, true
+	}
+	if !auth.IsAdmin(ctx) {
+		WriteProblem(w, r, http.StatusForbidden, "only admins may include deleted entries")
+		return nil, false
+	}
+	return storage.WithDeleted(ctx), true
+}
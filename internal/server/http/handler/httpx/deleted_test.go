@@ -0,0 +1,50 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+func TestIncludeDeleted(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		admin          bool
+		expectOK       bool
+		expectDeleted  bool
+		expectedStatus int
+	}{
+		{name: "not asked", expectOK: true},
+		{name: "asked by an admin", query: "?include_deleted=true", admin: true, expectOK: true, expectDeleted: true},
+		{name: "declined", query: "?include_deleted=false", expectOK: true},
+		{name: "asked by anyone else", query: "?include_deleted=true", expectedStatus: http.StatusForbidden},
+		{name: "invalid value", query: "?include_deleted=maybe", admin: true, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/books"+tc.query, nil)
+			if tc.admin {
+				req = req.WithContext(auth.WithAdmin(req.Context()))
+			}
+			rec := httptest.NewRecorder()
+
+			ctx, ok := httpx.IncludeDeleted(rec, req)
+
+			assert.Equal(t, tc.expectOK, ok)
+			if tc.expectOK {
+				assert.Equal(t, tc.expectDeleted, storage.IncludesDeleted(ctx))
+				return
+			}
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, httpx.ProblemContentType, rec.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package httpx
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag returns the entity tag of a resource at the given version.
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// NotModified sets the ETag of a resource at version and reports whether
+// the request's If-None-Match already names it. In that case it has
+// answered 304 Not Modified and the caller must not write a body.
+func NotModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	etag := ETag(version)
+	w.Header().Set("ETag", etag)
+
+	for _, tag := range entityTags(r.Header.Get("If-None-Match")) {
+		// If-None-Match uses the weak comparison
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// IfMatch returns the version the request's If-Match header requires, or 0
+// when there is no header or it is "*". A header that cannot match any
+// version, such as a weak or foreign entity tag, is answered with 412
+// Precondition Failed and a list of several tags with 400 Bad Request; ok
+// is then false.
+func IfMatch(w http.ResponseWriter, r *http.Request) (version int, ok bool) {
+	tags := entityTags(r.Header.Get("If-Match"))
+	switch {
+	case len(tags) == 0 || tags[0] == "*":
+		return 0, true
+	case len(tags) > 1:
+		WriteProblem(w, r, http.StatusBadRequest, "If-Match must name a single ETag")
+		return 0, false
+	}
+
+	// If-Match uses the strong comparison, so weak tags never match
+	raw, quoted := strings.CutPrefix(tags[0], `"`)
+	raw, closed := strings.CutSuffix(raw, `"`)
+	version, err := strconv.Atoi(raw)
+	if !quoted || !closed || err != nil || version <= 0 {
+		WriteProblem(w, r, http.StatusPreconditionFailed, "If-Match does not match the current ETag")
+		return 0, false
+	}
+	return version, true
+}
+
+// entityTags splits the comma-separated list of an If-Match or
+// If-None-Match header.
+func entityTags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
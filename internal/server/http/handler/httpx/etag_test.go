@@ -0,0 +1,79 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+)
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{name: "no header"},
+		{name: "same version", ifNoneMatch: `"3"`, expected: true},
+		{name: "weak tag of the same version", ifNoneMatch: `W/"3"`, expected: true},
+		{name: "one of several tags", ifNoneMatch: `"1", "3"`, expected: true},
+		{name: "any version", ifNoneMatch: "*", expected: true},
+		{name: "older version", ifNoneMatch: `"2"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rr := httptest.NewRecorder()
+
+			assert.Equal(t, tt.expected, httpx.NotModified(rr, req, 3))
+			assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+			if tt.expected {
+				assert.Equal(t, http.StatusNotModified, rr.Code)
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		ifMatch         string
+		expectedVersion int
+		expectedOK      bool
+		expectedStatus  int
+	}{
+		{name: "no header", expectedOK: true},
+		{name: "any version", ifMatch: "*", expectedOK: true},
+		{name: "strong tag", ifMatch: `"3"`, expectedVersion: 3, expectedOK: true},
+		{name: "weak tag", ifMatch: `W/"3"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "foreign tag", ifMatch: `"abc"`, expectedStatus: http.StatusPreconditionFailed},
+		{name: "unquoted tag", ifMatch: "3", expectedStatus: http.StatusPreconditionFailed},
+		{name: "several tags", ifMatch: `"2", "3"`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/books/1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+
+			version, ok := httpx.IfMatch(rr, req)
+
+			assert.Equal(t, tt.expectedVersion, version)
+			assert.Equal(t, tt.expectedOK, ok)
+			if !tt.expectedOK {
+				assert.Equal(t, tt.expectedStatus, rr.Code)
+				assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
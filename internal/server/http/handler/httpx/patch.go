@@ -0,0 +1,45 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
+)
+
+// AcceptPatch lists the patch media types PATCH endpoints accept.
+const AcceptPatch = jsonpatch.MergePatchType + ", " + jsonpatch.JSONPatchType
+
+// DecodePatch reads a JSON Merge Patch or a JSON Patch from the request
+// body, as its Content-Type says. Other media types are answered with 415
+// Unsupported Media Type and an Accept-Patch header, malformed patches
+// with 400 Bad Request; ok is then false.
+func DecodePatch(w http.ResponseWriter, r *http.Request) (patch jsonpatch.Patch, ok bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != jsonpatch.MergePatchType && mediaType != jsonpatch.JSONPatchType) {
+		w.Header().Set("Accept-Patch", AcceptPatch)
+		WriteProblem(w, r, http.StatusUnsupportedMediaType, "Content-Type must be one of "+AcceptPatch)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		WriteProblem(w, r, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit))
+		return nil, false
+	}
+	if err != nil {
+		WriteProblem(w, r, http.StatusBadRequest, "failed to read request body")
+		return nil, false
+	}
+
+	if patch, err = jsonpatch.Parse(mediaType, body); err != nil {
+		WriteProblem(w, r, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return patch, true
+}
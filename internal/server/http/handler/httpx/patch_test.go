@@ -0,0 +1,54 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+)
+
+func TestDecodePatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectOK       bool
+		expectedStatus int
+	}{
+		{name: "merge patch", contentType: "application/merge-patch+json", body: `{"price":10}`, expectOK: true},
+		{name: "merge patch with charset", contentType: "application/merge-patch+json; charset=utf-8", body: `{"price":10}`, expectOK: true},
+		{name: "json patch", contentType: "application/json-patch+json", body: `[{"op":"remove","path":"/tags"}]`, expectOK: true},
+		{name: "plain json", contentType: "application/json", body: `{"price":10}`, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "no content type", body: `{"price":10}`, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "malformed merge patch", contentType: "application/merge-patch+json", body: `{"price":`, expectedStatus: http.StatusBadRequest},
+		{name: "unknown op", contentType: "application/json-patch+json", body: `[{"op":"drop","path":"/tags"}]`, expectedStatus: http.StatusBadRequest},
+		{name: "oversized body", contentType: "application/merge-patch+json", body: `{"title":"` + strings.Repeat("a", httpx.MaxBodyBytes) + `"}`, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/books/1", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			patch, ok := httpx.DecodePatch(rec, req)
+
+			assert.Equal(t, tc.expectOK, ok)
+			if tc.expectOK {
+				assert.NotNil(t, patch)
+				return
+			}
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, httpx.ProblemContentType, rec.Header().Get("Content-Type"))
+			if tc.expectedStatus == http.StatusUnsupportedMediaType {
+				assert.Equal(t, httpx.AcceptPatch, rec.Header().Get("Accept-Patch"))
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Package httpx holds the helpers shared by the HTTP handlers.
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Errors lists the rejected fields of a validation problem.
+	Errors []apperr.FieldError `json:"errors,omitempty"`
+}
+
+// StatusFor maps an error to the HTTP status code of its apperr kind.
+// Errors of no known kind are internal server errors. A version mismatch
+// is a conflict that only If-Match can cause, so it fails the precondition.
+func StatusFor(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, apperr.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperr.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, apperr.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, apperr.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, apperr.ErrRateLimited):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteError translates err into a problem details response. Only the
+// client-safe apperr message is exposed; internal errors are logged to
+// logger and reported without detail. A retry hint on the error is sent as
+// a Retry-After header in whole seconds.
+func WriteError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, err error) {
+	status := StatusFor(err)
+
+	problem := newProblem(r, status, apperr.Message(err))
+	if status == http.StatusInternalServerError {
+		logger.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+		problem.Detail = ""
+	}
+	if fields := apperr.Fields(err); len(fields) > 0 {
+		problem.Detail = "one or more fields are invalid"
+		problem.Errors = fields
+	}
+	if retryAfter := apperr.RetryAfter(err); retryAfter > 0 {
+		seconds := int64((retryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+
+	writeProblem(w, problem)
+}
+
+// WriteProblem writes a problem details response with the given status.
+func WriteProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	writeProblem(w, newProblem(r, status, detail))
+}
+
+func newProblem(r *http.Request, status int, detail string) Problem {
+	return Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	}
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
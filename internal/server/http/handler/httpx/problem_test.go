@@ -0,0 +1,107 @@
+package httpx_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name               string
+		err                error
+		expectedStatus     int
+		expectedDetail     string
+		expectedRetryAfter string
+	}{
+		{
+			name:           "not found",
+			err:            apperr.NotFound("book with ID 1 not found"),
+			expectedStatus: http.StatusNotFound,
+			expectedDetail: "book with ID 1 not found",
+		},
+		{
+			name:           "wrapped conflict",
+			err:            fmt.Errorf("service: %w", apperr.Conflict("duplicate title", errors.New("23505"))),
+			expectedStatus: http.StatusConflict,
+			expectedDetail: "duplicate title",
+		},
+		{
+			name:           "version mismatch",
+			err:            apperr.Conflict("book with ID 1 is at version 3, not 2", storage.ErrVersionMismatch),
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedDetail: "book with ID 1 is at version 3, not 2",
+		},
+		{
+			name:           "validation",
+			err:            apperr.Validation("invalid cursor", nil),
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "invalid cursor",
+		},
+		{
+			name:           "unavailable",
+			err:            apperr.Unavailable("failed to count books", errors.New("dial tcp: connection refused")),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedDetail: "failed to count books",
+		},
+		{
+			name:               "rate limited",
+			err:                apperr.RateLimited("google books rate limit exceeded", 1500*time.Millisecond, nil),
+			expectedStatus:     http.StatusTooManyRequests,
+			expectedDetail:     "google books rate limit exceeded",
+			expectedRetryAfter: "2",
+		},
+		{
+			name:           "unclassified errors hide their detail",
+			err:            errors.New("pq: relation \"books\" does not exist"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedDetail: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/books/1", nil)
+			rec := httptest.NewRecorder()
+
+			httpx.WriteError(rec, req, slog.New(slog.DiscardHandler), tc.err)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			assert.Equal(t, httpx.ProblemContentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedRetryAfter, rec.Header().Get("Retry-After"))
+
+			var problem httpx.Problem
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tc.expectedStatus, problem.Status)
+			assert.Equal(t, http.StatusText(tc.expectedStatus), problem.Title)
+			assert.Equal(t, tc.expectedDetail, problem.Detail)
+			assert.Equal(t, "/books/1", problem.Instance)
+		})
+	}
+}
+
+func TestWriteError_ValidationFields(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/books", nil)
+	rec := httptest.NewRecorder()
+
+	httpx.WriteError(rec, req, slog.New(slog.DiscardHandler), &apperr.ValidationError{Fields: []apperr.FieldError{
+		{Field: "price", Rule: "min", Message: "price must not be negative"},
+	}})
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var problem httpx.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, []apperr.FieldError{{Field: "price", Rule: "min", Message: "price must not be negative"}}, problem.Errors)
+}
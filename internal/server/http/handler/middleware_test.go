@@ -0,0 +1,165 @@
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+)
+
+func TestRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "text", logging.NewLevels(slog.LevelInfo))
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(requestID)
+	r.Use(requestLogger(logger))
+	r.Get("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.InfoContext(r.Context(), "handler")
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "taken from the request", header: "abc-123", expected: "abc-123"},
+		{name: "generated when missing"},
+		{name: "generated when unsafe", header: "bad id\nforged=1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/books/7", nil)
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			if tc.expected != "" {
+				assert.Equal(t, tc.expected, id)
+			} else {
+				assert.Len(t, id, 32)
+			}
+
+			// Both the handler's line and the access log carry the ID
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, lines, 2)
+			for _, line := range lines {
+				assert.Contains(t, line, "request_id="+id)
+			}
+			assert.Contains(t, lines[1], "route=/books/{id}")
+			assert.Contains(t, lines[1], "status=200")
+		})
+	}
+}
+
+func TestIdentifyCaller_Admin(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		expected      bool
+	}{
+		{name: "admin token", token: "s3cret", authorization: "Bearer s3cret", expected: true},
+		{name: "other token", token: "s3cret", authorization: "Bearer guess"},
+		{name: "not a bearer token", token: "s3cret", authorization: "Basic s3cret"},
+		{name: "no header", token: "s3cret"},
+		{name: "no token configured", authorization: "Bearer "},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var isAdmin bool
+			h := identifyCaller(tc.token, "")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				isAdmin = auth.IsAdmin(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/books", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.expected, isAdmin)
+		})
+	}
+}
+
+func TestIdentifyCaller_Actor(t *testing.T) {
+	tests := []struct {
+		name           string
+		identityHeader string
+		authorization  string
+		caller         string
+		expected       string
+	}{
+		{name: "named caller", identityHeader: "X-Authenticated-User", caller: "alice", expected: "alice"},
+		{name: "named admin", identityHeader: "X-Authenticated-User", authorization: "Bearer s3cret", caller: "bob", expected: "bob"},
+		{name: "unnamed admin", identityHeader: "X-Authenticated-User", authorization: "Bearer s3cret", expected: auth.ActorAdmin},
+		{name: "unnamed caller", identityHeader: "X-Authenticated-User", expected: auth.ActorAnonymous},
+		{name: "name that could forge log lines", identityHeader: "X-Authenticated-User", caller: "alice\nbob", expected: auth.ActorAnonymous},
+		{name: "name too long", identityHeader: "X-Authenticated-User", caller: strings.Repeat("a", 129), expected: auth.ActorAnonymous},
+		{name: "no identity header configured", caller: "alice", expected: auth.ActorAnonymous},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var actor string
+			h := identifyCaller("s3cret", tc.identityHeader)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				actor = auth.Actor(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/books", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			if tc.caller != "" {
+				req.Header["X-Authenticated-User"] = []string{tc.caller}
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.expected, actor)
+		})
+	}
+}
+
+func TestTracing_NamesSpanAfterRoute(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	r := chi.NewRouter()
+	r.Use(tracing)
+	r.Get("/books/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/books/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /books/{id}", spans[0].Name())
+	// The incoming trace is continued
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+}
@@ -1,31 +1,62 @@
 package server
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/metrics"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/admin"
 	authorHandler "github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/author"
 	bookHandler "github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/book"
-	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+	genreHandler "github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/genre"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/health"
+	tagHandler "github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/tag"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service"
 )
 
-func NewRouter(
-	authorService *domain.AuthorService,
-	bookService *domain.BookService,
-) http.Handler {
+// Dependencies are the services and handlers the router is built from.
+type Dependencies struct {
+	AuthorService service.AuthorService
+	BookService   service.BookService
+	GenreService  service.GenreService
+	TagService    service.TagService
+	Health        *health.Handler
+	Metrics       *metrics.Metrics
+	Logger        *slog.Logger
+	LogLevels     *logging.Levels
+	// AdminToken identifies the requests made by an admin.
+	AdminToken string
+	// IdentityHeader names the caller of a request, set by an
+	// authenticating proxy. Nobody is named while it is empty.
+	IdentityHeader string
+}
+
+func NewRouter(deps Dependencies) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
-	r.Use(middleware.Logger)
+	r.Use(tracing)
+	r.Use(requestID)
+	r.Use(identifyCaller(deps.AdminToken, deps.IdentityHeader))
+	r.Use(requestLogger(logging.Component(deps.Logger, "http")))
 	r.Use(middleware.Recoverer)
+	r.Use(deps.Metrics.Middleware)
 
 	// Create handlers
-	authorH := authorHandler.NewHandler(authorService)
-	bookH := bookHandler.NewHandler(bookService)
+	authorH := authorHandler.NewHandler(deps.AuthorService, deps.Logger)
+	bookH := bookHandler.NewHandler(deps.BookService, deps.Logger)
+	genreH := genreHandler.NewHandler(deps.GenreService, deps.Logger)
+	tagH := tagHandler.NewHandler(deps.TagService, deps.Logger)
+	adminH := admin.NewHandler(deps.LogLevels, deps.Logger)
 
 	// Register routes
+	health.RegisterRoutes(r, deps.Health)
+	r.Method(http.MethodGet, "/metrics", deps.Metrics.Handler())
+
 	r.Route("/authors", func(r chi.Router) {
 		authorHandler.RegisterRoutes(r, authorH)
 	})
@@ -34,5 +65,17 @@ func NewRouter(
 		bookHandler.RegisterRoutes(r, bookH)
 	})
 
+	r.Route("/genres", func(r chi.Router) {
+		genreHandler.RegisterRoutes(r, genreH)
+	})
+
+	r.Route("/tags", func(r chi.Router) {
+		tagHandler.RegisterRoutes(r, tagH)
+	})
+
+	r.Route("/admin", func(r chi.Router) {
+		admin.RegisterRoutes(r, adminH)
+	})
+
 	return r
 }
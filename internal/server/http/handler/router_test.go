@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/metrics"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/health"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+)
+
+func TestNewRouter_AdminEndpointsRequireTheAdminToken(t *testing.T) {
+	router := NewRouter(Dependencies{
+		Health:     health.NewHandler(),
+		Metrics:    metrics.New(),
+		Logger:     slog.New(slog.DiscardHandler),
+		LogLevels:  logging.NewLevels(slog.LevelInfo),
+		AdminToken: "s3cret",
+	})
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "admin token", authorization: "Bearer s3cret", expectedStatus: http.StatusOK},
+		{name: "no token", expectedStatus: http.StatusForbidden},
+		{name: "wrong token", authorization: "Bearer guess", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/log-levels", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestNewRouter_WritesAreMadeByTheNamedCaller(t *testing.T) {
+	bookService := &domain.MockBookService{}
+	var actors []string
+	bookService.On("RemoveBook", mock.Anything, 3, 0).
+		Run(func(args mock.Arguments) {
+			actors = append(actors, auth.Actor(args.Get(0).(context.Context)))
+		}).
+		Return(nil)
+	router := NewRouter(Dependencies{
+		BookService:    bookService,
+		Health:         health.NewHandler(),
+		Metrics:        metrics.New(),
+		Logger:         slog.New(slog.DiscardHandler),
+		LogLevels:      logging.NewLevels(slog.LevelInfo),
+		IdentityHeader: "X-Authenticated-User",
+	})
+
+	for _, caller := range []string{"alice", "bob"} {
+		req := httptest.NewRequest(http.MethodDelete, "/books/3", nil)
+		req.Header.Set("X-Authenticated-User", caller)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	}
+	assert.Equal(t, []string{"alice", "bob"}, actors)
+}
@@ -1,29 +1,104 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
-	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
 )
 
-func StartServer(authorService *domain.AuthorService, bookService *domain.BookService) {
+// Config holds the listener settings of the HTTP server.
+type Config struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	MaxHeaderBytes  int
+	ShutdownTimeout time.Duration
+	// DrainDelay keeps serving for a while after shutdown starts, with
+	// readiness failing, so load balancers can stop sending new requests.
+	DrainDelay time.Duration
+}
+
+// DefaultConfig returns the settings the server used before they became
+// configurable.
+func DefaultConfig() Config {
+	return Config{
+		Addr:            ":8080",
+		ReadTimeout:     10 * time.Second,
+		WriteTimeout:    10 * time.Second,
+		IdleTimeout:     30 * time.Second,
+		MaxHeaderBytes:  http.DefaultMaxHeaderBytes,
+		ShutdownTimeout: 15 * time.Second,
+	}
+}
+
+// StartServer serves the API until ctx is cancelled, then marks readiness
+// as failing and waits up to cfg.ShutdownTimeout for in-flight requests.
+func StartServer(ctx context.Context, cfg Config, deps Dependencies) error {
 	// Set up router
-	router := NewRouter(authorService, bookService)
+	router := NewRouter(deps)
+
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.Addr, err)
+	}
+
+	logger := logging.Component(deps.Logger, "server")
+	logger.Info("server is running", "addr", ln.Addr().String())
+	return Serve(ctx, ln, cfg, router, logger, deps.Health.StartDraining)
+}
 
-	// Start server
+// Serve runs handler on ln with graceful shutdown when ctx is cancelled.
+// The onShutdown hooks run as soon as shutdown begins, before the drain delay.
+func Serve(ctx context.Context, ln net.Listener, cfg Config, handler http.Handler, logger *slog.Logger, onShutdown ...func()) error {
 	srv := &http.Server{
-		Addr:         ":8080",
-		Handler:      router,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  30 * time.Second,
+		Handler:        handler,
+		ReadTimeout:    cfg.ReadTimeout,
+		WriteTimeout:   cfg.WriteTimeout,
+		IdleTimeout:    cfg.IdleTimeout,
+		MaxHeaderBytes: cfg.MaxHeaderBytes,
+		ErrorLog:       slog.NewLogLogger(logger.Handler(), slog.LevelError),
 	}
 
-	fmt.Println("🚀 Server is running on http://localhost:8080")
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server error: %v", err)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+	}
+
+	for _, hook := range onShutdown {
+		hook()
 	}
+	if cfg.DrainDelay > 0 {
+		logger.Info("readiness failing, draining before shutdown", "delay", cfg.DrainDelay)
+		time.Sleep(cfg.DrainDelay)
+	}
+
+	logger.Info("shutting down, draining connections", "timeout", cfg.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// The deadline passed with requests still running; cut them off
+		srv.Close()
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+
+	logger.Info("server stopped")
+	return nil
 }
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/health"
+)
+
+func TestServe_DrainsInFlightRequests(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 2 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- Serve(ctx, ln, cfg, handler, slog.New(slog.DiscardHandler)) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-responses
+	require.NoError(t, res.err)
+	assert.Equal(t, "done", res.body)
+	assert.NoError(t, <-served)
+
+	// The listener is closed once shutdown completes
+	_, err = http.Get("http://" + ln.Addr().String())
+	assert.Error(t, err)
+}
+
+func TestServe_ShutdownDeadline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- Serve(ctx, ln, cfg, handler, slog.New(slog.DiscardHandler)) }()
+
+	go http.Get("http://" + ln.Addr().String())
+
+	<-started
+	cancel()
+
+	assert.ErrorContains(t, <-served, "graceful shutdown failed")
+}
+
+func TestServe_ReadinessFailsWhileDraining(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	healthH := health.NewHandler()
+	router := http.NewServeMux()
+	router.HandleFunc("/readyz", healthH.Readiness)
+
+	cfg := DefaultConfig()
+	cfg.DrainDelay = 200 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- Serve(ctx, ln, cfg, router, slog.New(slog.DiscardHandler), healthH.StartDraining) }()
+
+	readyz := func() int {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/readyz")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, readyz())
+
+	cancel()
+	// Still serving during the drain delay, but no longer ready
+	assert.Eventually(t, func() bool { return readyz() == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, <-served)
+}
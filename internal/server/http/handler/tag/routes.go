@@ -0,0 +1,11 @@
+package tag
+
+import "github.com/go-chi/chi/v5"
+
+func RegisterRoutes(r chi.Router, h *Handler) {
+	r.Get("/", h.GetAllTags)
+	r.Post("/", h.AddTag)
+	r.Get("/{id}", h.GetTagByID)
+	r.Put("/{id}", h.UpdateTag)
+	r.Delete("/{id}", h.DeleteTag)
+}
@@ -0,0 +1,100 @@
+package tag
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/server/http/handler/httpx"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	TagService service.TagService
+	logger     *slog.Logger
+}
+
+func NewHandler(tagService service.TagService, logger *slog.Logger) *Handler {
+	return &Handler{TagService: tagService, logger: logging.Component(logger, "http")}
+}
+
+func (h *Handler) GetAllTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.TagService.GetAllTags(r.Context())
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(tags)
+}
+
+func (h *Handler) AddTag(w http.ResponseWriter, r *http.Request) {
+	var tag entities.Tag
+	if !httpx.DecodeJSON(w, r, &tag) {
+		return
+	}
+
+	if err := h.TagService.AddTag(r.Context(), &tag); err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+func (h *Handler) GetTagByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	tag, err := h.TagService.GetTagByID(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(tag)
+}
+
+func (h *Handler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var tag entities.Tag
+	if !httpx.DecodeJSON(w, r, &tag) {
+		return
+	}
+	tag.ID = id
+
+	if err := h.TagService.UpdateTag(r.Context(), &tag); err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(tag)
+}
+
+func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		httpx.WriteProblem(w, r, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.TagService.RemoveTag(r.Context(), id); err != nil {
+		httpx.WriteError(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,84 @@
+package tag
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetAllTags(t *testing.T) {
+	mockService := new(domain.MockTagService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+	mockService.On("GetAllTags", mock.Anything).Return([]*entities.Tag{{ID: 1, Name: "award-winner", BookCount: 2}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Get("/tags", handler.GetAllTags)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"id": 1, "name": "award-winner", "book_count": 2}]`, rec.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestAddTag(t *testing.T) {
+	tests := []struct {
+		name       string
+		serviceErr error
+		expectCode int
+	}{
+		{name: "created", serviceErr: nil, expectCode: http.StatusCreated},
+		{name: "name taken", serviceErr: apperr.Conflict("a tag named classic already exists", nil), expectCode: http.StatusConflict},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := new(domain.MockTagService)
+			handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+			mockService.On("AddTag", mock.Anything, &entities.Tag{Name: "classic"}).Return(tc.serviceErr)
+
+			req := httptest.NewRequest(http.MethodPost, "/tags", bytes.NewBufferString(`{"name": "classic"}`))
+			rec := httptest.NewRecorder()
+
+			r := chi.NewRouter()
+			r.Post("/tags", handler.AddTag)
+
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectCode, rec.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteTag(t *testing.T) {
+	mockService := new(domain.MockTagService)
+	handler := NewHandler(mockService, slog.New(slog.DiscardHandler))
+
+	mockService.On("RemoveTag", mock.Anything, 5).Return(nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/tags/5", nil)
+	rec := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.Delete("/tags/{id}", handler.DeleteTag)
+
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	mockService.AssertExpectations(t)
+}
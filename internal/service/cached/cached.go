@@ -0,0 +1,78 @@
+// Package cached wraps the book service so that external catalog searches
+// are served from a cache instead of calling the upstream APIs every time.
+package cached
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/cache"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service"
+)
+
+// Cache names reported to the cache.Observer.
+const (
+	GoogleSearchCache   = "google_search"
+	ExternalSearchCache = "external_search"
+)
+
+// BookService caches SearchGoogleBooks and SearchExternalBooks and passes
+// every other call through to the wrapped service.
+type BookService struct {
+	service.BookService
+
+	google   *cache.Cache[*entities.GoogleBookPage]
+	external *cache.Cache[*entities.ExternalSearchResult]
+}
+
+// NewBookService returns next with its searches cached in backend. opts
+// applies to both searches; its Name is replaced by GoogleSearchCache and
+// ExternalSearchCache.
+func NewBookService(next service.BookService, backend cache.Backend, opts cache.Options) *BookService {
+	googleOpts, externalOpts := opts, opts
+	googleOpts.Name, externalOpts.Name = GoogleSearchCache, ExternalSearchCache
+	return &BookService{
+		BookService: next,
+		google:      cache.New[*entities.GoogleBookPage](backend, googleOpts),
+		external: cache.New[*entities.ExternalSearchResult](backend, externalOpts).StoreIf(func(r *entities.ExternalSearchResult) bool {
+			return len(r.Errors) == 0
+		}),
+	}
+}
+
+func (s *BookService) SearchGoogleBooks(ctx context.Context, query entities.GoogleBookQuery) (*entities.GoogleBookPage, error) {
+	return s.google.Get(ctx, googleKey(query), func(ctx context.Context) (*entities.GoogleBookPage, error) {
+		return s.BookService.SearchGoogleBooks(ctx, query)
+	})
+}
+
+// SearchExternalBooks caches only complete answers: a result in which a
+// provider failed is returned but not stored, so the next search retries
+// that provider.
+func (s *BookService) SearchExternalBooks(ctx context.Context, query string, providers []string) (*entities.ExternalSearchResult, error) {
+	return s.external.Get(ctx, externalKey(query, providers), func(ctx context.Context) (*entities.ExternalSearchResult, error) {
+		return s.BookService.SearchExternalBooks(ctx, query, providers)
+	})
+}
+
+// googleKey identifies a search by every parameter, with defaults applied
+// and the search terms folded to the case and spacing Google Books ignores.
+func googleKey(query entities.GoogleBookQuery) string {
+	query = query.WithDefaults()
+	fold := func(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+	return fmt.Sprintf("google:%q:%q:%q:%q:%d:%d:%s:%s",
+		fold(query.Query), fold(query.Title), fold(query.Author), fold(query.ISBN),
+		query.StartIndex, query.MaxResults, query.LangRestrict, query.OrderBy)
+}
+
+// externalKey identifies a search by its query and the set of providers
+// asked, in any order and with repeats; no providers means all of them.
+func externalKey(query string, providers []string) string {
+	names := slices.Clone(providers)
+	slices.Sort(names)
+	names = slices.Compact(names)
+	return "external:" + strings.Join(names, ",") + ":" + strings.ToLower(strings.TrimSpace(query))
+}
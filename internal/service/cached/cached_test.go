@@ -0,0 +1,115 @@
+package cached_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/cache"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service/cached"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+)
+
+func newService() (*cached.BookService, *domain.MockBookService) {
+	next := new(domain.MockBookService)
+	svc := cached.NewBookService(next, cache.NewMemory(100), cache.Options{
+		TTL:    time.Minute,
+		Logger: slog.New(slog.DiscardHandler),
+	})
+	return svc, next
+}
+
+func TestBookService_SearchGoogleBooks(t *testing.T) {
+	ctx := context.Background()
+	svc, next := newService()
+	page := &entities.GoogleBookPage{
+		Items:      []entities.GoogleBook{{ID: "g1", VolumeInfo: entities.GoogleVolumeInfo{Title: "Dune"}}},
+		TotalItems: 1,
+		MaxResults: entities.DefaultGoogleMaxResults,
+	}
+	next.On("SearchGoogleBooks", mock.Anything, entities.GoogleBookQuery{Title: "Dune"}).Return(page, nil).Once()
+	next.On("SearchGoogleBooks", mock.Anything, entities.GoogleBookQuery{Title: "Dune", StartIndex: 10}).Return(page, nil).Once()
+
+	first, err := svc.SearchGoogleBooks(ctx, entities.GoogleBookQuery{Title: "Dune"})
+	require.NoError(t, err)
+	// Same search: case, spacing and the default page size do not matter.
+	second, err := svc.SearchGoogleBooks(ctx, entities.GoogleBookQuery{Title: " dune ", MaxResults: entities.DefaultGoogleMaxResults})
+	require.NoError(t, err)
+	// Another page is another search.
+	_, err = svc.SearchGoogleBooks(ctx, entities.GoogleBookQuery{Title: "Dune", StartIndex: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, page, first)
+	assert.Equal(t, page, second)
+	next.AssertExpectations(t)
+}
+
+func TestBookService_SearchGoogleBooks_DoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	svc, next := newService()
+	query := entities.GoogleBookQuery{Query: "dune"}
+	next.On("SearchGoogleBooks", mock.Anything, query).Return(nil, apperr.Unavailable("google books API request failed", nil)).Once()
+	next.On("SearchGoogleBooks", mock.Anything, query).Return(&entities.GoogleBookPage{Items: []entities.GoogleBook{}}, nil).Once()
+
+	_, err := svc.SearchGoogleBooks(ctx, query)
+	assert.ErrorIs(t, err, apperr.ErrUnavailable)
+	_, err = svc.SearchGoogleBooks(ctx, query)
+	assert.NoError(t, err)
+	next.AssertExpectations(t)
+}
+
+func TestBookService_SearchExternalBooks(t *testing.T) {
+	ctx := context.Background()
+	complete := &entities.ExternalSearchResult{Items: []entities.ExternalBook{{Title: "Dune"}}}
+	partial := &entities.ExternalSearchResult{
+		Items:  []entities.ExternalBook{{Title: "Dune"}},
+		Errors: map[string]string{"openlibrary": "open library API request failed"},
+	}
+
+	t.Run("caches by query and provider set", func(t *testing.T) {
+		svc, next := newService()
+		next.On("SearchExternalBooks", mock.Anything, "dune", []string{"openlibrary", "google"}).Return(complete, nil).Once()
+		next.On("SearchExternalBooks", mock.Anything, "dune", []string{"google"}).Return(complete, nil).Once()
+
+		for _, providers := range [][]string{{"openlibrary", "google"}, {"google", "openlibrary", "google"}, {"google"}} {
+			result, err := svc.SearchExternalBooks(ctx, "dune", providers)
+			require.NoError(t, err)
+			assert.Equal(t, complete, result)
+		}
+		next.AssertExpectations(t)
+	})
+
+	t.Run("does not cache partial results", func(t *testing.T) {
+		svc, next := newService()
+		next.On("SearchExternalBooks", mock.Anything, "dune", []string(nil)).Return(partial, nil).Once()
+		next.On("SearchExternalBooks", mock.Anything, "dune", []string(nil)).Return(complete, nil).Once()
+
+		result, err := svc.SearchExternalBooks(ctx, "dune", nil)
+		require.NoError(t, err)
+		assert.Equal(t, partial, result)
+
+		result, err = svc.SearchExternalBooks(ctx, "dune", nil)
+		require.NoError(t, err)
+		assert.Equal(t, complete, result)
+		next.AssertExpectations(t)
+	})
+}
+
+func TestBookService_PassesOtherCallsThrough(t *testing.T) {
+	svc, next := newService()
+	next.On("GetBookByID", mock.Anything, 1).Return(&entities.Book{ID: 1}, nil).Twice()
+
+	for range 2 {
+		book, err := svc.GetBookByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, book.ID)
+	}
+	next.AssertExpectations(t)
+}
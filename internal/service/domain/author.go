@@ -2,27 +2,154 @@ package domain
 
 import (
 	"context"
+	"log/slog"
+	"time"
 
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
 )
 
 type AuthorService struct {
 	repo     storage.AuthorRepository
 	bookRepo storage.BookRepository
+	logger   *slog.Logger
 }
 
-func NewAuthorService(authorRepo storage.AuthorRepository, bookRepo storage.BookRepository) *AuthorService {
+func NewAuthorService(authorRepo storage.AuthorRepository, bookRepo storage.BookRepository, logger *slog.Logger) *AuthorService {
 	return &AuthorService{
 		repo:     authorRepo,
 		bookRepo: bookRepo,
+		logger:   logging.Component(logger, "service"),
 	}
 }
 
+func (s *AuthorService) GetAllAuthors(ctx context.Context) ([]*entities.Author, error) {
+	return s.repo.FindAll(ctx)
+}
+
 func (s *AuthorService) GetAuthorByID(ctx context.Context, id int) (*entities.Author, error) {
 	return s.repo.FindByID(ctx, id)
 }
 
+// GetAuthorWithBooks returns the author and their books in one response.
+func (s *AuthorService) GetAuthorWithBooks(ctx context.Context, id int) (*entities.AuthorWithBooks, error) {
+	author, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	books, err := s.bookRepo.FindByAuthorID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.AuthorWithBooks{Author: *author, Books: books}, nil
+}
+
+// GetAuthorBooks returns the author's books. The author is looked up first
+// so that an unknown author is reported instead of an empty list.
+func (s *AuthorService) GetAuthorBooks(ctx context.Context, id int) ([]*entities.Book, error) {
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.bookRepo.FindByAuthorID(ctx, id)
+}
+
 func (s *AuthorService) RegisterAuthor(ctx context.Context, author *entities.Author) error {
-	return s.repo.Create(ctx, author)
+	if err := validateAuthor(author, time.Now()).err(); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, author); err != nil {
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "author registered", "author_id", author.ID)
+	return nil
+}
+
+func (s *AuthorService) UpdateAuthor(ctx context.Context, author *entities.Author) error {
+	if err := validateAuthor(author, time.Now()).err(); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, author)
+}
+
+// PatchAuthor applies a JSON Merge Patch or JSON Patch to the author's
+// JSON representation and stores the result, checked like UpdateAuthor.
+// The author stays locked in between; a non-zero version must match
+// theirs.
+func (s *AuthorService) PatchAuthor(ctx context.Context, id, version int, patch jsonpatch.Patch) (*entities.Author, error) {
+	return s.repo.Patch(ctx, id, version, func(author *entities.Author) error {
+		if err := applyPatch(patch, author); err != nil {
+			return err
+		}
+		return validateAuthor(author, time.Now()).err()
+	})
+}
+
+// RemoveAuthor soft-deletes an author, which RestoreAuthor undoes until
+// they are purged. It fails with storage.ErrAuthorHasBooks while the
+// author still has books, and with storage.ErrVersionMismatch when a
+// non-zero version is not the author's.
+func (s *AuthorService) RemoveAuthor(ctx context.Context, id, version int) error {
+	if err := s.repo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "author removed", "author_id", id)
+	return nil
+}
+
+// RestoreAuthor undoes the deletion of an author and returns them. A
+// non-zero version must match the author's.
+func (s *AuthorService) RestoreAuthor(ctx context.Context, id, version int) (*entities.Author, error) {
+	author, err := s.repo.Restore(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.DebugContext(ctx, "author restored", "author_id", id, "version", author.Version)
+	return author, nil
+}
+
+// GetAuthorHistory returns the recorded revisions of the author like
+// GetBookHistory.
+func (s *AuthorService) GetAuthorHistory(ctx context.Context, id int) ([]*entities.Revision, error) {
+	history, err := s.repo.History(ctx, id)
+	if err != nil || len(history) > 0 {
+		return history, err
+	}
+	if _, err := s.repo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// RevertAuthor takes the author back to the given revision like
+// RevertBook.
+func (s *AuthorService) RevertAuthor(ctx context.Context, id, revision, version int) (*entities.Author, error) {
+	history, err := s.repo.History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	author, err := s.repo.Patch(storage.WithAuditAction(ctx, entities.AuditRevert), id, version, func(author *entities.Author) error {
+		patch, err := revertPatch("author", id, history, author.Version, revision)
+		if err != nil {
+			return err
+		}
+		if err := applyPatch(patch, author); err != nil {
+			return err
+		}
+		return validateAuthor(author, time.Now()).err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.DebugContext(ctx, "author reverted", "author_id", id, "revision", revision, "version", author.Version)
+	return author, nil
 }
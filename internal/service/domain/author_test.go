@@ -0,0 +1,221 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// authorRepoMock uses testify's mock to verify interactions with the author repository.
+type authorRepoMock struct {
+	mock.Mock
+}
+
+func (m *authorRepoMock) FindAll(ctx context.Context) ([]*entities.Author, error) {
+	args := m.Called(ctx)
+	authors, _ := args.Get(0).([]*entities.Author)
+	return authors, args.Error(1)
+}
+
+func (m *authorRepoMock) FindByID(ctx context.Context, id int) (*entities.Author, error) {
+	args := m.Called(ctx, id)
+	author, _ := args.Get(0).(*entities.Author)
+	return author, args.Error(1)
+}
+
+func (m *authorRepoMock) FindOrCreateByName(ctx context.Context, name string) (*entities.Author, bool, error) {
+	args := m.Called(ctx, name)
+	author, _ := args.Get(0).(*entities.Author)
+	return author, args.Bool(1), args.Error(2)
+}
+
+func (m *authorRepoMock) Create(ctx context.Context, author *entities.Author) error {
+	args := m.Called(ctx, author)
+	return args.Error(0)
+}
+
+func (m *authorRepoMock) Update(ctx context.Context, author *entities.Author) error {
+	args := m.Called(ctx, author)
+	return args.Error(0)
+}
+
+// Patch hands apply the stored author set up with On and returns them as
+// apply left them.
+func (m *authorRepoMock) Patch(ctx context.Context, id, version int, apply func(author *entities.Author) error) (*entities.Author, error) {
+	args := m.Called(ctx, id, version)
+	author, _ := args.Get(0).(*entities.Author)
+	if err := args.Error(1); err != nil {
+		return nil, err
+	}
+	if err := apply(author); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+func (m *authorRepoMock) Delete(ctx context.Context, id, version int) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (m *authorRepoMock) Restore(ctx context.Context, id, version int) (*entities.Author, error) {
+	args := m.Called(ctx, id, version)
+	author, _ := args.Get(0).(*entities.Author)
+	return author, args.Error(1)
+}
+
+func (m *authorRepoMock) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *authorRepoMock) History(ctx context.Context, id int) ([]*entities.Revision, error) {
+	args := m.Called(ctx, id)
+	history, _ := args.Get(0).([]*entities.Revision)
+	return history, args.Error(1)
+}
+
+func TestAuthorService_GetAuthorWithBooks(t *testing.T) {
+	ctx := context.Background()
+	author := &entities.Author{ID: 1, Name: "Ursula K. Le Guin"}
+	books := []*entities.Book{{ID: 10, Title: "A Wizard of Earthsea", AuthorID: 1}}
+
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("FindByID", ctx, 1).Return(author, nil).Once()
+	bookRepo := &repoMock{}
+	bookRepo.On("FindByAuthorID", ctx, 1).Return(books, nil).Once()
+
+	svc := NewAuthorService(authorRepo, bookRepo, slog.New(slog.DiscardHandler))
+
+	result, err := svc.GetAuthorWithBooks(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ursula K. Le Guin", result.Name)
+	assert.Equal(t, books, result.Books)
+	authorRepo.AssertExpectations(t)
+	bookRepo.AssertExpectations(t)
+}
+
+func TestAuthorService_GetAuthorBooks_UnknownAuthor(t *testing.T) {
+	ctx := context.Background()
+
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("FindByID", ctx, 42).Return(nil, errors.New("author with ID 42 not found")).Once()
+	bookRepo := &repoMock{}
+
+	svc := NewAuthorService(authorRepo, bookRepo, slog.New(slog.DiscardHandler))
+
+	books, err := svc.GetAuthorBooks(ctx, 42)
+	assert.Error(t, err)
+	assert.Nil(t, books)
+	bookRepo.AssertNotCalled(t, "FindByAuthorID", mock.Anything, mock.Anything)
+}
+
+func TestAuthorService_RegisterAuthor_Validation(t *testing.T) {
+	ctx := context.Background()
+
+	authorRepo := &authorRepoMock{}
+	svc := NewAuthorService(authorRepo, &repoMock{}, slog.New(slog.DiscardHandler))
+
+	err := svc.RegisterAuthor(ctx, &entities.Author{Name: "", BirthDate: time.Now().AddDate(1, 0, 0)})
+
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	fields := apperr.Fields(err)
+	if assert.Len(t, fields, 2) {
+		assert.Equal(t, apperr.FieldError{Field: "name", Rule: "required", Message: "name is required"}, fields[0])
+		assert.Equal(t, "birthdate", fields[1].Field)
+		assert.Equal(t, "not_future", fields[1].Rule)
+	}
+	authorRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAuthorService_RegisterAuthor(t *testing.T) {
+	ctx := context.Background()
+	author := &entities.Author{Name: "Octavia E. Butler", BirthDate: time.Date(1947, 6, 22, 0, 0, 0, 0, time.UTC)}
+
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("Create", ctx, author).Return(nil).Once()
+	svc := NewAuthorService(authorRepo, &repoMock{}, slog.New(slog.DiscardHandler))
+
+	assert.NoError(t, svc.RegisterAuthor(ctx, author))
+	authorRepo.AssertExpectations(t)
+}
+
+func TestAuthorService_PatchAuthor(t *testing.T) {
+	ctx := context.Background()
+	birth := time.Date(1947, 6, 22, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should apply the patch and validate the result", func(t *testing.T) {
+		authorRepo := &authorRepoMock{}
+		authorRepo.On("Patch", ctx, 5, 0).Return(&entities.Author{ID: 5, Name: "Octavia Butler", BirthDate: birth, Version: 1}, nil).Once()
+		svc := NewAuthorService(authorRepo, &repoMock{}, slog.New(slog.DiscardHandler))
+
+		patch, err := jsonpatch.Parse(jsonpatch.MergePatchType, []byte(`{"name": "Octavia E. Butler"}`))
+		assert.NoError(t, err)
+		author, err := svc.PatchAuthor(ctx, 5, 0, patch)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Octavia E. Butler", author.Name)
+		assert.Equal(t, birth, author.BirthDate)
+		authorRepo.AssertExpectations(t)
+	})
+
+	t.Run("should reject a patch leaving an invalid author", func(t *testing.T) {
+		authorRepo := &authorRepoMock{}
+		authorRepo.On("Patch", ctx, 5, 0).Return(&entities.Author{ID: 5, Name: "Octavia Butler", BirthDate: birth, Version: 1}, nil).Once()
+		svc := NewAuthorService(authorRepo, &repoMock{}, slog.New(slog.DiscardHandler))
+
+		patch, err := jsonpatch.Parse(jsonpatch.JSONPatchType, []byte(`[{"op": "remove", "path": "/name"}]`))
+		assert.NoError(t, err)
+		_, err = svc.PatchAuthor(ctx, 5, 0, patch)
+
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+	})
+
+	t.Run("should patch an imported author without a birthdate", func(t *testing.T) {
+		authorRepo := &authorRepoMock{}
+		authorRepo.On("Patch", ctx, 5, 0).Return(&entities.Author{ID: 5, Name: "Octavia E. Butler", Version: 1}, nil).Once()
+		svc := NewAuthorService(authorRepo, &repoMock{}, slog.New(slog.DiscardHandler))
+
+		patch, err := jsonpatch.Parse(jsonpatch.MergePatchType, []byte(`{"bio": "x"}`))
+		assert.NoError(t, err)
+		author, err := svc.PatchAuthor(ctx, 5, 0, patch)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "x", author.Bio)
+		assert.True(t, author.BirthDate.IsZero())
+	})
+}
+
+func TestAuthorService_RestoreAuthor(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the restored author", func(t *testing.T) {
+		authorRepo := &authorRepoMock{}
+		authorRepo.On("Restore", ctx, 5, 2).Return(&entities.Author{ID: 5, Name: "Octavia E. Butler", Version: 3}, nil).Once()
+		svc := NewAuthorService(authorRepo, &repoMock{}, slog.New(slog.DiscardHandler))
+
+		author, err := svc.RestoreAuthor(ctx, 5, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, author.Version)
+		authorRepo.AssertExpectations(t)
+	})
+
+	t.Run("should pass on a missing author", func(t *testing.T) {
+		authorRepo := &authorRepoMock{}
+		authorRepo.On("Restore", ctx, 5, 0).Return(nil, apperr.NotFound("author with ID 5 not found")).Once()
+		svc := NewAuthorService(authorRepo, &repoMock{}, slog.New(slog.DiscardHandler))
+
+		_, err := svc.RestoreAuthor(ctx, 5, 0)
+
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+}
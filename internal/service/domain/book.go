@@ -2,99 +2,347 @@ package domain
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
-	"net/url"
-	"time"
+	"log/slog"
+	"slices"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog/google"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
 )
 
 type BookService struct {
-	repo   storage.BookRepository
-	client *http.Client
-}
-
-type ctxKey string
-
-const baseURLKey ctxKey = "baseURL"
-
-type GoogleBooksSearchResponse struct {
-	Items []entities.GoogleBook `json:"items"`
+	repo       storage.BookRepository
+	authorRepo storage.AuthorRepository
+	genreRepo  storage.GenreRepository
+	google     *google.Client
+	catalog    *catalog.Catalog
+	logger     *slog.Logger
 }
 
 type BookServiceInterface interface {
-	GetAllBooks(ctx context.Context) ([]*entities.Book, error)
+	GetAllBooks(ctx context.Context, query entities.BookQuery) (*entities.BookPage, error)
+	SearchBooks(ctx context.Context, query entities.BookSearchQuery) (*entities.BookSearchPage, error)
 	GetBookByID(ctx context.Context, id int) (*entities.Book, error)
+	GetBookByISBN(ctx context.Context, isbn string) (*entities.Book, error)
+	LookupExternalISBN(ctx context.Context, isbn string) (*entities.ExternalBook, error)
 	AddBook(ctx context.Context, book *entities.Book) error
 	UpdateBook(ctx context.Context, book *entities.Book) error
-	RemoveBook(ctx context.Context, id int) error
-	SearchGoogleBooks(ctx context.Context, title string) ([]entities.GoogleBook, error)
+	PatchBook(ctx context.Context, id, version int, patch jsonpatch.Patch) (*entities.Book, error)
+	RemoveBook(ctx context.Context, id, version int) error
+	RestoreBook(ctx context.Context, id, version int) (*entities.Book, error)
+	GetBookHistory(ctx context.Context, id int) ([]*entities.Revision, error)
+	RevertBook(ctx context.Context, id, revision, version int) (*entities.Book, error)
+	SearchGoogleBooks(ctx context.Context, query entities.GoogleBookQuery) (*entities.GoogleBookPage, error)
+	ImportGoogleBook(ctx context.Context, volumeID string) (*entities.Book, bool, error)
+	SearchExternalBooks(ctx context.Context, query string, providers []string) (*entities.ExternalSearchResult, error)
 }
 
-func NewBookService(repo storage.BookRepository) *BookService {
+// NewBookService returns a book service. googleClient backs the Google
+// search and import; catalog backs the external search across providers.
+func NewBookService(repo storage.BookRepository, authorRepo storage.AuthorRepository, genreRepo storage.GenreRepository, googleClient *google.Client, catalog *catalog.Catalog, logger *slog.Logger) *BookService {
 	return &BookService{
-		repo:   repo,
-		client: &http.Client{Timeout: 10 * time.Second},
+		repo:       repo,
+		authorRepo: authorRepo,
+		genreRepo:  genreRepo,
+		google:     googleClient,
+		catalog:    catalog,
+		logger:     logging.Component(logger, "service"),
+	}
+}
+
+// GetAllBooks returns one page of books. Missing paging and sorting
+// options fall back to entities.DefaultBookLimit, newest first. The genre
+// and tag filters are slugified like the labels they match.
+func (s *BookService) GetAllBooks(ctx context.Context, query entities.BookQuery) (*entities.BookPage, error) {
+	if query.Genre != "" {
+		query.Genre = slugify(query.Genre)
+	}
+	if len(query.Tags) > 0 {
+		tags := make([]string, len(query.Tags))
+		for i, tag := range query.Tags {
+			tags[i] = slugify(tag)
+		}
+		query.Tags = tags
 	}
+	return s.repo.FindAll(ctx, query.WithDefaults())
 }
 
-func (s *BookService) GetAllBooks(ctx context.Context) ([]*entities.Book, error) {
-	return s.repo.FindAll(ctx)
+// SearchBooks runs a full-text search over the local catalog. Missing
+// paging options fall back to entities.DefaultBookLimit.
+func (s *BookService) SearchBooks(ctx context.Context, query entities.BookSearchQuery) (*entities.BookSearchPage, error) {
+	if err := validateBookSearch(query).err(); err != nil {
+		return nil, err
+	}
+	return s.repo.Search(ctx, query.WithDefaults())
 }
 
 func (s *BookService) GetBookByID(ctx context.Context, id int) (*entities.Book, error) {
 	return s.repo.FindById(ctx, id)
 }
 
+// GetBookByISBN returns the book with the given ISBN, which may be an
+// ISBN-10 or an ISBN-13 and may contain hyphens.
+func (s *BookService) GetBookByISBN(ctx context.Context, isbn string) (*entities.Book, error) {
+	isbn13, err := normalizeISBN(isbn)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindByISBN(ctx, isbn13)
+}
+
+// LookupExternalISBN searches every configured catalog for the ISBN and
+// returns the merged record of the book carrying it.
+func (s *BookService) LookupExternalISBN(ctx context.Context, isbn string) (*entities.ExternalBook, error) {
+	isbn13, err := normalizeISBN(isbn)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.catalog.Search(ctx, "isbn:"+isbn13, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result.Items {
+		if slices.Contains(result.Items[i].ISBNs, isbn13) {
+			return &result.Items[i], nil
+		}
+	}
+	return nil, apperr.NotFound(fmt.Sprintf("no external catalog knows ISBN %s", isbn13))
+}
+
 func (s *BookService) AddBook(ctx context.Context, book *entities.Book) error {
-	return s.repo.Create(ctx, book)
+	if err := s.validate(ctx, book); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, book); err != nil {
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "book added", "book_id", book.ID, "author_id", book.AuthorID)
+	return nil
 }
 
+// UpdateBook validates and overwrites the book. A book sent without
+// contributors keeps the stored ones, with author_id as their primary
+// author; it is read and written back under one lock, like a patch.
 func (s *BookService) UpdateBook(ctx context.Context, book *entities.Book) error {
-	return s.repo.Update(ctx, book)
+	if book.Contributors != nil {
+		if err := s.validate(ctx, book); err != nil {
+			return err
+		}
+		return s.repo.Update(ctx, book)
+	}
+
+	updated, err := s.repo.Patch(ctx, book.ID, book.Version, func(stored *entities.Book) error {
+		book.Contributors = withPrimaryAuthor(stored.Contributors, book.AuthorID)
+		if err := s.validate(ctx, book); err != nil {
+			return err
+		}
+		*stored = *book
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*book = *updated
+	return nil
 }
 
-func (s *BookService) RemoveBook(ctx context.Context, id int) error {
-	return s.repo.Delete(ctx, id)
+// withPrimaryAuthor returns contributors with authorID as the primary
+// author, the first contributor with the author role: authorID takes the
+// place of the current primary author, and is no longer credited as
+// author further down. The others keep their place. contributors is
+// returned unchanged when authorID is not set.
+func withPrimaryAuthor(contributors []entities.Contributor, authorID int) []entities.Contributor {
+	if authorID <= 0 || entities.PrimaryAuthorID(contributors) == authorID {
+		return slices.Clone(contributors)
+	}
+
+	primary := entities.Contributor{AuthorID: authorID, Role: entities.RoleAuthor}
+	out := make([]entities.Contributor, 0, len(contributors)+1)
+	replaced := false
+	for _, c := range contributors {
+		switch {
+		case c.Role != entities.RoleAuthor:
+			out = append(out, c)
+		case !replaced:
+			out = append(out, primary)
+			replaced = true
+		case c.AuthorID != authorID:
+			out = append(out, c)
+		}
+	}
+	if !replaced {
+		// Nobody was credited as author: the new one is credited first
+		out = append([]entities.Contributor{primary}, out...)
+	}
+	return out
 }
 
-// ✅ Google Books API logic
-//
-// SearchGoogleBooks fetches a list of books from Google Books API by title
-func (s *BookService) SearchGoogleBooks(ctx context.Context, title string) ([]entities.GoogleBook, error) {
-	baseURL := "https://www.googleapis.com/books/v1/volumes"
-
-	// Allow test to override base URL via context
-	if custom := ctx.Value(baseURLKey); custom != nil {
-		if str, ok := custom.(string); ok {
-			baseURL = str
+// PatchBook applies a JSON Merge Patch or JSON Patch to the book's JSON
+// representation and stores the result, checked like UpdateBook. The book
+// stays locked from reading it to writing it back, so no concurrent write
+// is lost; a non-zero version must also match the book's.
+func (s *BookService) PatchBook(ctx context.Context, id, version int, patch jsonpatch.Patch) (*entities.Book, error) {
+	book, err := s.repo.Patch(ctx, id, version, func(book *entities.Book) error {
+		before := *book
+		if err := applyPatch(patch, book); err != nil {
+			return err
+		}
+		rederive(&before, book)
+		return s.validate(ctx, book)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.DebugContext(ctx, "book patched", "book_id", book.ID, "version", book.Version)
+	return book, nil
+}
+
+// validate checks the book's fields and that its contributors and genres
+// exist, and fills in the contributors' names. Unknown tags are created
+// when the book is stored.
+func (s *BookService) validate(ctx context.Context, book *entities.Book) error {
+	v := validateBook(book)
+	authors := make(map[int]*entities.Author)
+	for i := range book.Contributors {
+		c := &book.Contributors[i]
+		if c.AuthorID <= 0 {
+			continue
+		}
+		author, ok := authors[c.AuthorID]
+		if !ok {
+			var err error
+			author, err = s.authorRepo.FindByID(ctx, c.AuthorID)
+			if errors.Is(err, apperr.ErrNotFound) {
+				field := fmt.Sprintf("contributors[%d].author_id", i)
+				if c.AuthorID == book.AuthorID {
+					field = "author_id"
+				}
+				v.check(false, field, "exists", fmt.Sprintf("author with ID %d does not exist", c.AuthorID))
+			} else if err != nil {
+				return err
+			}
+			authors[c.AuthorID] = author
+		}
+		if author != nil {
+			c.Name = author.Name
 		}
 	}
+	for i, slug := range book.Genres {
+		_, err := s.genreRepo.FindBySlug(ctx, slug)
+		if errors.Is(err, apperr.ErrNotFound) {
+			v.check(false, fmt.Sprintf("genres[%d]", i), "exists", fmt.Sprintf("genre %q does not exist", slug))
+		} else if err != nil {
+			return err
+		}
+	}
+	return v.err()
+}
 
-	url := fmt.Sprintf("%s?q=%s", baseURL, url.QueryEscape(title))
+// RemoveBook soft-deletes a book, which RestoreBook undoes until the book
+// is purged. A non-zero version must match the book's, otherwise it fails
+// with storage.ErrVersionMismatch.
+func (s *BookService) RemoveBook(ctx context.Context, id, version int) error {
+	if err := s.repo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "book removed", "book_id", id)
+	return nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// RestoreBook undoes the deletion of a book and returns it. A non-zero
+// version must match the book's.
+func (s *BookService) RestoreBook(ctx context.Context, id, version int) (*entities.Book, error) {
+	book, err := s.repo.Restore(ctx, id, version)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	resp, err := s.client.Do(req)
+	s.logger.DebugContext(ctx, "book restored", "book_id", id, "version", book.Version)
+	return book, nil
+}
+
+// GetBookHistory returns the recorded revisions of the book, newest
+// first, even once it is deleted or purged. A book without any is looked
+// up, so that an unknown one is reported as not found rather than with an
+// empty history.
+func (s *BookService) GetBookHistory(ctx context.Context, id int) ([]*entities.Revision, error) {
+	history, err := s.repo.History(ctx, id)
+	if err != nil || len(history) > 0 {
+		return history, err
+	}
+	if _, err := s.repo.FindById(ctx, id); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// RevertBook takes the book back to the given revision by undoing the
+// changes recorded since, checked like UpdateBook. The revert is itself
+// recorded as a new revision. A non-zero version must match the book's;
+// a deleted book must be restored first.
+func (s *BookService) RevertBook(ctx context.Context, id, revision, version int) (*entities.Book, error) {
+	history, err := s.repo.History(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("google books API request failed: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	book, err := s.repo.Patch(storage.WithAuditAction(ctx, entities.AuditRevert), id, version, func(book *entities.Book) error {
+		// Writes made since history was read are missing from it, which
+		// revertPatch reports
+		patch, err := revertPatch("book", id, history, book.Version, revision)
+		if err != nil {
+			return err
+		}
+		before := *book
+		if err := applyPatch(patch, book); err != nil {
+			return err
+		}
+		rederive(&before, book)
+		return s.validate(ctx, book)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	var result GoogleBooksSearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode Google Books API response: %w", err)
+	s.logger.DebugContext(ctx, "book reverted", "book_id", id, "revision", revision, "version", book.Version)
+	return book, nil
+}
+
+// ✅ Google Books API logic
+//
+// SearchGoogleBooks returns one page of Google Books search results. A
+// missing page size falls back to entities.DefaultGoogleMaxResults.
+func (s *BookService) SearchGoogleBooks(ctx context.Context, query entities.GoogleBookQuery) (*entities.GoogleBookPage, error) {
+	query = query.WithDefaults()
+	if err := validateGoogleQuery(query).err(); err != nil {
+		return nil, err
+	}
+
+	page, err := s.google.Search(ctx, query)
+	if err != nil {
+		s.logger.WarnContext(ctx, "google books search failed", "err", err)
+		return nil, err
 	}
+	return page, nil
+}
 
-	return result.Items, nil
+// SearchExternalBooks searches the named catalog providers, or every
+// configured one when providers is empty, and merges their results by ISBN.
+func (s *BookService) SearchExternalBooks(ctx context.Context, query string, providers []string) (*entities.ExternalSearchResult, error) {
+	v := &validator{}
+	v.required(query, "q")
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return s.catalog.Search(ctx, query, providers)
 }
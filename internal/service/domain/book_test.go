@@ -2,23 +2,57 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog/google"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Dummy repo that does nothing (we're testing API logic only)
 type mockBookRepo struct{}
 
-func (m *mockBookRepo) FindAll(ctx context.Context) ([]*entities.Book, error)        { return nil, nil }
 func (m *mockBookRepo) FindById(ctx context.Context, id int) (*entities.Book, error) { return nil, nil }
 func (m *mockBookRepo) Create(ctx context.Context, book *entities.Book) error        { return nil }
 func (m *mockBookRepo) Update(ctx context.Context, book *entities.Book) error        { return nil }
-func (m *mockBookRepo) Delete(ctx context.Context, id int) error                     { return nil }
+func (m *mockBookRepo) Delete(ctx context.Context, id, version int) error            { return nil }
+func (m *mockBookRepo) Patch(ctx context.Context, id, version int, apply func(book *entities.Book) error) (*entities.Book, error) {
+	return nil, nil
+}
+func (m *mockBookRepo) FindAll(ctx context.Context, query entities.BookQuery) (*entities.BookPage, error) {
+	return nil, nil
+}
+func (m *mockBookRepo) FindByISBN(ctx context.Context, isbn13 string) (*entities.Book, error) {
+	return nil, nil
+}
+func (m *mockBookRepo) Search(ctx context.Context, query entities.BookSearchQuery) (*entities.BookSearchPage, error) {
+	return nil, nil
+}
+func (m *mockBookRepo) FindByAuthorID(ctx context.Context, authorID int) ([]*entities.Book, error) {
+	return nil, nil
+}
+func (m *mockBookRepo) UpsertByGoogleID(ctx context.Context, book *entities.Book) (bool, error) {
+	return false, nil
+}
+func (m *mockBookRepo) Restore(ctx context.Context, id, version int) (*entities.Book, error) {
+	return nil, nil
+}
+func (m *mockBookRepo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return 0, nil
+}
+func (m *mockBookRepo) History(ctx context.Context, id int) ([]*entities.Revision, error) {
+	return nil, nil
+}
 
 func TestSearchGoogleBooks(t *testing.T) {
 	fakeResponse := `{
@@ -43,18 +77,53 @@ func TestSearchGoogleBooks(t *testing.T) {
 
 	svc := &BookService{
 		repo:   &mockBookRepo{},
-		client: server.Client(), // Inject mock HTTP client
+		google: google.New(google.Config{BaseURL: server.URL}),
+		logger: slog.New(slog.DiscardHandler),
 	}
-	ctx := context.WithValue(context.Background(), baseURLKey, server.URL)
+	ctx := context.Background()
 
-	results, err := svc.SearchGoogleBooks(ctx, "harry potter")
+	page, err := svc.SearchGoogleBooks(ctx, entities.GoogleBookQuery{Query: "harry potter"})
 
 	assert.NoError(t, err)
+	results := page.Items
 	assert.Len(t, results, 1)
 	assert.GreaterOrEqual(t, len(results), 1)
 	assert.Equal(t, "abc123", results[0].ID)
 	assert.Equal(t, "Harry Potter and the Sorcerer's Stone", results[0].VolumeInfo.Title)
 	assert.Equal(t, "J.K. Rowling", results[0].VolumeInfo.Authors[0])
+	assert.Equal(t, entities.DefaultGoogleMaxResults, page.MaxResults)
+}
+
+func TestSearchGoogleBooks_Validation(t *testing.T) {
+	// No Google client: invalid searches must not reach it.
+	svc := &BookService{logger: slog.New(slog.DiscardHandler)}
+
+	tests := []struct {
+		name   string
+		query  entities.GoogleBookQuery
+		fields []string
+	}{
+		{name: "no search terms", query: entities.GoogleBookQuery{Query: "  "}, fields: []string{"q"}},
+		{name: "invalid isbn", query: entities.GoogleBookQuery{ISBN: "9780441172710"}, fields: []string{"isbn"}},
+		{
+			name:   "unknown language and order",
+			query:  entities.GoogleBookQuery{Author: "herbert", LangRestrict: "eng", OrderBy: "oldest"},
+			fields: []string{"langRestrict", "orderBy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.SearchGoogleBooks(context.Background(), tt.query)
+
+			assert.ErrorIs(t, err, apperr.ErrValidation)
+			var fields []string
+			for _, f := range apperr.Fields(err) {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
 }
 
 // repoMock uses testify's mock to verify interactions with the repository.
@@ -62,10 +131,10 @@ type repoMock struct {
 	mock.Mock
 }
 
-func (m *repoMock) FindAll(ctx context.Context) ([]*entities.Book, error) {
-	args := m.Called(ctx)
-	books, _ := args.Get(0).([]*entities.Book)
-	return books, args.Error(1)
+func (m *repoMock) FindAll(ctx context.Context, query entities.BookQuery) (*entities.BookPage, error) {
+	args := m.Called(ctx, query)
+	page, _ := args.Get(0).(*entities.BookPage)
+	return page, args.Error(1)
 }
 
 func (m *repoMock) FindById(ctx context.Context, id int) (*entities.Book, error) {
@@ -74,6 +143,24 @@ func (m *repoMock) FindById(ctx context.Context, id int) (*entities.Book, error)
 	return book, args.Error(1)
 }
 
+func (m *repoMock) FindByISBN(ctx context.Context, isbn13 string) (*entities.Book, error) {
+	args := m.Called(ctx, isbn13)
+	book, _ := args.Get(0).(*entities.Book)
+	return book, args.Error(1)
+}
+
+func (m *repoMock) Search(ctx context.Context, query entities.BookSearchQuery) (*entities.BookSearchPage, error) {
+	args := m.Called(ctx, query)
+	page, _ := args.Get(0).(*entities.BookSearchPage)
+	return page, args.Error(1)
+}
+
+func (m *repoMock) FindByAuthorID(ctx context.Context, authorID int) ([]*entities.Book, error) {
+	args := m.Called(ctx, authorID)
+	books, _ := args.Get(0).([]*entities.Book)
+	return books, args.Error(1)
+}
+
 func (m *repoMock) Create(ctx context.Context, book *entities.Book) error {
 	args := m.Called(ctx, book)
 	return args.Error(0)
@@ -84,31 +171,113 @@ func (m *repoMock) Update(ctx context.Context, book *entities.Book) error {
 	return args.Error(0)
 }
 
-func (m *repoMock) Delete(ctx context.Context, id int) error {
-	args := m.Called(ctx, id)
+// Patch hands apply the stored book set up with On and returns it as apply
+// left it.
+func (m *repoMock) Patch(ctx context.Context, id, version int, apply func(book *entities.Book) error) (*entities.Book, error) {
+	args := m.Called(ctx, id, version)
+	book, _ := args.Get(0).(*entities.Book)
+	if err := args.Error(1); err != nil {
+		return nil, err
+	}
+	if err := apply(book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (m *repoMock) Delete(ctx context.Context, id, version int) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
-// helper to create service with mock repository
-func newServiceWithMock(repo *repoMock) *BookService {
-	return &BookService{repo: repo, client: &http.Client{}}
+func (m *repoMock) UpsertByGoogleID(ctx context.Context, book *entities.Book) (bool, error) {
+	args := m.Called(ctx, book)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *repoMock) Restore(ctx context.Context, id, version int) (*entities.Book, error) {
+	args := m.Called(ctx, id, version)
+	book, _ := args.Get(0).(*entities.Book)
+	return book, args.Error(1)
+}
+
+func (m *repoMock) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *repoMock) History(ctx context.Context, id int) ([]*entities.Revision, error) {
+	args := m.Called(ctx, id)
+	history, _ := args.Get(0).([]*entities.Revision)
+	return history, args.Error(1)
+}
+
+// helper to create service with mock repositories
+func newServiceWithMock(repo *repoMock, authorRepo ...*authorRepoMock) *BookService {
+	svc := &BookService{repo: repo, logger: slog.New(slog.DiscardHandler)}
+	if len(authorRepo) > 0 {
+		svc.authorRepo = authorRepo[0]
+	}
+	return svc
+}
+
+// validBook returns a book that passes validation, written by author 1.
+func validBook(id int, title string) *entities.Book {
+	return &entities.Book{
+		ID:          id,
+		Title:       title,
+		PublishedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		AuthorID:    1,
+		Price:       9.99,
+	}
 }
 
 func TestBookService_GetAllBooks(t *testing.T) {
 	ctx := context.Background()
-	expected := []*entities.Book{{ID: 1, Title: "Test"}}
+	expected := &entities.BookPage{Items: []*entities.Book{{ID: 1, Title: "Test"}}, Total: 1, Limit: entities.DefaultBookLimit}
 
+	// An empty query is sent to the repository with the defaults applied
 	repo := &repoMock{}
-	repo.On("FindAll", ctx).Return(expected, nil).Once()
+	repo.On("FindAll", ctx, entities.BookQuery{
+		Limit: entities.DefaultBookLimit,
+		Sort:  entities.SortByPublishedAt,
+		Desc:  true,
+	}).Return(expected, nil).Once()
 
 	svc := newServiceWithMock(repo)
 
-	books, err := svc.GetAllBooks(ctx)
+	page, err := svc.GetAllBooks(ctx, entities.BookQuery{})
 	assert.NoError(t, err)
-	assert.Equal(t, expected, books)
+	assert.Equal(t, expected, page)
 	repo.AssertExpectations(t)
 }
 
+func TestBookService_SearchBooks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should apply the paging defaults", func(t *testing.T) {
+		expected := &entities.BookSearchPage{Items: []*entities.BookSearchHit{}, Limit: entities.DefaultBookLimit}
+		repo := &repoMock{}
+		repo.On("Search", ctx, entities.BookSearchQuery{Query: "earthsea", Limit: entities.DefaultBookLimit, Fuzzy: true}).
+			Return(expected, nil).Once()
+		svc := newServiceWithMock(repo)
+
+		page, err := svc.SearchBooks(ctx, entities.BookSearchQuery{Query: "earthsea", Fuzzy: true})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, page)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should require a query", func(t *testing.T) {
+		repo := &repoMock{}
+		svc := newServiceWithMock(repo)
+
+		_, err := svc.SearchBooks(ctx, entities.BookSearchQuery{Query: "  "})
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		repo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+	})
+}
+
 func TestBookService_GetBookByID(t *testing.T) {
 	ctx := context.Background()
 	expected := &entities.Book{ID: 2, Title: "Another"}
@@ -126,40 +295,498 @@ func TestBookService_GetBookByID(t *testing.T) {
 
 func TestBookService_AddBook(t *testing.T) {
 	ctx := context.Background()
-	b := &entities.Book{Title: "Create"}
+	b := validBook(0, "Create")
 
 	repo := &repoMock{}
 	repo.On("Create", ctx, b).Return(nil).Once()
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil).Once()
 
-	svc := newServiceWithMock(repo)
+	svc := newServiceWithMock(repo, authorRepo)
+
+	err := svc.AddBook(ctx, b)
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestBookService_AddBook_NormalizesISBN(t *testing.T) {
+	ctx := context.Background()
+	b := validBook(0, "Dune")
+	b.ISBN10 = "0-441-17271-7"
+
+	repo := &repoMock{}
+	repo.On("Create", ctx, b).Return(nil).Once()
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil).Once()
+
+	svc := newServiceWithMock(repo, authorRepo)
+
+	err := svc.AddBook(ctx, b)
+	assert.NoError(t, err)
+	assert.Equal(t, "9780441172719", b.ISBN13)
+	assert.Equal(t, "0441172717", b.ISBN10)
+	repo.AssertExpectations(t)
+}
+
+func TestBookService_AddBook_Contributors(t *testing.T) {
+	ctx := context.Background()
+	b := validBook(0, "Good Omens")
+	b.AuthorID = 0
+	b.Contributors = []entities.Contributor{
+		{AuthorID: 3, Role: entities.RoleEditor},
+		{AuthorID: 1},
+		{AuthorID: 2, Role: entities.RoleAuthor},
+	}
+
+	repo := &repoMock{}
+	repo.On("Create", ctx, b).Return(nil).Once()
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1, Name: "Terry Pratchett"}, nil).Once()
+	authorRepo.On("FindByID", ctx, 2).Return(&entities.Author{ID: 2, Name: "Neil Gaiman"}, nil).Once()
+	authorRepo.On("FindByID", ctx, 3).Return(&entities.Author{ID: 3, Name: "An Editor"}, nil).Once()
+
+	svc := newServiceWithMock(repo, authorRepo)
+
+	err := svc.AddBook(ctx, b)
+	assert.NoError(t, err)
+	// The first contributor credited as author is the primary author
+	assert.Equal(t, 1, b.AuthorID)
+	assert.Equal(t, []entities.Contributor{
+		{AuthorID: 3, Name: "An Editor", Role: entities.RoleEditor},
+		{AuthorID: 1, Name: "Terry Pratchett", Role: entities.RoleAuthor},
+		{AuthorID: 2, Name: "Neil Gaiman", Role: entities.RoleAuthor},
+	}, b.Contributors)
+	repo.AssertExpectations(t)
+	authorRepo.AssertExpectations(t)
+}
+
+func TestBookService_AddBook_Labels(t *testing.T) {
+	ctx := context.Background()
+	b := validBook(0, "The Left Hand of Darkness")
+	b.Genres = []string{"Science Fiction", "science-fiction"}
+	b.Tags = []string{"Hugo Award", "classic", "hugo-award"}
+
+	repo := &repoMock{}
+	repo.On("Create", ctx, b).Return(nil).Once()
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil).Once()
+	genreRepo := &genreRepoMock{}
+	genreRepo.On("FindBySlug", ctx, "science-fiction").Return(&entities.Genre{ID: 2, Slug: "science-fiction"}, nil).Once()
+
+	svc := newServiceWithMock(repo, authorRepo)
+	svc.genreRepo = genreRepo
+
+	err := svc.AddBook(ctx, b)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"science-fiction"}, b.Genres)
+	assert.Equal(t, []string{"classic", "hugo-award"}, b.Tags)
+	repo.AssertExpectations(t)
+	genreRepo.AssertExpectations(t)
+}
+
+func TestBookService_AddBook_UnknownGenre(t *testing.T) {
+	ctx := context.Background()
+	b := validBook(0, "Dune")
+	b.Genres = []string{"space-opera"}
+	b.Tags = []string{"!!"}
+
+	repo := &repoMock{}
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil).Once()
+	genreRepo := &genreRepoMock{}
+	genreRepo.On("FindBySlug", ctx, "space-opera").Return(nil, apperr.NotFound("genre space-opera not found")).Once()
+
+	svc := newServiceWithMock(repo, authorRepo)
+	svc.genreRepo = genreRepo
 
 	err := svc.AddBook(ctx, b)
+
+	assert.ErrorIs(t, err, apperr.ErrValidation)
+	var got []string
+	for _, f := range apperr.Fields(err) {
+		got = append(got, f.Field+":"+f.Rule)
+	}
+	assert.Equal(t, []string{"tags[0]:required", "genres[0]:exists"}, got)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestBookService_GetBookByISBN(t *testing.T) {
+	ctx := context.Background()
+	expected := &entities.Book{ID: 2, Title: "Dune", ISBN13: "9780441172719", ISBN10: "0441172717"}
+
+	repo := &repoMock{}
+	repo.On("FindByISBN", ctx, "9780441172719").Return(expected, nil).Once()
+
+	svc := newServiceWithMock(repo)
+
+	book, err := svc.GetBookByISBN(ctx, "0-441-17271-7")
 	assert.NoError(t, err)
+	assert.Equal(t, expected, book)
+
+	_, err = svc.GetBookByISBN(ctx, "0441172718")
+	assert.ErrorIs(t, err, apperr.ErrValidation)
 	repo.AssertExpectations(t)
 }
 
+// isbnProvider is a catalog provider that answers every search with books.
+type isbnProvider struct {
+	query string
+	books []entities.ExternalBook
+}
+
+func (p *isbnProvider) Name() string { return "fake" }
+
+func (p *isbnProvider) SearchBooks(ctx context.Context, query string) ([]entities.ExternalBook, error) {
+	p.query = query
+	return p.books, nil
+}
+
+func TestBookService_LookupExternalISBN(t *testing.T) {
+	ctx := context.Background()
+	dune := entities.ExternalBook{Sources: map[string]string{"fake": "1"}, Title: "Dune", ISBNs: []string{"9780441172719"}}
+	other := entities.ExternalBook{Sources: map[string]string{"fake": "2"}, Title: "Dune Messiah", ISBNs: []string{"9780593098233"}}
+
+	provider := &isbnProvider{books: []entities.ExternalBook{other, dune}}
+	svc := &BookService{catalog: catalog.New(slog.New(slog.DiscardHandler), provider), logger: slog.New(slog.DiscardHandler)}
+
+	book, err := svc.LookupExternalISBN(ctx, "0441172717")
+	assert.NoError(t, err)
+	assert.Equal(t, "isbn:9780441172719", provider.query)
+	assert.Equal(t, "Dune", book.Title)
+
+	provider.books = []entities.ExternalBook{other}
+	_, err = svc.LookupExternalISBN(ctx, "9780441172719")
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
 func TestBookService_UpdateBook(t *testing.T) {
 	ctx := context.Background()
-	b := &entities.Book{ID: 3, Title: "Updated"}
+	b := validBook(3, "Updated")
+	b.Contributors = []entities.Contributor{{AuthorID: 1}}
 
 	repo := &repoMock{}
 	repo.On("Update", ctx, b).Return(nil).Once()
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil).Once()
 
-	svc := newServiceWithMock(repo)
+	svc := newServiceWithMock(repo, authorRepo)
 
 	err := svc.UpdateBook(ctx, b)
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 }
 
+func TestBookService_UpdateBook_WithoutContributors(t *testing.T) {
+	ctx := context.Background()
+
+	// stored returns Good Omens, credited to two authors and an editor
+	stored := func() *entities.Book {
+		b := validBook(3, "Good Omens")
+		b.Contributors = []entities.Contributor{
+			{AuthorID: 1, Name: "Terry Pratchett", Role: entities.RoleAuthor},
+			{AuthorID: 2, Name: "Neil Gaiman", Role: entities.RoleAuthor},
+			{AuthorID: 4, Name: "Malcolm Edwards", Role: entities.RoleEditor},
+		}
+		b.Genres, b.Tags = []string{}, []string{}
+		b.Version = 2
+		return b
+	}
+
+	tests := []struct {
+		name             string
+		authorID         int
+		wantContributors []int
+	}{
+		{name: "same primary author keeps the contributors", authorID: 1, wantContributors: []int{1, 2, 4}},
+		{name: "another primary author replaces only the first author", authorID: 3, wantContributors: []int{3, 2, 4}},
+		{name: "a co-author becomes the primary author once", authorID: 2, wantContributors: []int{2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMock{}
+			repo.On("Patch", ctx, 3, 2).Return(stored(), nil).Once()
+			authorRepo := &authorRepoMock{}
+			for id, name := range map[int]string{1: "Terry Pratchett", 2: "Neil Gaiman", 3: "Someone Else", 4: "Malcolm Edwards"} {
+				authorRepo.On("FindByID", ctx, id).Return(&entities.Author{ID: id, Name: name}, nil).Maybe()
+			}
+			svc := newServiceWithMock(repo, authorRepo)
+
+			// Only author_id is sent, without contributors
+			book := validBook(3, "Good Omens")
+			book.AuthorID = tt.authorID
+			book.Version = 2
+			err := svc.UpdateBook(ctx, book)
+
+			require.NoError(t, err)
+			var ids []int
+			for _, c := range book.Contributors {
+				ids = append(ids, c.AuthorID)
+			}
+			assert.Equal(t, tt.wantContributors, ids)
+			assert.Equal(t, tt.authorID, book.AuthorID)
+			assert.Equal(t, entities.RoleEditor, book.Contributors[len(book.Contributors)-1].Role)
+			repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestWithPrimaryAuthor(t *testing.T) {
+	author := func(id int) entities.Contributor {
+		return entities.Contributor{AuthorID: id, Role: entities.RoleAuthor}
+	}
+	editor := entities.Contributor{AuthorID: 9, Role: entities.RoleEditor}
+
+	tests := []struct {
+		name         string
+		contributors []entities.Contributor
+		authorID     int
+		want         []entities.Contributor
+	}{
+		{name: "no author_id", contributors: []entities.Contributor{author(1), editor}, want: []entities.Contributor{author(1), editor}},
+		{name: "current primary author", contributors: []entities.Contributor{editor, author(1), author(2)}, authorID: 1, want: []entities.Contributor{editor, author(1), author(2)}},
+		{name: "new author replaces the primary one", contributors: []entities.Contributor{editor, author(1), author(2)}, authorID: 3, want: []entities.Contributor{editor, author(3), author(2)}},
+		{name: "co-author moves up", contributors: []entities.Contributor{author(1), author(2), editor}, authorID: 2, want: []entities.Contributor{author(2), editor}},
+		{name: "no author credited", contributors: []entities.Contributor{editor}, authorID: 3, want: []entities.Contributor{author(3), editor}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, withPrimaryAuthor(tt.contributors, tt.authorID))
+		})
+	}
+}
+
+func TestBookService_PatchBook(t *testing.T) {
+	ctx := context.Background()
+
+	// stored returns the book as the repository reads it
+	stored := func() *entities.Book {
+		b := validBook(3, "Dune")
+		b.ISBN13, b.ISBN10 = "9780441172719", "0441172717"
+		b.Contributors = []entities.Contributor{{AuthorID: 1, Name: "Frank Herbert", Role: entities.RoleAuthor}}
+		b.Genres, b.Tags = []string{}, []string{"classic"}
+		b.Version = 2
+		return b
+	}
+
+	tests := []struct {
+		name      string
+		mediaType string
+		patch     string
+		check     func(t *testing.T, book *entities.Book)
+		wantErr   error
+	}{
+		{
+			name:      "merge patch keeps the other fields",
+			mediaType: jsonpatch.MergePatchType,
+			patch:     `{"price": 12.5, "tags": null}`,
+			check: func(t *testing.T, book *entities.Book) {
+				assert.Equal(t, 12.5, book.Price)
+				assert.Equal(t, "Dune", book.Title)
+				assert.Equal(t, "9780441172719", book.ISBN13)
+				assert.Empty(t, book.Tags)
+			},
+		},
+		{
+			name:      "a new ISBN-13 replaces the ISBN-10",
+			mediaType: jsonpatch.JSONPatchType,
+			patch:     `[{"op": "replace", "path": "/isbn_13", "value": "979-10-90636-07-1"}]`,
+			check: func(t *testing.T, book *entities.Book) {
+				assert.Equal(t, "9791090636071", book.ISBN13)
+				assert.Empty(t, book.ISBN10)
+			},
+		},
+		{
+			name:      "new contributors set the primary author",
+			mediaType: jsonpatch.JSONPatchType,
+			patch:     `[{"op": "add", "path": "/contributors/0", "value": {"author_id": 2}}]`,
+			check: func(t *testing.T, book *entities.Book) {
+				assert.Equal(t, 2, book.AuthorID)
+				assert.Len(t, book.Contributors, 2)
+			},
+		},
+		{
+			name:      "failed test operation",
+			mediaType: jsonpatch.JSONPatchType,
+			patch:     `[{"op": "test", "path": "/price", "value": 1}, {"op": "replace", "path": "/price", "value": 2}]`,
+			wantErr:   apperr.ErrConflict,
+		},
+		{
+			name:      "unknown field",
+			mediaType: jsonpatch.MergePatchType,
+			patch:     `{"subtitle": "Book One"}`,
+			wantErr:   apperr.ErrValidation,
+		},
+		{
+			name:      "removed title",
+			mediaType: jsonpatch.JSONPatchType,
+			patch:     `[{"op": "remove", "path": "/title"}]`,
+			wantErr:   apperr.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := jsonpatch.Parse(tt.mediaType, []byte(tt.patch))
+			assert.NoError(t, err)
+
+			repo := &repoMock{}
+			repo.On("Patch", ctx, 3, 2).Return(stored(), nil).Once()
+			authorRepo := &authorRepoMock{}
+			authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1, Name: "Frank Herbert"}, nil).Maybe()
+			authorRepo.On("FindByID", ctx, 2).Return(&entities.Author{ID: 2, Name: "Brian Herbert"}, nil).Maybe()
+			svc := newServiceWithMock(repo, authorRepo)
+
+			book, err := svc.PatchBook(ctx, 3, 2, patch)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, book)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestBookService_RemoveBook(t *testing.T) {
 	ctx := context.Background()
 	repo := &repoMock{}
-	repo.On("Delete", ctx, 4).Return(nil).Once()
+	repo.On("Delete", ctx, 4, 2).Return(nil).Once()
 
 	svc := newServiceWithMock(repo)
 
-	err := svc.RemoveBook(ctx, 4)
+	err := svc.RemoveBook(ctx, 4, 2)
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 }
+
+func TestBookService_RestoreBook(t *testing.T) {
+	ctx := context.Background()
+	repo := &repoMock{}
+	repo.On("Restore", ctx, 4, 3).Return(&entities.Book{ID: 4, Version: 4}, nil).Once()
+
+	svc := newServiceWithMock(repo)
+
+	book, err := svc.RestoreBook(ctx, 4, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, book.Version)
+	repo.AssertExpectations(t)
+}
+
+func TestBookService_AddBook_Validation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		book           *entities.Book
+		authorSetup    func(authorRepo *authorRepoMock)
+		expectedFields []string
+	}{
+		{
+			name: "empty title and negative price",
+			book: &entities.Book{Title: " ", Price: -1, PublishedAt: time.Now(), AuthorID: 1},
+			authorSetup: func(authorRepo *authorRepoMock) {
+				authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil)
+			},
+			expectedFields: []string{"title:required", "price:min"},
+		},
+		{
+			name: "mismatched ISBNs",
+			book: &entities.Book{Title: "Dune", PublishedAt: time.Now(), AuthorID: 1, ISBN13: "9780441172719", ISBN10: "0441013597"},
+			authorSetup: func(authorRepo *authorRepoMock) {
+				authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil)
+			},
+			expectedFields: []string{"isbn_10:mismatch"},
+		},
+		{
+			name: "ISBN with a wrong check digit",
+			book: &entities.Book{Title: "Dune", PublishedAt: time.Now(), AuthorID: 1, ISBN13: "978-0-441-17271-0"},
+			authorSetup: func(authorRepo *authorRepoMock) {
+				authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil)
+			},
+			expectedFields: []string{"isbn_13:format"},
+		},
+		{
+			name: "invalid contributors",
+			book: &entities.Book{Title: "Dune", PublishedAt: time.Now(), AuthorID: 2, Contributors: []entities.Contributor{
+				{AuthorID: 1, Role: entities.RoleAuthor},
+				{AuthorID: 1},
+				{Role: "narrator"},
+			}},
+			authorSetup: func(authorRepo *authorRepoMock) {
+				authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil)
+			},
+			expectedFields: []string{
+				"contributors[1]:unique",
+				"contributors[2].author_id:required",
+				"contributors[2].role:one_of",
+				"author_id:mismatch",
+			},
+		},
+		{
+			name: "contributor that does not exist",
+			book: &entities.Book{Title: "Dune", PublishedAt: time.Now(), Contributors: []entities.Contributor{
+				{AuthorID: 1, Role: entities.RoleAuthor},
+				{AuthorID: 404, Role: entities.RoleTranslator},
+			}},
+			authorSetup: func(authorRepo *authorRepoMock) {
+				authorRepo.On("FindByID", ctx, 1).Return(&entities.Author{ID: 1}, nil)
+				authorRepo.On("FindByID", ctx, 404).Return(nil, apperr.NotFound("author with ID 404 not found"))
+			},
+			expectedFields: []string{"contributors[1].author_id:exists"},
+		},
+		{
+			name:           "missing author and publication date",
+			book:           &entities.Book{Title: "Orphan"},
+			authorSetup:    func(authorRepo *authorRepoMock) {},
+			expectedFields: []string{"published_at:required", "author_id:required"},
+		},
+		{
+			name: "author that does not exist",
+			book: &entities.Book{Title: "Ghost", PublishedAt: time.Now(), AuthorID: 404},
+			authorSetup: func(authorRepo *authorRepoMock) {
+				authorRepo.On("FindByID", ctx, 404).Return(nil, apperr.NotFound("author with ID 404 not found"))
+			},
+			expectedFields: []string{"author_id:exists"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &repoMock{}
+			authorRepo := &authorRepoMock{}
+			tc.authorSetup(authorRepo)
+
+			svc := newServiceWithMock(repo, authorRepo)
+
+			err := svc.AddBook(ctx, tc.book)
+
+			assert.ErrorIs(t, err, apperr.ErrValidation)
+			var got []string
+			for _, f := range apperr.Fields(err) {
+				got = append(got, f.Field+":"+f.Rule)
+			}
+			assert.Equal(t, tc.expectedFields, got)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestBookService_AddBook_AuthorLookupFailure(t *testing.T) {
+	ctx := context.Background()
+	b := validBook(0, "Create")
+	dbErr := apperr.Unavailable("failed to find author by ID 1", errors.New("connection refused"))
+
+	repo := &repoMock{}
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("FindByID", ctx, 1).Return(nil, dbErr).Once()
+
+	svc := newServiceWithMock(repo, authorRepo)
+
+	err := svc.AddBook(ctx, b)
+	assert.ErrorIs(t, err, apperr.ErrUnavailable)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+type GenreService struct {
+	repo   storage.GenreRepository
+	logger *slog.Logger
+}
+
+func NewGenreService(repo storage.GenreRepository, logger *slog.Logger) *GenreService {
+	return &GenreService{
+		repo:   repo,
+		logger: logging.Component(logger, "service"),
+	}
+}
+
+// GetAllGenres returns every genre with its book count, which includes the
+// books of its subgenres.
+func (s *GenreService) GetAllGenres(ctx context.Context) ([]*entities.Genre, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *GenreService) GetGenreByID(ctx context.Context, id int) (*entities.Genre, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// AddGenre stores a genre. Its slug is derived from the name when missing.
+func (s *GenreService) AddGenre(ctx context.Context, genre *entities.Genre) error {
+	if err := validateGenre(genre).err(); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, genre); err != nil {
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "genre added", "genre_id", genre.ID, "slug", genre.Slug)
+	return nil
+}
+
+// UpdateGenre renames or moves a genre. It fails with
+// storage.ErrGenreCycle when the new parent is one of its subgenres.
+func (s *GenreService) UpdateGenre(ctx context.Context, genre *entities.Genre) error {
+	if err := validateGenre(genre).err(); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, genre)
+}
+
+// RemoveGenre deletes a genre and unfiles its books. It fails with
+// storage.ErrGenreHasSubgenres while the genre still has subgenres.
+func (s *GenreService) RemoveGenre(ctx context.Context, id int) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "genre removed", "genre_id", id)
+	return nil
+}
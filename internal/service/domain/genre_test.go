@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+type genreRepoMock struct {
+	mock.Mock
+}
+
+func (m *genreRepoMock) FindAll(ctx context.Context) ([]*entities.Genre, error) {
+	args := m.Called(ctx)
+	genres, _ := args.Get(0).([]*entities.Genre)
+	return genres, args.Error(1)
+}
+
+func (m *genreRepoMock) FindByID(ctx context.Context, id int) (*entities.Genre, error) {
+	args := m.Called(ctx, id)
+	genre, _ := args.Get(0).(*entities.Genre)
+	return genre, args.Error(1)
+}
+
+func (m *genreRepoMock) FindBySlug(ctx context.Context, slug string) (*entities.Genre, error) {
+	args := m.Called(ctx, slug)
+	genre, _ := args.Get(0).(*entities.Genre)
+	return genre, args.Error(1)
+}
+
+func (m *genreRepoMock) Create(ctx context.Context, genre *entities.Genre) error {
+	args := m.Called(ctx, genre)
+	return args.Error(0)
+}
+
+func (m *genreRepoMock) Update(ctx context.Context, genre *entities.Genre) error {
+	args := m.Called(ctx, genre)
+	return args.Error(0)
+}
+
+func (m *genreRepoMock) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestGenreService_AddGenre(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		genre        *entities.Genre
+		expectedSlug string
+	}{
+		{name: "slug derived from the name", genre: &entities.Genre{Name: "Science Fiction"}, expectedSlug: "science-fiction"},
+		{name: "slug derived from a non-ASCII name", genre: &entities.Genre{Name: "Ciência"}, expectedSlug: "ciência"},
+		{name: "slug derived from non-ASCII words", genre: &entities.Genre{Name: "Ficção Científica"}, expectedSlug: "ficção-científica"},
+		{name: "non-ASCII slug", genre: &entities.Genre{Name: "Science", Slug: "ciência"}, expectedSlug: "ciência"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &genreRepoMock{}
+			repo.On("Create", ctx, tc.genre).Return(nil).Once()
+			svc := NewGenreService(repo, slog.New(slog.DiscardHandler))
+
+			assert.NoError(t, svc.AddGenre(ctx, tc.genre))
+			assert.Equal(t, tc.expectedSlug, tc.genre.Slug)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGenreService_AddGenre_Validation(t *testing.T) {
+	ctx := context.Background()
+	self := 3
+
+	tests := []struct {
+		name           string
+		genre          *entities.Genre
+		expectedFields []string
+	}{
+		{
+			name:           "missing name",
+			genre:          &entities.Genre{},
+			expectedFields: []string{"name:required", "slug:format"},
+		},
+		{
+			name:           "malformed slug",
+			genre:          &entities.Genre{Name: "Fantasy", Slug: "Fantasy--"},
+			expectedFields: []string{"slug:format"},
+		},
+		{
+			name:           "uppercase non-ASCII slug",
+			genre:          &entities.Genre{Name: "Science", Slug: "Ciência"},
+			expectedFields: []string{"slug:format"},
+		},
+		{
+			name:           "own parent",
+			genre:          &entities.Genre{ID: 3, Name: "Fantasy", ParentID: &self},
+			expectedFields: []string{"parent_id:not_self"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &genreRepoMock{}
+			svc := NewGenreService(repo, slog.New(slog.DiscardHandler))
+
+			err := svc.AddGenre(ctx, tc.genre)
+
+			assert.ErrorIs(t, err, apperr.ErrValidation)
+			var got []string
+			for _, f := range apperr.Fields(err) {
+				got = append(got, f.Field+":"+f.Rule)
+			}
+			assert.Equal(t, tc.expectedFields, got)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestGenreService_RemoveGenre_HasSubgenres(t *testing.T) {
+	ctx := context.Background()
+	repoErr := apperr.Conflict("genre with ID 1 still has subgenres", storage.ErrGenreHasSubgenres)
+
+	repo := &genreRepoMock{}
+	repo.On("Delete", ctx, 1).Return(repoErr).Once()
+	svc := NewGenreService(repo, slog.New(slog.DiscardHandler))
+
+	err := svc.RemoveGenre(ctx, 1)
+
+	assert.ErrorIs(t, err, storage.ErrGenreHasSubgenres)
+	repo.AssertExpectations(t)
+}
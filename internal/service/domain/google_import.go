@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/isbn"
+)
+
+// googleVolumeID matches the IDs Google Books assigns to volumes.
+var googleVolumeID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ImportGoogleBook fetches a Google Books volume and stores it as a local
+// book. The volume's first author is matched by name (ignoring case) or
+// created. Importing the same volume again refreshes the fields of the
+// existing book that come from the volume, and keeps its contributors,
+// genres and tags; the returned flag reports whether the book was created.
+func (s *BookService) ImportGoogleBook(ctx context.Context, volumeID string) (*entities.Book, bool, error) {
+	v := &validator{}
+	v.required(volumeID, "volume_id")
+	v.check(volumeID == "" || googleVolumeID.MatchString(volumeID), "volume_id", "format", "volume_id is not a Google Books volume ID")
+	if err := v.err(); err != nil {
+		return nil, false, err
+	}
+
+	volume, err := s.fetchGoogleVolume(ctx, volumeID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	book, authorName, err := bookFromGoogleVolume(volume)
+	if err != nil {
+		return nil, false, err
+	}
+
+	author, err := s.importAuthor(ctx, authorName)
+	if err != nil {
+		return nil, false, err
+	}
+	book.AuthorID = author.ID
+	book.Contributors = []entities.Contributor{{AuthorID: author.ID, Name: author.Name, Role: entities.RoleAuthor}}
+
+	if err := validateBook(book).err(); err != nil {
+		return nil, false, err
+	}
+
+	created, err := s.repo.UpsertByGoogleID(ctx, book)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.logger.DebugContext(ctx, "google volume imported", "volume_id", volumeID, "book_id", book.ID, "created", created)
+	return book, created, nil
+}
+
+func (s *BookService) fetchGoogleVolume(ctx context.Context, volumeID string) (*entities.GoogleBook, error) {
+	volume, err := s.google.Volume(ctx, volumeID)
+	if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+		s.logger.WarnContext(ctx, "google books volume request failed", "err", err)
+	}
+	return volume, err
+}
+
+// importAuthor returns the author named name, creating it when there is
+// none. Imported authors have no birthdate or bio.
+func (s *BookService) importAuthor(ctx context.Context, name string) (*entities.Author, error) {
+	author, created, err := s.authorRepo.FindOrCreateByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		s.logger.DebugContext(ctx, "author created by import", "author_id", author.ID)
+	}
+	return author, nil
+}
+
+// bookFromGoogleVolume maps a volume onto a book and returns the name of
+// its first author. The list price is taken as is, whatever its currency.
+// Identifiers that are not valid ISBNs are ignored.
+func bookFromGoogleVolume(volume *entities.GoogleBook) (*entities.Book, string, error) {
+	info := volume.VolumeInfo
+	book := &entities.Book{
+		Title:       strings.TrimSpace(info.Title),
+		Description: info.Description,
+		GoogleID:    volume.ID,
+	}
+	if price := volume.SaleInfo.ListPrice; price != nil {
+		book.Price = price.Amount
+	}
+	for _, kind := range []string{entities.GoogleISBN13, entities.GoogleISBN10} {
+		if n, err := isbn.Normalize(info.Identifier(kind)); err == nil {
+			book.ISBN13 = n
+			break
+		}
+	}
+
+	v := &validator{}
+	publishedAt, ok := parseGoogleDate(info.PublishedDate)
+	v.check(ok, "published_at", "required", fmt.Sprintf("google volume %s has no usable published date", volume.ID))
+	book.PublishedAt = publishedAt
+
+	var authorName string
+	if len(info.Authors) > 0 {
+		authorName = strings.TrimSpace(info.Authors[0])
+	}
+	v.check(authorName != "", "author_id", "required", fmt.Sprintf("google volume %s lists no author", volume.ID))
+	v.maxLength(authorName, "author.name", maxNameLength)
+
+	return book, authorName, v.err()
+}
+
+// parseGoogleDate parses the full, year-month and year-only dates Google
+// Books reports. Partial dates fall on the first day of the period.
+func parseGoogleDate(s string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
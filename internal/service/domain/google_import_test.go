@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog/google"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+)
+
+const duneVolume = `{
+	"id": "B1hSG45JCX4C",
+	"volumeInfo": {
+		"title": "Dune",
+		"authors": ["Frank Herbert", "Someone Else"],
+		"description": "A desert planet.",
+		"publishedDate": "1965-08",
+		"industryIdentifiers": [{"type": "ISBN_10", "identifier": "0441172717"}]
+	},
+	"saleInfo": {"listPrice": {"amount": 9.99, "currencyCode": "USD"}}
+}`
+
+// newImportService returns a service whose Google client talks to a test
+// server answering every volume request with status and body.
+func newImportService(t *testing.T, status int, body string) (*BookService, *repoMock, *authorRepoMock, *string) {
+	t.Helper()
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	repo, authorRepo := new(repoMock), new(authorRepoMock)
+	client := google.New(google.Config{BaseURL: server.URL + "/volumes"})
+	return NewBookService(repo, authorRepo, nil, client, nil, slog.New(slog.DiscardHandler)), repo, authorRepo, &path
+}
+
+func TestBookService_ImportGoogleBook(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates the book and its author", func(t *testing.T) {
+		svc, repo, authorRepo, path := newImportService(t, http.StatusOK, duneVolume)
+		authorRepo.On("FindOrCreateByName", mock.Anything, "Frank Herbert").Return(&entities.Author{ID: 4, Name: "Frank Herbert"}, true, nil)
+		repo.On("UpsertByGoogleID", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { args.Get(1).(*entities.Book).ID = 10 }).
+			Return(true, nil)
+
+		book, created, err := svc.ImportGoogleBook(ctx, "B1hSG45JCX4C")
+
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, "/volumes/B1hSG45JCX4C", *path)
+		assert.Equal(t, &entities.Book{
+			ID:          10,
+			Title:       "Dune",
+			Description: "A desert planet.",
+			PublishedAt: time.Date(1965, 8, 1, 0, 0, 0, 0, time.UTC),
+			AuthorID:    4,
+			Contributors: []entities.Contributor{
+				{AuthorID: 4, Name: "Frank Herbert", Role: entities.RoleAuthor},
+			},
+			Price:    9.99,
+			GoogleID: "B1hSG45JCX4C",
+			ISBN10:   "0441172717",
+			ISBN13:   "9780441172719",
+		}, book)
+		repo.AssertExpectations(t)
+		authorRepo.AssertExpectations(t)
+	})
+
+	t.Run("reuses an existing author", func(t *testing.T) {
+		svc, repo, authorRepo, _ := newImportService(t, http.StatusOK, duneVolume)
+		authorRepo.On("FindOrCreateByName", mock.Anything, "Frank Herbert").Return(&entities.Author{ID: 2, Name: "frank herbert"}, false, nil)
+		repo.On("UpsertByGoogleID", mock.Anything, mock.MatchedBy(func(b *entities.Book) bool { return b.AuthorID == 2 })).
+			Return(false, nil)
+
+		_, created, err := svc.ImportGoogleBook(ctx, "B1hSG45JCX4C")
+
+		require.NoError(t, err)
+		assert.False(t, created)
+		repo.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name     string
+		volumeID string
+		status   int
+		body     string
+		kind     error
+		field    string
+	}{
+		{name: "missing volume ID", volumeID: "", kind: apperr.ErrValidation, field: "volume_id"},
+		{name: "malformed volume ID", volumeID: "../admin", kind: apperr.ErrValidation, field: "volume_id"},
+		{name: "unknown volume", volumeID: "missing", status: http.StatusNotFound, body: `{}`, kind: apperr.ErrNotFound},
+		{name: "google failure", volumeID: "abc", status: http.StatusInternalServerError, kind: apperr.ErrUnavailable},
+		{
+			name:     "volume without published date",
+			volumeID: "abc",
+			status:   http.StatusOK,
+			body:     `{"id":"abc","volumeInfo":{"title":"Untitled","authors":["A"]}}`,
+			kind:     apperr.ErrValidation,
+			field:    "published_at",
+		},
+		{
+			name:     "volume without author",
+			volumeID: "abc",
+			status:   http.StatusOK,
+			body:     `{"id":"abc","volumeInfo":{"title":"Anonymous","publishedDate":"2001"}}`,
+			kind:     apperr.ErrValidation,
+			field:    "author_id",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, repo, authorRepo, _ := newImportService(t, tc.status, tc.body)
+
+			_, _, err := svc.ImportGoogleBook(ctx, tc.volumeID)
+
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, tc.kind), "unexpected error kind: %v", err)
+			if tc.field != "" {
+				require.NotEmpty(t, apperr.Fields(err))
+				assert.Equal(t, tc.field, apperr.Fields(err)[0].Field)
+			}
+			repo.AssertNotCalled(t, "UpsertByGoogleID", mock.Anything, mock.Anything)
+			authorRepo.AssertNotCalled(t, "FindOrCreateByName", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -20,3 +21,59 @@ func (m *MockAuthorService) GetAuthorByID(ctx context.Context, id int) (*entitie
 	args := m.Called(ctx, id)
 	return args.Get(0).(*entities.Author), args.Error(1)
 }
+
+func (m *MockAuthorService) GetAllAuthors(ctx context.Context) ([]*entities.Author, error) {
+	args := m.Called(ctx)
+
+	authors, _ := args.Get(0).([]*entities.Author)
+	err := args.Error(1)
+	return authors, err
+}
+
+func (m *MockAuthorService) UpdateAuthor(ctx context.Context, author *entities.Author) error {
+	args := m.Called(ctx, author)
+	return args.Error(0)
+}
+
+func (m *MockAuthorService) PatchAuthor(ctx context.Context, id, version int, patch jsonpatch.Patch) (*entities.Author, error) {
+	args := m.Called(ctx, id, version, patch)
+	author, _ := args.Get(0).(*entities.Author)
+	return author, args.Error(1)
+}
+
+func (m *MockAuthorService) RemoveAuthor(ctx context.Context, id, version int) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (m *MockAuthorService) RestoreAuthor(ctx context.Context, id, version int) (*entities.Author, error) {
+	args := m.Called(ctx, id, version)
+	author, _ := args.Get(0).(*entities.Author)
+	return author, args.Error(1)
+}
+
+func (m *MockAuthorService) GetAuthorWithBooks(ctx context.Context, id int) (*entities.AuthorWithBooks, error) {
+	args := m.Called(ctx, id)
+
+	author, _ := args.Get(0).(*entities.AuthorWithBooks)
+	return author, args.Error(1)
+}
+
+func (m *MockAuthorService) GetAuthorBooks(ctx context.Context, id int) ([]*entities.Book, error) {
+	args := m.Called(ctx, id)
+
+	books, _ := args.Get(0).([]*entities.Book)
+	return books, args.Error(1)
+}
+
+func (m *MockAuthorService) GetAuthorHistory(ctx context.Context, id int) ([]*entities.Revision, error) {
+	args := m.Called(ctx, id)
+	history, _ := args.Get(0).([]*entities.Revision)
+	return history, args.Error(1)
+}
+
+func (m *MockAuthorService) RevertAuthor(ctx context.Context, id, revision, version int) (*entities.Author, error) {
+	args := m.Called(ctx, id, revision, version)
+	author, _ := args.Get(0).(*entities.Author)
+	return author, args.Error(1)
+}
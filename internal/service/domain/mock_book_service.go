@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -11,12 +12,18 @@ type MockBookService struct {
 	mock.Mock
 }
 
-func (m *MockBookService) GetAllBooks(ctx context.Context) ([]*entities.Book, error) {
-	args := m.Called(ctx)
+func (m *MockBookService) GetAllBooks(ctx context.Context, query entities.BookQuery) (*entities.BookPage, error) {
+	args := m.Called(ctx, query)
 
-	books, _ := args.Get(0).([]*entities.Book)
+	page, _ := args.Get(0).(*entities.BookPage)
 	err := args.Error(1)
-	return books, err
+	return page, err
+}
+
+func (m *MockBookService) SearchBooks(ctx context.Context, query entities.BookSearchQuery) (*entities.BookSearchPage, error) {
+	args := m.Called(ctx, query)
+	page, _ := args.Get(0).(*entities.BookSearchPage)
+	return page, args.Error(1)
 }
 
 func (m *MockBookService) GetBookByID(ctx context.Context, id int) (*entities.Book, error) {
@@ -24,6 +31,18 @@ func (m *MockBookService) GetBookByID(ctx context.Context, id int) (*entities.Bo
 	return args.Get(0).(*entities.Book), args.Error(1)
 }
 
+func (m *MockBookService) GetBookByISBN(ctx context.Context, isbn string) (*entities.Book, error) {
+	args := m.Called(ctx, isbn)
+	book, _ := args.Get(0).(*entities.Book)
+	return book, args.Error(1)
+}
+
+func (m *MockBookService) LookupExternalISBN(ctx context.Context, isbn string) (*entities.ExternalBook, error) {
+	args := m.Called(ctx, isbn)
+	book, _ := args.Get(0).(*entities.ExternalBook)
+	return book, args.Error(1)
+}
+
 func (m *MockBookService) AddBook(ctx context.Context, book *entities.Book) error {
 	args := m.Called(ctx, book)
 	return args.Error(0)
@@ -34,15 +53,51 @@ func (m *MockBookService) UpdateBook(ctx context.Context, book *entities.Book) e
 	return args.Error(0)
 }
 
-func (m *MockBookService) RemoveBook(ctx context.Context, id int) error {
-	args := m.Called(ctx, id)
+func (m *MockBookService) PatchBook(ctx context.Context, id, version int, patch jsonpatch.Patch) (*entities.Book, error) {
+	args := m.Called(ctx, id, version, patch)
+	book, _ := args.Get(0).(*entities.Book)
+	return book, args.Error(1)
+}
+
+func (m *MockBookService) RemoveBook(ctx context.Context, id, version int) error {
+	args := m.Called(ctx, id, version)
 	return args.Error(0)
 }
 
-func (m *MockBookService) SearchGoogleBooks(ctx context.Context, title string) ([]entities.GoogleBook, error) {
-	args := m.Called(ctx, title)
+func (m *MockBookService) RestoreBook(ctx context.Context, id, version int) (*entities.Book, error) {
+	args := m.Called(ctx, id, version)
+	book, _ := args.Get(0).(*entities.Book)
+	return book, args.Error(1)
+}
+
+func (m *MockBookService) GetBookHistory(ctx context.Context, id int) ([]*entities.Revision, error) {
+	args := m.Called(ctx, id)
+	history, _ := args.Get(0).([]*entities.Revision)
+	return history, args.Error(1)
+}
+
+func (m *MockBookService) RevertBook(ctx context.Context, id, revision, version int) (*entities.Book, error) {
+	args := m.Called(ctx, id, revision, version)
+	book, _ := args.Get(0).(*entities.Book)
+	return book, args.Error(1)
+}
+
+func (m *MockBookService) SearchGoogleBooks(ctx context.Context, query entities.GoogleBookQuery) (*entities.GoogleBookPage, error) {
+	args := m.Called(ctx, query)
 
-	books, _ := args.Get(0).([]entities.GoogleBook)
+	page, _ := args.Get(0).(*entities.GoogleBookPage)
 	err := args.Error(1)
-	return books, err
+	return page, err
+}
+
+func (m *MockBookService) ImportGoogleBook(ctx context.Context, volumeID string) (*entities.Book, bool, error) {
+	args := m.Called(ctx, volumeID)
+	book, _ := args.Get(0).(*entities.Book)
+	return book, args.Bool(1), args.Error(2)
+}
+
+func (m *MockBookService) SearchExternalBooks(ctx context.Context, query string, providers []string) (*entities.ExternalSearchResult, error) {
+	args := m.Called(ctx, query, providers)
+	result, _ := args.Get(0).(*entities.ExternalSearchResult)
+	return result, args.Error(1)
 }
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockGenreService struct {
+	mock.Mock
+}
+
+func (m *MockGenreService) GetAllGenres(ctx context.Context) ([]*entities.Genre, error) {
+	args := m.Called(ctx)
+	genres, _ := args.Get(0).([]*entities.Genre)
+	return genres, args.Error(1)
+}
+
+func (m *MockGenreService) GetGenreByID(ctx context.Context, id int) (*entities.Genre, error) {
+	args := m.Called(ctx, id)
+	genre, _ := args.Get(0).(*entities.Genre)
+	return genre, args.Error(1)
+}
+
+func (m *MockGenreService) AddGenre(ctx context.Context, genre *entities.Genre) error {
+	args := m.Called(ctx, genre)
+	return args.Error(0)
+}
+
+func (m *MockGenreService) UpdateGenre(ctx context.Context, genre *entities.Genre) error {
+	args := m.Called(ctx, genre)
+	return args.Error(0)
+}
+
+func (m *MockGenreService) RemoveGenre(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
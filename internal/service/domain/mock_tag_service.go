@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) GetAllTags(ctx context.Context) ([]*entities.Tag, error) {
+	args := m.Called(ctx)
+	tags, _ := args.Get(0).([]*entities.Tag)
+	return tags, args.Error(1)
+}
+
+func (m *MockTagService) GetTagByID(ctx context.Context, id int) (*entities.Tag, error) {
+	args := m.Called(ctx, id)
+	tag, _ := args.Get(0).(*entities.Tag)
+	return tag, args.Error(1)
+}
+
+func (m *MockTagService) AddTag(ctx context.Context, tag *entities.Tag) error {
+	args := m.Called(ctx, tag)
+	return args.Error(0)
+}
+
+func (m *MockTagService) UpdateTag(ctx context.Context, tag *entities.Tag) error {
+	args := m.Called(ctx, tag)
+	return args.Error(0)
+}
+
+func (m *MockTagService) RemoveTag(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
+)
+
+// applyPatch applies patch to the JSON representation of v and decodes the
+// result back into v. A patch that does not fit the document is a
+// conflict, and one that leaves a document v cannot hold a validation
+// error.
+func applyPatch[T any](patch jsonpatch.Patch, v *T) error {
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if doc, err = patch.Apply(doc); err != nil {
+		return apperr.Conflict(err.Error(), err)
+	}
+
+	var patched T
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patched); err != nil {
+		return apperr.Validation(patchedDocumentError(err), err)
+	}
+	*v = patched
+	return nil
+}
+
+// patchedDocumentError describes why the patched document could not be
+// decoded, in the words httpx.DecodeJSON uses for request bodies.
+func patchedDocumentError(err error) string {
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field == "":
+		return "the patched document must be a JSON object"
+	case errors.As(err, &typeErr):
+		return fmt.Sprintf("field %q must be of type %s", typeErr.Field, typeErr.Type)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return "the patched document contains unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	}
+	return "the patched document is invalid: " + err.Error()
+}
+
+// rederive drops the derived fields of a patched book whose source the
+// patch changed, or the source of those the patch changed, so that
+// validation derives them again instead of reporting a mismatch: the
+// ISBN-10 follows the ISBN-13, and author_id the contributors.
+func rederive(before, book *entities.Book) {
+	switch {
+	case book.ISBN13 != before.ISBN13 && book.ISBN10 == before.ISBN10:
+		book.ISBN10 = ""
+	case book.ISBN10 != before.ISBN10 && book.ISBN13 == before.ISBN13:
+		book.ISBN13 = ""
+	}
+
+	sameCredits := entities.SameCredits(book.Contributors, before.Contributors)
+	switch {
+	case !sameCredits && book.AuthorID == before.AuthorID:
+		book.AuthorID = 0
+	case sameCredits && book.AuthorID != before.AuthorID:
+		// Like a book sent without contributors, the author becomes the
+		// primary author and the others stay credited
+		book.Contributors = withPrimaryAuthor(book.Contributors, book.AuthorID)
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+// revertPatch returns the merge patch taking an entity at version back to
+// revision, by undoing the changes recorded since. history lists the
+// revisions of the entity newest first and must hold every one from
+// revision to version, otherwise the conflict wraps
+// storage.ErrHistoryIncomplete.
+func revertPatch(entity string, id int, history []*entities.Revision, version, revision int) (jsonpatch.Patch, error) {
+	if !slices.ContainsFunc(history, func(r *entities.Revision) bool { return r.Revision == revision }) {
+		return nil, apperr.NotFound(fmt.Sprintf("%s with ID %d has no revision %d", entity, id, revision))
+	}
+
+	// Older revisions come last and overwrite the values of newer ones,
+	// so each field ends up with its value at revision
+	values := make(map[string]json.RawMessage)
+	next := version
+	for _, r := range history {
+		// Stop at revision, or at the first write missing since
+		if r.Revision <= revision || r.Revision != next {
+			break
+		}
+		for name, change := range r.Changes {
+			values[name] = change.Before
+		}
+		next--
+	}
+	if next != revision {
+		return nil, apperr.Conflict(fmt.Sprintf("%s with ID %d cannot be reverted to revision %d: revision %d is not recorded", entity, id, revision, next), storage.ErrHistoryIncomplete)
+	}
+
+	doc, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpatch.Parse(jsonpatch.MergePatchType, doc)
+}
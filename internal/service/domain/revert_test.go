@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+// revision returns a revision of book 3 changing one field.
+func revision(n int, action entities.AuditAction, field, before, after string) *entities.Revision {
+	r := &entities.Revision{Entity: "book", EntityID: 3, Revision: n, Action: action, Changes: map[string]entities.FieldChange{}}
+	if field != "" {
+		r.Changes[field] = entities.FieldChange{Before: json.RawMessage(before), After: json.RawMessage(after)}
+	}
+	return r
+}
+
+// reverting matches the context of a write recorded as a revert.
+var reverting = mock.MatchedBy(func(ctx context.Context) bool {
+	return storage.AuditActionOf(ctx, entities.AuditUpdate) == entities.AuditRevert
+})
+
+func TestBookService_RevertBook(t *testing.T) {
+	ctx := context.Background()
+
+	// stored returns the book at version 6, after its price and then its
+	// title were changed, and it was deleted and restored
+	stored := func() *entities.Book {
+		b := validBook(3, "Dune Messiah")
+		b.Price = 12.5
+		b.Contributors = []entities.Contributor{{AuthorID: 1, Name: "Frank Herbert", Role: entities.RoleAuthor}}
+		b.Genres, b.Tags = []string{}, []string{}
+		b.Version = 6
+		return b
+	}
+	history := []*entities.Revision{
+		revision(6, entities.AuditRestore, "", "", ""),
+		revision(5, entities.AuditDelete, "", "", ""),
+		revision(4, entities.AuditUpdate, "title", `"Dune"`, `"Dune Messiah"`),
+		revision(3, entities.AuditUpdate, "price", `9.99`, `12.5`),
+		revision(2, entities.AuditUpdate, "price", `5`, `9.99`),
+		revision(1, entities.AuditCreate, "title", `null`, `"Dune"`),
+	}
+
+	tests := []struct {
+		name      string
+		revision  int
+		history   []*entities.Revision
+		wantTitle string
+		wantPrice float64
+		wantErr   error
+	}{
+		{name: "undoes every later change", revision: 2, history: history, wantTitle: "Dune", wantPrice: 9.99},
+		{name: "takes each field back to its oldest value since", revision: 1, history: history, wantTitle: "Dune", wantPrice: 5},
+		{name: "to the current revision changes nothing", revision: 6, history: history, wantTitle: "Dune Messiah", wantPrice: 12.5},
+		{name: "unknown revision", revision: 7, history: history, wantErr: apperr.ErrNotFound},
+		{name: "revision missing since", revision: 2, history: append(history[:3:3], history[4:]...), wantErr: storage.ErrHistoryIncomplete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &repoMock{}
+			repo.On("History", ctx, 3).Return(tt.history, nil).Once()
+			repo.On("Patch", reverting, 3, 6).Return(stored(), nil).Once()
+			authorRepo := &authorRepoMock{}
+			authorRepo.On("FindByID", mock.Anything, 1).Return(&entities.Author{ID: 1, Name: "Frank Herbert"}, nil).Maybe()
+			svc := newServiceWithMock(repo, authorRepo)
+
+			book, err := svc.RevertBook(ctx, 3, tt.revision, 6)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTitle, book.Title)
+			assert.Equal(t, tt.wantPrice, book.Price)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBookService_GetBookHistory(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the recorded revisions", func(t *testing.T) {
+		repo := &repoMock{}
+		repo.On("History", ctx, 3).Return([]*entities.Revision{revision(1, entities.AuditCreate, "title", `null`, `"Dune"`)}, nil).Once()
+		svc := newServiceWithMock(repo)
+
+		history, err := svc.GetBookHistory(ctx, 3)
+
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should report an unknown book without revisions", func(t *testing.T) {
+		repo := &repoMock{}
+		repo.On("History", ctx, 3).Return([]*entities.Revision{}, nil).Once()
+		repo.On("FindById", ctx, 3).Return(nil, apperr.NotFound("book with ID 3 not found")).Once()
+		svc := newServiceWithMock(repo)
+
+		_, err := svc.GetBookHistory(ctx, 3)
+
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+}
+
+func TestAuthorService_RevertAuthor(t *testing.T) {
+	ctx := context.Background()
+	authorRepo := &authorRepoMock{}
+	authorRepo.On("History", ctx, 5).Return([]*entities.Revision{
+		{Revision: 2, Action: entities.AuditUpdate, Changes: map[string]entities.FieldChange{
+			"bio": {Before: json.RawMessage(`""`), After: json.RawMessage(`"Science fiction writer"`)},
+		}},
+		{Revision: 1, Action: entities.AuditCreate},
+	}, nil).Once()
+	authorRepo.On("Patch", reverting, 5, 0).Return(&entities.Author{ID: 5, Name: "Octavia E. Butler", Bio: "Science fiction writer", BirthDate: time.Date(1947, 6, 22, 0, 0, 0, 0, time.UTC), Version: 2}, nil).Once()
+	svc := NewAuthorService(authorRepo, &repoMock{}, slog.New(slog.DiscardHandler))
+
+	author, err := svc.RevertAuthor(ctx, 5, 1, 0)
+
+	require.NoError(t, err)
+	assert.Empty(t, author.Bio)
+	authorRepo.AssertExpectations(t)
+}
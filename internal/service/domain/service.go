@@ -1,13 +1,20 @@
 package domain
 
 import (
+	"log/slog"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/catalog/google"
+
 	"github.com/demirbalemir/hop/Onboardingv2/internal/service"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
 )
 
-func NewService(repositories *storage.Repository) *service.Service {
+func NewService(repositories *storage.Repository, googleClient *google.Client, catalog *catalog.Catalog, logger *slog.Logger) *service.Service {
 	return &service.Service{
-		Book:   NewBookService(repositories.Book),
-		Author: NewAuthorService(repositories.Author, repositories.Book),
+		Book:   NewBookService(repositories.Book, repositories.Author, repositories.Genre, googleClient, catalog, logger),
+		Author: NewAuthorService(repositories.Author, repositories.Book, logger),
+		Genre:  NewGenreService(repositories.Genre, logger),
+		Tag:    NewTagService(repositories.Tag, logger),
 	}
 }
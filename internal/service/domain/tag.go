@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+type TagService struct {
+	repo   storage.TagRepository
+	logger *slog.Logger
+}
+
+func NewTagService(repo storage.TagRepository, logger *slog.Logger) *TagService {
+	return &TagService{
+		repo:   repo,
+		logger: logging.Component(logger, "service"),
+	}
+}
+
+func (s *TagService) GetAllTags(ctx context.Context) ([]*entities.Tag, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *TagService) GetTagByID(ctx context.Context, id int) (*entities.Tag, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// AddTag stores a tag ahead of its first book. Tags sent with books are
+// created on the fly, so this is only needed to curate them.
+func (s *TagService) AddTag(ctx context.Context, tag *entities.Tag) error {
+	if err := validateTag(tag).err(); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, tag); err != nil {
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "tag added", "tag_id", tag.ID, "name", tag.Name)
+	return nil
+}
+
+// UpdateTag renames a tag on every book carrying it.
+func (s *TagService) UpdateTag(ctx context.Context, tag *entities.Tag) error {
+	if err := validateTag(tag).err(); err != nil {
+		return err
+	}
+	return s.repo.Update(ctx, tag)
+}
+
+// RemoveTag deletes a tag and removes it from its books.
+func (s *TagService) RemoveTag(ctx context.Context, id int) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "tag removed", "tag_id", id)
+	return nil
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+)
+
+type tagRepoMock struct {
+	mock.Mock
+}
+
+func (m *tagRepoMock) FindAll(ctx context.Context) ([]*entities.Tag, error) {
+	args := m.Called(ctx)
+	tags, _ := args.Get(0).([]*entities.Tag)
+	return tags, args.Error(1)
+}
+
+func (m *tagRepoMock) FindByID(ctx context.Context, id int) (*entities.Tag, error) {
+	args := m.Called(ctx, id)
+	tag, _ := args.Get(0).(*entities.Tag)
+	return tag, args.Error(1)
+}
+
+func (m *tagRepoMock) Create(ctx context.Context, tag *entities.Tag) error {
+	args := m.Called(ctx, tag)
+	return args.Error(0)
+}
+
+func (m *tagRepoMock) Update(ctx context.Context, tag *entities.Tag) error {
+	args := m.Called(ctx, tag)
+	return args.Error(0)
+}
+
+func (m *tagRepoMock) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestTagService_AddTag(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should slugify the name", func(t *testing.T) {
+		tag := &entities.Tag{Name: "  Award Winner "}
+		repo := &tagRepoMock{}
+		repo.On("Create", ctx, tag).Return(nil).Once()
+		svc := NewTagService(repo, slog.New(slog.DiscardHandler))
+
+		assert.NoError(t, svc.AddTag(ctx, tag))
+		assert.Equal(t, "award-winner", tag.Name)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("should reject a name without letters or digits", func(t *testing.T) {
+		repo := &tagRepoMock{}
+		svc := NewTagService(repo, slog.New(slog.DiscardHandler))
+
+		err := svc.AddTag(ctx, &entities.Tag{Name: "--"})
+
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
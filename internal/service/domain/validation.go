@@ -0,0 +1,227 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/isbn"
+)
+
+const (
+	maxTitleLength       = 255
+	maxNameLength        = 255
+	maxDescriptionLength = 10000
+	maxBioLength         = 10000
+	maxLabelLength       = 64
+	maxSearchLength      = 256
+	maxPrice             = 99999999.99 // NUMERIC(10, 2)
+)
+
+// validator collects field errors so that every problem with a payload is
+// reported at once rather than one per request.
+type validator struct {
+	fields []apperr.FieldError
+}
+
+func (v *validator) check(ok bool, field, rule, message string) {
+	if !ok {
+		v.fields = append(v.fields, apperr.FieldError{Field: field, Rule: rule, Message: message})
+	}
+}
+
+func (v *validator) required(value, field string) {
+	v.check(strings.TrimSpace(value) != "", field, "required", field+" is required")
+}
+
+func (v *validator) maxLength(value, field string, max int) {
+	v.check(utf8.RuneCountInString(value) <= max, field, "max_length",
+		fmt.Sprintf("%s must be at most %d characters", field, max))
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &apperr.ValidationError{Fields: v.fields}
+}
+
+// validateBook checks the book's fields. It also normalizes its ISBNs:
+// either may be given, and both are set from the ISBN-13 when valid. It
+// reconciles the deprecated author_id with the contributors, and slugifies,
+// sorts and dedupes the genres and tags.
+func validateBook(book *entities.Book) *validator {
+	v := &validator{}
+	v.bookISBN(book)
+	v.bookContributors(book)
+	book.Genres = v.labels(book.Genres, "genres")
+	book.Tags = v.labels(book.Tags, "tags")
+	v.required(book.Title, "title")
+	v.maxLength(book.Title, "title", maxTitleLength)
+	v.maxLength(book.Description, "description", maxDescriptionLength)
+	v.check(book.Price >= 0, "price", "min", "price must not be negative")
+	v.check(book.Price <= maxPrice, "price", "max", fmt.Sprintf("price must be at most %.2f", maxPrice))
+	v.check(!book.PublishedAt.IsZero(), "published_at", "required", "published_at is required")
+	v.check(book.AuthorID > 0, "author_id", "required", "author_id or a contributor with the author role is required")
+	return v
+}
+
+// bookContributors checks the book's contributors. A book sent with only
+// an author_id gets that author as its sole contributor; otherwise
+// author_id is set to the primary author. Roles default to author.
+func (v *validator) bookContributors(book *entities.Book) {
+	if len(book.Contributors) == 0 {
+		if book.AuthorID > 0 {
+			book.Contributors = []entities.Contributor{{AuthorID: book.AuthorID, Role: entities.RoleAuthor}}
+		}
+		return
+	}
+
+	type credit struct {
+		authorID int
+		role     entities.ContributorRole
+	}
+	seen := make(map[credit]bool, len(book.Contributors))
+	for i := range book.Contributors {
+		c := &book.Contributors[i]
+		field := fmt.Sprintf("contributors[%d]", i)
+		if c.Role == "" {
+			c.Role = entities.RoleAuthor
+		}
+		v.check(c.AuthorID > 0, field+".author_id", "required", field+".author_id is required")
+		v.check(c.Role.Valid(), field+".role", "one_of", field+".role must be author, editor, translator or illustrator")
+		v.check(!seen[credit{c.AuthorID, c.Role}], field, "unique", fmt.Sprintf("author %d is credited as %s more than once", c.AuthorID, c.Role))
+		seen[credit{c.AuthorID, c.Role}] = true
+	}
+
+	primary := entities.PrimaryAuthorID(book.Contributors)
+	v.check(book.AuthorID == 0 || book.AuthorID == primary, "author_id", "mismatch",
+		"author_id must be the first contributor with the author role")
+	book.AuthorID = primary
+}
+
+// labels returns the genre or tag slugs slugified, sorted and without
+// duplicates, checking each one.
+func (v *validator) labels(labels []string, field string) []string {
+	var out []string
+	for i, label := range labels {
+		slug := slugify(label)
+		f := fmt.Sprintf("%s[%d]", field, i)
+		v.check(slug != "", f, "required", f+" must contain a letter or digit")
+		v.maxLength(slug, f, maxLabelLength)
+		if slug != "" {
+			out = append(out, slug)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// slugify lowercases s and joins its runs of letters and digits with
+// hyphens, so that "Science Fiction" becomes "science-fiction".
+func slugify(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+// isSlug reports whether s is a slug as slugify produces them, from names
+// in any script: runs of lowercase letters and digits joined by single
+// hyphens.
+func isSlug(s string) bool {
+	return s != "" && slugify(s) == s
+}
+
+// validateGenre checks the genre's fields. A missing slug is derived from
+// the name.
+func validateGenre(genre *entities.Genre) *validator {
+	v := &validator{}
+	v.required(genre.Name, "name")
+	v.maxLength(genre.Name, "name", maxNameLength)
+	if genre.Slug == "" {
+		genre.Slug = slugify(genre.Name)
+	}
+	v.check(isSlug(genre.Slug), "slug", "format",
+		"slug must be lowercase letters and digits separated by single hyphens")
+	v.maxLength(genre.Slug, "slug", maxLabelLength)
+	v.check(genre.ParentID == nil || *genre.ParentID > 0, "parent_id", "min", "parent_id must be positive")
+	v.check(genre.ParentID == nil || genre.ID == 0 || *genre.ParentID != genre.ID, "parent_id", "not_self",
+		"a genre cannot be its own parent")
+	return v
+}
+
+// validateTag checks the tag's name, which is slugified like the tags
+// sent with books.
+func validateTag(tag *entities.Tag) *validator {
+	v := &validator{}
+	tag.Name = slugify(tag.Name)
+	v.required(tag.Name, "name")
+	v.maxLength(tag.Name, "name", maxLabelLength)
+	return v
+}
+
+func (v *validator) bookISBN(book *entities.Book) {
+	var isbn13 string
+	for _, f := range []struct{ field, value string }{{"isbn_13", book.ISBN13}, {"isbn_10", book.ISBN10}} {
+		if f.value == "" {
+			continue
+		}
+		n, err := isbn.Normalize(f.value)
+		v.check(err == nil, f.field, "format", f.field+" must be a valid ISBN")
+		if err != nil {
+			return
+		}
+		v.check(isbn13 == "" || n == isbn13, f.field, "mismatch", "isbn_10 and isbn_13 must denote the same book")
+		isbn13 = n
+	}
+	book.ISBN13 = isbn13
+	book.ISBN10, _ = isbn.ToISBN10(isbn13)
+}
+
+// normalizeISBN returns s as an ISBN-13, or a validation error for the isbn
+// field when it is not a valid ISBN.
+func normalizeISBN(s string) (string, error) {
+	n, err := isbn.Normalize(s)
+	v := &validator{}
+	v.check(err == nil, "isbn", "format", "isbn must be a valid ISBN-10 or ISBN-13")
+	return n, v.err()
+}
+
+// languageCode matches the ISO 639-1 codes Google Books restricts by.
+var languageCode = regexp.MustCompile(`^[a-z]{2}$`)
+
+func validateGoogleQuery(query entities.GoogleBookQuery) *validator {
+	v := &validator{}
+	v.check(strings.TrimSpace(query.Query+query.Title+query.Author+query.ISBN) != "", "q", "required",
+		"one of q, title, author or isbn is required")
+	v.check(query.ISBN == "" || isbn.Valid(query.ISBN), "isbn", "format", "isbn must be a valid ISBN-10 or ISBN-13")
+	v.check(query.LangRestrict == "" || languageCode.MatchString(query.LangRestrict), "langRestrict", "format",
+		"langRestrict must be a two-letter ISO 639-1 code")
+	v.check(query.OrderBy == "" || query.OrderBy.Valid(), "orderBy", "one_of", "orderBy must be relevance or newest")
+	return v
+}
+
+func validateBookSearch(query entities.BookSearchQuery) *validator {
+	v := &validator{}
+	v.required(query.Query, "q")
+	v.maxLength(query.Query, "q", maxSearchLength)
+	return v
+}
+
+// validateAuthor checks the author's fields. The birthdate is optional, as
+// authors created by an import have none.
+func validateAuthor(author *entities.Author, now time.Time) *validator {
+	v := &validator{}
+	v.required(author.Name, "name")
+	v.maxLength(author.Name, "name", maxNameLength)
+	v.maxLength(author.Bio, "bio", maxBioLength)
+	v.check(!author.BirthDate.After(now), "birthdate", "not_future", "birthdate must not be in the future")
+	return v
+}
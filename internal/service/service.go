@@ -5,22 +5,77 @@ import (
 	"context"
 
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
 )
 
 type BookService interface {
-	GetAllBooks(ctx context.Context) ([]*entities.Book, error)
+	GetAllBooks(ctx context.Context, query entities.BookQuery) (*entities.BookPage, error)
+	// SearchBooks runs a full-text search over the local catalog.
+	SearchBooks(ctx context.Context, query entities.BookSearchQuery) (*entities.BookSearchPage, error)
 	GetBookByID(ctx context.Context, id int) (*entities.Book, error)
+	// GetBookByISBN returns the local book with the given ISBN-10 or ISBN-13.
+	GetBookByISBN(ctx context.Context, isbn string) (*entities.Book, error)
+	// LookupExternalISBN looks the ISBN up in the external catalogs.
+	LookupExternalISBN(ctx context.Context, isbn string) (*entities.ExternalBook, error)
 	AddBook(ctx context.Context, book *entities.Book) error
+	// UpdateBook and RemoveBook fail with storage.ErrVersionMismatch when
+	// a non-zero book.Version or version is not the stored one.
 	UpdateBook(ctx context.Context, book *entities.Book) error
-	RemoveBook(ctx context.Context, id int) error
-	SearchGoogleBooks(ctx context.Context, title string) ([]entities.GoogleBook, error)
+	// PatchBook applies a merge patch or JSON Patch to the book atomically
+	// and returns the result.
+	PatchBook(ctx context.Context, id, version int, patch jsonpatch.Patch) (*entities.Book, error)
+	// RemoveBook soft-deletes the book; RestoreBook undoes that until the
+	// book is purged.
+	RemoveBook(ctx context.Context, id, version int) error
+	RestoreBook(ctx context.Context, id, version int) (*entities.Book, error)
+	// GetBookHistory returns the recorded revisions of the book, newest
+	// first. RevertBook takes the book back to one of them.
+	GetBookHistory(ctx context.Context, id int) ([]*entities.Revision, error)
+	RevertBook(ctx context.Context, id, revision, version int) (*entities.Book, error)
+	SearchGoogleBooks(ctx context.Context, query entities.GoogleBookQuery) (*entities.GoogleBookPage, error)
+	// ImportGoogleBook stores a Google Books volume as a local book and
+	// reports whether it was created rather than updated.
+	ImportGoogleBook(ctx context.Context, volumeID string) (*entities.Book, bool, error)
+	// SearchExternalBooks searches external catalogs, all configured ones
+	// when providers is empty, and merges their results by ISBN.
+	SearchExternalBooks(ctx context.Context, query string, providers []string) (*entities.ExternalSearchResult, error)
 }
 
 type AuthorService interface {
+	GetAllAuthors(ctx context.Context) ([]*entities.Author, error)
 	GetAuthorByID(ctx context.Context, id int) (*entities.Author, error)
+	GetAuthorWithBooks(ctx context.Context, id int) (*entities.AuthorWithBooks, error)
+	GetAuthorBooks(ctx context.Context, id int) ([]*entities.Book, error)
 	RegisterAuthor(ctx context.Context, author *entities.Author) error
+	// UpdateAuthor and RemoveAuthor check a non-zero version like the
+	// BookService.
+	UpdateAuthor(ctx context.Context, author *entities.Author) error
+	PatchAuthor(ctx context.Context, id, version int, patch jsonpatch.Patch) (*entities.Author, error)
+	RemoveAuthor(ctx context.Context, id, version int) error
+	RestoreAuthor(ctx context.Context, id, version int) (*entities.Author, error)
+	GetAuthorHistory(ctx context.Context, id int) ([]*entities.Revision, error)
+	RevertAuthor(ctx context.Context, id, revision, version int) (*entities.Author, error)
 }
+
+type GenreService interface {
+	GetAllGenres(ctx context.Context) ([]*entities.Genre, error)
+	GetGenreByID(ctx context.Context, id int) (*entities.Genre, error)
+	AddGenre(ctx context.Context, genre *entities.Genre) error
+	UpdateGenre(ctx context.Context, genre *entities.Genre) error
+	RemoveGenre(ctx context.Context, id int) error
+}
+
+type TagService interface {
+	GetAllTags(ctx context.Context) ([]*entities.Tag, error)
+	GetTagByID(ctx context.Context, id int) (*entities.Tag, error)
+	AddTag(ctx context.Context, tag *entities.Tag) error
+	UpdateTag(ctx context.Context, tag *entities.Tag) error
+	RemoveTag(ctx context.Context, id int) error
+}
+
 type Service struct {
 	Book   BookService
 	Author AuthorService
+	Genre  GenreService
+	Tag    TagService
 }
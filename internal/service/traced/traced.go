@@ -0,0 +1,278 @@
+// Package traced wraps the services so that every call is recorded as an
+// OpenTelemetry span named after the service method.
+package traced
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/jsonpatch"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service"
+)
+
+const tracerName = "github.com/demirbalemir/hop/Onboardingv2/internal/service"
+
+// end records err on the span, if any, and ends it.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type BookService struct {
+	next   service.BookService
+	tracer trace.Tracer
+}
+
+func NewBookService(next service.BookService) *BookService {
+	return &BookService{next: next, tracer: otel.Tracer(tracerName)}
+}
+
+func (s *BookService) GetAllBooks(ctx context.Context, query entities.BookQuery) (page *entities.BookPage, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.GetAllBooks")
+	defer func() { end(span, err) }()
+	return s.next.GetAllBooks(ctx, query)
+}
+
+func (s *BookService) SearchBooks(ctx context.Context, query entities.BookSearchQuery) (page *entities.BookSearchPage, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.SearchBooks")
+	defer func() { end(span, err) }()
+	return s.next.SearchBooks(ctx, query)
+}
+
+func (s *BookService) GetBookByID(ctx context.Context, id int) (book *entities.Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.GetBookByID")
+	defer func() { end(span, err) }()
+	return s.next.GetBookByID(ctx, id)
+}
+
+func (s *BookService) GetBookByISBN(ctx context.Context, isbn string) (book *entities.Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.GetBookByISBN")
+	defer func() { end(span, err) }()
+	return s.next.GetBookByISBN(ctx, isbn)
+}
+
+func (s *BookService) LookupExternalISBN(ctx context.Context, isbn string) (book *entities.ExternalBook, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.LookupExternalISBN")
+	defer func() { end(span, err) }()
+	return s.next.LookupExternalISBN(ctx, isbn)
+}
+
+func (s *BookService) AddBook(ctx context.Context, book *entities.Book) (err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.AddBook")
+	defer func() { end(span, err) }()
+	return s.next.AddBook(ctx, book)
+}
+
+func (s *BookService) UpdateBook(ctx context.Context, book *entities.Book) (err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.UpdateBook")
+	defer func() { end(span, err) }()
+	return s.next.UpdateBook(ctx, book)
+}
+
+func (s *BookService) PatchBook(ctx context.Context, id, version int, patch jsonpatch.Patch) (book *entities.Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.PatchBook")
+	defer func() { end(span, err) }()
+	return s.next.PatchBook(ctx, id, version, patch)
+}
+
+func (s *BookService) RemoveBook(ctx context.Context, id, version int) (err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.RemoveBook")
+	defer func() { end(span, err) }()
+	return s.next.RemoveBook(ctx, id, version)
+}
+
+func (s *BookService) RestoreBook(ctx context.Context, id, version int) (book *entities.Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.RestoreBook")
+	defer func() { end(span, err) }()
+	return s.next.RestoreBook(ctx, id, version)
+}
+
+func (s *BookService) GetBookHistory(ctx context.Context, id int) (history []*entities.Revision, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.GetBookHistory")
+	defer func() { end(span, err) }()
+	return s.next.GetBookHistory(ctx, id)
+}
+
+func (s *BookService) RevertBook(ctx context.Context, id, revision, version int) (book *entities.Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.RevertBook")
+	defer func() { end(span, err) }()
+	return s.next.RevertBook(ctx, id, revision, version)
+}
+
+func (s *BookService) SearchGoogleBooks(ctx context.Context, query entities.GoogleBookQuery) (page *entities.GoogleBookPage, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.SearchGoogleBooks")
+	defer func() { end(span, err) }()
+	return s.next.SearchGoogleBooks(ctx, query)
+}
+
+func (s *BookService) ImportGoogleBook(ctx context.Context, volumeID string) (book *entities.Book, created bool, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.ImportGoogleBook")
+	defer func() { end(span, err) }()
+	return s.next.ImportGoogleBook(ctx, volumeID)
+}
+
+func (s *BookService) SearchExternalBooks(ctx context.Context, query string, providers []string) (result *entities.ExternalSearchResult, err error) {
+	ctx, span := s.tracer.Start(ctx, "BookService.SearchExternalBooks")
+	defer func() { end(span, err) }()
+	return s.next.SearchExternalBooks(ctx, query, providers)
+}
+
+type AuthorService struct {
+	next   service.AuthorService
+	tracer trace.Tracer
+}
+
+func NewAuthorService(next service.AuthorService) *AuthorService {
+	return &AuthorService{next: next, tracer: otel.Tracer(tracerName)}
+}
+
+func (s *AuthorService) GetAllAuthors(ctx context.Context) (authors []*entities.Author, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.GetAllAuthors")
+	defer func() { end(span, err) }()
+	return s.next.GetAllAuthors(ctx)
+}
+
+func (s *AuthorService) GetAuthorByID(ctx context.Context, id int) (author *entities.Author, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.GetAuthorByID")
+	defer func() { end(span, err) }()
+	return s.next.GetAuthorByID(ctx, id)
+}
+
+func (s *AuthorService) GetAuthorWithBooks(ctx context.Context, id int) (author *entities.AuthorWithBooks, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.GetAuthorWithBooks")
+	defer func() { end(span, err) }()
+	return s.next.GetAuthorWithBooks(ctx, id)
+}
+
+func (s *AuthorService) GetAuthorBooks(ctx context.Context, id int) (books []*entities.Book, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.GetAuthorBooks")
+	defer func() { end(span, err) }()
+	return s.next.GetAuthorBooks(ctx, id)
+}
+
+func (s *AuthorService) RegisterAuthor(ctx context.Context, author *entities.Author) (err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.RegisterAuthor")
+	defer func() { end(span, err) }()
+	return s.next.RegisterAuthor(ctx, author)
+}
+
+func (s *AuthorService) UpdateAuthor(ctx context.Context, author *entities.Author) (err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.UpdateAuthor")
+	defer func() { end(span, err) }()
+	return s.next.UpdateAuthor(ctx, author)
+}
+
+func (s *AuthorService) PatchAuthor(ctx context.Context, id, version int, patch jsonpatch.Patch) (author *entities.Author, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.PatchAuthor")
+	defer func() { end(span, err) }()
+	return s.next.PatchAuthor(ctx, id, version, patch)
+}
+
+func (s *AuthorService) RemoveAuthor(ctx context.Context, id, version int) (err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.RemoveAuthor")
+	defer func() { end(span, err) }()
+	return s.next.RemoveAuthor(ctx, id, version)
+}
+
+func (s *AuthorService) RestoreAuthor(ctx context.Context, id, version int) (author *entities.Author, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.RestoreAuthor")
+	defer func() { end(span, err) }()
+	return s.next.RestoreAuthor(ctx, id, version)
+}
+
+func (s *AuthorService) GetAuthorHistory(ctx context.Context, id int) (history []*entities.Revision, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.GetAuthorHistory")
+	defer func() { end(span, err) }()
+	return s.next.GetAuthorHistory(ctx, id)
+}
+
+func (s *AuthorService) RevertAuthor(ctx context.Context, id, revision, version int) (author *entities.Author, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuthorService.RevertAuthor")
+	defer func() { end(span, err) }()
+	return s.next.RevertAuthor(ctx, id, revision, version)
+}
+
+type GenreService struct {
+	next   service.GenreService
+	tracer trace.Tracer
+}
+
+func NewGenreService(next service.GenreService) *GenreService {
+	return &GenreService{next: next, tracer: otel.Tracer(tracerName)}
+}
+
+func (s *GenreService) GetAllGenres(ctx context.Context) (genres []*entities.Genre, err error) {
+	ctx, span := s.tracer.Start(ctx, "GenreService.GetAllGenres")
+	defer func() { end(span, err) }()
+	return s.next.GetAllGenres(ctx)
+}
+
+func (s *GenreService) GetGenreByID(ctx context.Context, id int) (genre *entities.Genre, err error) {
+	ctx, span := s.tracer.Start(ctx, "GenreService.GetGenreByID")
+	defer func() { end(span, err) }()
+	return s.next.GetGenreByID(ctx, id)
+}
+
+func (s *GenreService) AddGenre(ctx context.Context, genre *entities.Genre) (err error) {
+	ctx, span := s.tracer.Start(ctx, "GenreService.AddGenre")
+	defer func() { end(span, err) }()
+	return s.next.AddGenre(ctx, genre)
+}
+
+func (s *GenreService) UpdateGenre(ctx context.Context, genre *entities.Genre) (err error) {
+	ctx, span := s.tracer.Start(ctx, "GenreService.UpdateGenre")
+	defer func() { end(span, err) }()
+	return s.next.UpdateGenre(ctx, genre)
+}
+
+func (s *GenreService) RemoveGenre(ctx context.Context, id int) (err error) {
+	ctx, span := s.tracer.Start(ctx, "GenreService.RemoveGenre")
+	defer func() { end(span, err) }()
+	return s.next.RemoveGenre(ctx, id)
+}
+
+type TagService struct {
+	next   service.TagService
+	tracer trace.Tracer
+}
+
+func NewTagService(next service.TagService) *TagService {
+	return &TagService{next: next, tracer: otel.Tracer(tracerName)}
+}
+
+func (s *TagService) GetAllTags(ctx context.Context) (tags []*entities.Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.GetAllTags")
+	defer func() { end(span, err) }()
+	return s.next.GetAllTags(ctx)
+}
+
+func (s *TagService) GetTagByID(ctx context.Context, id int) (tag *entities.Tag, err error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.GetTagByID")
+	defer func() { end(span, err) }()
+	return s.next.GetTagByID(ctx, id)
+}
+
+func (s *TagService) AddTag(ctx context.Context, tag *entities.Tag) (err error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.AddTag")
+	defer func() { end(span, err) }()
+	return s.next.AddTag(ctx, tag)
+}
+
+func (s *TagService) UpdateTag(ctx context.Context, tag *entities.Tag) (err error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.UpdateTag")
+	defer func() { end(span, err) }()
+	return s.next.UpdateTag(ctx, tag)
+}
+
+func (s *TagService) RemoveTag(ctx context.Context, id int) (err error) {
+	ctx, span := s.tracer.Start(ctx, "TagService.RemoveTag")
+	defer func() { end(span, err) }()
+	return s.next.RemoveTag(ctx, id)
+}
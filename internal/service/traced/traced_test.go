@@ -0,0 +1,45 @@
+package traced
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/service/domain"
+)
+
+func TestBookService_RecordsSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	next := new(domain.MockBookService)
+	// The wrapped service runs inside the span
+	next.On("GetBookByID", mock.MatchedBy(func(ctx context.Context) bool {
+		return trace.SpanFromContext(ctx).SpanContext().IsValid()
+	}), 1).Return(&entities.Book{ID: 1}, nil)
+	next.On("RemoveBook", mock.Anything, 2, 0).Return(apperr.NotFound("book with ID 2 not found"))
+
+	svc := &BookService{next: next, tracer: tracer}
+
+	book, err := svc.GetBookByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, book.ID)
+	assert.Error(t, svc.RemoveBook(context.Background(), 2, 0))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "BookService.GetBookByID", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "BookService.RemoveBook", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	next.AssertExpectations(t)
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+// unaudited lists the JSON fields left out of the recorded changes: those
+// the repositories maintain themselves, and the ISBN-10, which is derived
+// from the ISBN-13.
+var unaudited = []string{"id", "version", "created_at", "updated_at", "deleted_at", "isbn_10"}
+
+// audit records a write of the entity with the given ID in the audit log.
+// It runs in the transaction of the write, so that both or neither are
+// stored. revision is the version the write left the entity at; before
+// and after are the entity around the write, nil when there is none, and
+// only the fields in which their JSON forms differ are recorded. The
+// action can be overridden with storage.WithAuditAction and the actor is
+// taken from ctx.
+func audit(ctx context.Context, tx PgxIface, entity string, id, revision int, action entities.AuditAction, before, after any) error {
+	changes, err := diff(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to diff %s with ID %d: %w", entity, id, err)
+	}
+
+	query := `
+	INSERT INTO audit_log (entity, entity_id, revision, action, actor, changes)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	action = storage.AuditActionOf(ctx, action)
+	_, err = tx.Exec(withQueryName(ctx, "audit_log.insert"), query, entity, id, revision, string(action), auth.Actor(ctx), changes)
+	return err
+}
+
+// diff returns the JSON object mapping the fields in which before and
+// after differ to their values on either side.
+func diff(before, after any) ([]byte, error) {
+	from, err := auditedFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := auditedFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]entities.FieldChange)
+	for name, value := range to {
+		if !sameValue(from[name], value) {
+			changes[name] = entities.FieldChange{Before: from[name], After: value}
+		}
+	}
+	for name, value := range from {
+		if _, ok := to[name]; !ok && !sameValue(value, nil) {
+			changes[name] = entities.FieldChange{Before: value}
+		}
+	}
+	return json.Marshal(changes)
+}
+
+// sameValue reports whether two JSON values are the same. A missing value
+// is the same as null.
+func sameValue(a, b json.RawMessage) bool {
+	if a == nil {
+		a = json.RawMessage("null")
+	}
+	if b == nil {
+		b = json.RawMessage("null")
+	}
+	return bytes.Equal(a, b)
+}
+
+// auditedFields returns the JSON fields of v but the unaudited ones.
+func auditedFields(v any) (map[string]json.RawMessage, error) {
+	fields := make(map[string]json.RawMessage)
+	if v == nil {
+		return fields, nil
+	}
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return nil, err
+	}
+	for name := range fields {
+		if slices.Contains(unaudited, name) {
+			delete(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+// auditedBook returns the book as the audit log records it: without the
+// names of its contributors, which are read from the authors rather than
+// written, and with empty rather than missing contributors, genres and
+// tags, like when it is read.
+func auditedBook(book *entities.Book) *entities.Book {
+	c := *book
+	c.Contributors = make([]entities.Contributor, len(book.Contributors))
+	for i, contributor := range book.Contributors {
+		contributor.Name = ""
+		c.Contributors[i] = contributor
+	}
+	c.Genres = append([]string{}, book.Genres...)
+	c.Tags = append([]string{}, book.Tags...)
+	return &c
+}
+
+// history returns the revisions of the entity with the given ID, newest
+// first.
+func (l queryLogger) history(ctx context.Context, db PgxIface, entity string, id int) ([]*entities.Revision, error) {
+	ctx = withQueryName(ctx, "audit_log.find_by_entity")
+
+	query := `
+	SELECT
+		entity,
+		entity_id,
+		revision,
+		action,
+		actor,
+		changed_at,
+		changes
+	FROM
+		audit_log
+	WHERE
+		entity = $1
+		AND entity_id = $2
+	ORDER BY id DESC
+	`
+
+	rows, err := db.Query(ctx, query, entity, id)
+	if err != nil {
+		return nil, l.queryError(ctx, fmt.Sprintf("failed to find history of %s with ID %d", entity, id), err)
+	}
+	defer rows.Close()
+
+	revisions := make([]*entities.Revision, 0)
+	for rows.Next() {
+		r := &entities.Revision{}
+		var action string
+		var changes []byte
+		if err := rows.Scan(&r.Entity, &r.EntityID, &r.Revision, &action, &r.Actor, &r.ChangedAt, &changes); err != nil {
+			return nil, fmt.Errorf("failed to scan revision row: %w", err)
+		}
+		r.Action = entities.AuditAction(action)
+		if err := json.Unmarshal(changes, &r.Changes); err != nil {
+			return nil, fmt.Errorf("failed to decode changes of %s with ID %d: %w", entity, id, err)
+		}
+		revisions = append(revisions, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, l.queryError(ctx, "error during rows iteration", err)
+	}
+	return revisions, nil
+}
@@ -0,0 +1,146 @@
+package postgres_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+// selectHistory matches the query reading the revisions of an entity.
+const selectHistory = `SELECT entity, entity_id, revision, action, actor, changed_at, changes FROM audit_log WHERE entity = \$1 AND entity_id = \$2 ORDER BY id DESC`
+
+var historyColumns = []string{"entity", "entity_id", "revision", "action", "actor", "changed_at", "changes"}
+
+func TestBookRepository_History(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the revisions newest first", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectQuery(selectHistory).
+			WithArgs("book", 3).
+			WillReturnRows(pgxmock.NewRows(historyColumns).
+				AddRow("book", 3, 2, "update", "admin", stamp.Add(time.Hour), []byte(`{"price":{"before":9.99,"after":12.5}}`)).
+				AddRow("book", 3, 1, "create", "anonymous", stamp, []byte(`{"title":{"before":null,"after":"Dune"}}`)))
+
+		history, err := repo.History(ctx, 3)
+
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		assert.Equal(t, 2, history[0].Revision)
+		assert.Equal(t, entities.AuditUpdate, history[0].Action)
+		assert.Equal(t, "admin", history[0].Actor)
+		assert.Equal(t, entities.FieldChange{Before: json.RawMessage(`9.99`), After: json.RawMessage(`12.5`)}, history[0].Changes["price"])
+		assert.Equal(t, entities.AuditCreate, history[1].Action)
+		assert.JSONEq(t, `null`, string(history[1].Changes["title"].Before))
+	})
+
+	t.Run("should return an empty history for a book without revisions", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectQuery(selectHistory).
+			WithArgs("book", 3).
+			WillReturnRows(pgxmock.NewRows(historyColumns))
+
+		history, err := repo.History(ctx, 3)
+
+		require.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	t.Run("should return error on database failure", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectQuery(selectHistory).
+			WithArgs("book", 3).
+			WillReturnError(errors.New("db error during history"))
+
+		_, err := repo.History(ctx, 3)
+
+		assert.ErrorContains(t, err, "failed to find history of book with ID 3")
+	})
+}
+
+func TestAuthorRepository_Create(t *testing.T) {
+	mockPool, repo, cleanup := setupMockAuthorRepo(t)
+	defer cleanup()
+
+	// The author and the record of their creation are written together
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(`INSERT INTO authors \(name, bio, birthdate\)`).
+		WithArgs("Ursula K. Le Guin", "", nil).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(8, 1, stamp, stamp))
+	mockPool.ExpectExec(insertAudit).
+		WithArgs("author", 8, 1, "create", auth.ActorAdmin, []byte(`{"bio":{"before":null,"after":""},"name":{"before":null,"after":"Ursula K. Le Guin"}}`)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+
+	author := &entities.Author{Name: "Ursula K. Le Guin"}
+	err := repo.Create(auth.WithAdmin(context.Background()), author)
+
+	require.NoError(t, err)
+	assert.Equal(t, 8, author.ID)
+}
+
+func TestAuthorRepository_PatchRecordsTheAuditAction(t *testing.T) {
+	mockPool, repo, cleanup := setupMockAuthorRepo(t)
+	defer cleanup()
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(selectAuthors + ` WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+		WithArgs(4).
+		WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Octavia E. Butler", "Science fiction writer", nil, 3, stamp, stamp, nil))
+	mockPool.ExpectQuery(`UPDATE authors SET bio = \$1`).
+		WithArgs("", 4).
+		WillReturnRows(pgxmock.NewRows([]string{"version", "updated_at"}).AddRow(4, stamp))
+	mockPool.ExpectExec(insertAudit).
+		WithArgs("author", 4, 4, "revert", auth.ActorAdmin, []byte(`{"bio":{"before":"Science fiction writer","after":""}}`)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectCommit()
+
+	ctx := storage.WithAuditAction(auth.WithAdmin(context.Background()), entities.AuditRevert)
+	_, err := repo.Patch(ctx, 4, 3, func(author *entities.Author) error {
+		author.Bio = ""
+		return nil
+	})
+
+	assert.NoError(t, err)
+}
+
+func TestAuthorRepository_RecordsTheActor(t *testing.T) {
+	mockPool, repo, cleanup := setupMockAuthorRepo(t)
+	defer cleanup()
+
+	// Two callers deleting authors are told apart in the audit log
+	for _, caller := range []struct {
+		id    int
+		actor string
+	}{{4, "alice"}, {5, "bob"}} {
+		id, actor := caller.id, caller.actor
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(`UPDATE authors SET deleted_at = now\(\)`).
+			WithArgs(id, 0).
+			WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(2))
+		mockPool.ExpectExec(insertAudit).
+			WithArgs("author", id, 2, "delete", actor, []byte(`{}`)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mockPool.ExpectCommit()
+
+		err := repo.Delete(auth.WithActor(context.Background(), actor), id, 0)
+
+		require.NoError(t, err)
+	}
+}
@@ -2,66 +2,456 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Author struct {
 	db PgxIface
+	queryLogger
 }
 
-func NewAuthorRepository(pool PgxIface) *Author {
-	return &Author{db: pool}
+func NewAuthorRepository(pool PgxIface, logger *slog.Logger) *Author {
+	return &Author{db: pool, queryLogger: newQueryLogger(logger)}
+}
+
+// authorColumns lists the author columns read by scanAuthor, in order.
+const authorColumns = `
+			id,
+			name,
+			bio,
+			birthdate,
+			version,
+			created_at,
+			updated_at,
+			deleted_at`
+
+// scanAuthor reads an author selected with authorColumns. A NULL birthdate
+// (authors created by an import) becomes the zero time.
+func scanAuthor(row pgx.Row) (*entities.Author, error) {
+	author := &entities.Author{}
+	var birthDate pgtype.Date
+	if err := row.Scan(&author.ID, &author.Name, &author.Bio, &birthDate, &author.Version, &author.CreatedAt, &author.UpdatedAt, &author.DeletedAt); err != nil {
+		return nil, err
+	}
+	if birthDate.Valid {
+		author.BirthDate = birthDate.Time
+	}
+	return author, nil
+}
+
+// nullableDate stores the zero time as NULL.
+func nullableDate(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
 }
 
 func (a *Author) FindByID(ctx context.Context, id int) (*entities.Author, error) {
+	ctx = withQueryName(ctx, "authors.find_by_id")
 
 	query :=
 		`
-		SELECT
-			id,
-			name,
-			bio,
-			birthdate
+		SELECT ` + authorColumns + `
 		FROM
 			authors
 		WHERE
 			id = $1 -- Use $1 for the first parameter in pgx
+			AND ($2 OR deleted_at IS NULL)
 	`
-	author := &entities.Author{}
-	err := a.db.QueryRow(ctx, query, id).Scan(
-		&author.ID,
-		&author.Name,
-		&author.Bio,
-		&author.BirthDate,
-	)
+	author, err := scanAuthor(a.db.QueryRow(ctx, query, id, storage.IncludesDeleted(ctx)))
 	if err != nil {
-		if err == pgx.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			// If no row was found, return nil for the author and a specific error
-			return nil, fmt.Errorf("author with ID %d not found: %w", id, err)
+			return nil, apperr.NotFound(fmt.Sprintf("author with ID %d not found", id))
 		}
 		// For any other database error
-		return nil, fmt.Errorf("failed to find author by ID %d: %w", id, err)
+		return nil, a.queryError(ctx, fmt.Sprintf("failed to find author by ID %d", id), err)
 	}
 	return author, nil
 }
+
+// findByName selects the oldest author that is not deleted whose name
+// matches $1, ignoring case.
+const findByName = `
+	SELECT ` + authorColumns + `
+	FROM
+		authors
+	WHERE
+		lower(name) = lower($1)
+		AND deleted_at IS NULL
+	ORDER BY id
+	LIMIT 1
+`
+
+// FindOrCreateByName returns the author whose name matches name, ignoring
+// case, or creates one with only that name, and reports whether it was
+// created. When several authors share the name the oldest one is returned.
+// Calls for the same name wait for each other, so concurrent imports of
+// books by a new author create the author once.
+func (a *Author) FindOrCreateByName(ctx context.Context, name string) (*entities.Author, bool, error) {
+	ctx = withQueryName(ctx, "authors.find_or_create_by_name")
+
+	var author *entities.Author
+	var created bool
+	err := inTx(ctx, a.db, func(tx PgxIface) error {
+		// The lock is held until the transaction ends, so a concurrent call
+		// for the name finds the author created here
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('authors.name'), hashtext(lower($1)))`, name); err != nil {
+			return err
+		}
+		var err error
+		author, err = scanAuthor(tx.QueryRow(ctx, findByName, name))
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		author, created = &entities.Author{Name: name}, true
+		return createAuthor(ctx, tx, author)
+	})
+	if err != nil {
+		return nil, false, a.queryError(ctx, fmt.Sprintf("failed to find or create author named %q", name), err)
+	}
+	return author, created, nil
+}
+
 func (a *Author) Create(ctx context.Context, author *entities.Author) error {
+	ctx = withQueryName(ctx, "authors.create")
+
+	err := inTx(ctx, a.db, func(tx PgxIface) error {
+		return createAuthor(ctx, tx, author)
+	})
+	if err != nil {
+		author.ID, author.Version = 0, 0
+		return a.queryError(ctx, "failed to create author", err)
+	}
+
+	// If successful, the author.ID field of the passed-in struct
+	// will now be populated with the new ID from the database.
+	return nil // No error
+}
+
+// createAuthor inserts the author in tx, sets its ID, version and
+// timestamps, and records its creation.
+func createAuthor(ctx context.Context, tx PgxIface, author *entities.Author) error {
 	query := `
 		INSERT INTO authors (name, bio, birthdate)
 		VALUES ($1, $2, $3)
-		RETURNING id -- This returns the auto-generated ID
+		RETURNING id, version, created_at, updated_at -- This returns the auto-generated ID
 	`
 
 	// Use QueryRow because we expect to return the generated ID
-	// Pass the fields of the 'author' struct as parameters to the query
-	err := a.db.QueryRow(ctx, query, author.Name, author.Bio, author.BirthDate).Scan(&author.ID)
+	err := tx.QueryRow(ctx, query, author.Name, author.Bio, nullableDate(author.BirthDate)).
+		Scan(&author.ID, &author.Version, &author.CreatedAt, &author.UpdatedAt)
 	if err != nil {
-		return fmt.Errorf("failed to create author: %w", err)
+		return err
 	}
+	return audit(ctx, tx, "author", author.ID, author.Version, entities.AuditCreate, nil, author)
+}
 
-	// If successful, the author.ID field of the passed-in struct
-	// will now be populated with the new ID from the database.
-	return nil // No error
+func (a *Author) FindAll(ctx context.Context) ([]*entities.Author, error) {
+	ctx = withQueryName(ctx, "authors.find_all")
+
+	query := `
+	SELECT ` + authorColumns + `
+	FROM
+		authors
+	WHERE
+		$1 OR deleted_at IS NULL
+	ORDER BY name, id
+	`
+
+	rows, err := a.db.Query(ctx, query, storage.IncludesDeleted(ctx))
+	if err != nil {
+		return nil, a.queryError(ctx, "failed to execute query", err)
+	}
+	defer rows.Close()
+
+	authors := make([]*entities.Author, 0)
+	for rows.Next() {
+		author, err := scanAuthor(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan author row: %w", err)
+		}
+		authors = append(authors, author)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, a.queryError(ctx, "error during rows iteration", err)
+	}
+
+	return authors, nil
+}
+
+// Update modifies an existing author's details in the database and bumps
+// their version. It uses author.ID to identify the record to update; a
+// non-zero author.Version must match the stored one, otherwise the
+// conflict wraps storage.ErrVersionMismatch.
+func (a *Author) Update(ctx context.Context, author *entities.Author) error {
+	ctx = withQueryName(ctx, "authors.update")
+
+	query := `
+		UPDATE authors
+		SET
+			name = $1,
+			bio = $2,
+			birthdate = $3,
+			version = version + 1,
+			updated_at = now()
+		WHERE
+			id = $4
+			AND deleted_at IS NULL
+			AND ($5 = 0 OR version = $5)
+		RETURNING version, updated_at
+	`
+
+	var found bool
+	err := inTx(ctx, a.db, func(tx PgxIface) error {
+		// The stored author is locked and read for the audit log
+		before, err := lockAuthor(ctx, tx, author.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, query, author.Name, author.Bio, nullableDate(author.BirthDate), author.ID, author.Version).
+			Scan(&author.Version, &author.UpdatedAt)
+		// No row means the author is at another version
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		return audit(ctx, tx, "author", author.ID, author.Version, entities.AuditUpdate, before, author)
+	})
+	if err != nil {
+		return a.queryError(ctx, fmt.Sprintf("failed to update author with ID %d", author.ID), err)
+	}
+	if !found {
+		return a.versionError(ctx, a.db, "authors", "author", author.ID, author.Version, "update")
+	}
+
+	return nil
+}
+
+// Patch locks the author, passes them to apply and writes back the
+// columns apply changed, bumping the version and recording the change
+// unless nothing changed. apply cannot change the ID, version or
+// timestamps. Deleted authors cannot be patched.
+func (a *Author) Patch(ctx context.Context, id, version int, apply func(author *entities.Author) error) (*entities.Author, error) {
+	ctx = withQueryName(ctx, "authors.patch")
+
+	var author *entities.Author
+	// patchErr is returned as it is: it was classified where it happened
+	var patchErr error
+	err := inTx(ctx, a.db, func(tx PgxIface) error {
+		current, err := lockAuthor(ctx, tx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			patchErr = apperr.NotFound(fmt.Sprintf("author with ID %d not found for patch", id))
+			return patchErr
+		}
+		if err != nil {
+			return err
+		}
+		if patchErr = checkVersion("author", id, current.Version, version); patchErr != nil {
+			return patchErr
+		}
+
+		copied := *current
+		author = &copied
+		if patchErr = apply(author); patchErr != nil {
+			return patchErr
+		}
+		author.ID, author.Version = current.ID, current.Version
+		author.CreatedAt, author.UpdatedAt, author.DeletedAt = current.CreatedAt, current.UpdatedAt, current.DeletedAt
+
+		c := &columnChanges{}
+		c.add(author.Name != current.Name, "name = ?", author.Name)
+		c.add(author.Bio != current.Bio, "bio = ?", author.Bio)
+		c.add(!author.BirthDate.Equal(current.BirthDate), "birthdate = ?", nullableDate(author.BirthDate))
+		if len(c.set) == 0 {
+			return nil
+		}
+		query, args := c.update("authors", id)
+		if err := tx.QueryRow(ctx, query, args...).Scan(&author.Version, &author.UpdatedAt); err != nil {
+			return err
+		}
+		return audit(ctx, tx, "author", id, author.Version, entities.AuditUpdate, current, author)
+	})
+	if patchErr != nil {
+		return nil, patchErr
+	}
+	if err != nil {
+		return nil, a.queryError(ctx, fmt.Sprintf("failed to patch author with ID %d", id), err)
+	}
+
+	return author, nil
+}
+
+// Delete soft-deletes an author and bumps their version. Authors who
+// are still credited on a book that is not deleted are rejected with
+// storage.ErrAuthorHasBooks; their books must be deleted or reassigned
+// first. A non-zero version must match the stored one.
+func (a *Author) Delete(ctx context.Context, id, version int) error {
+	ctx = withQueryName(ctx, "authors.delete")
+
+	query := `
+		UPDATE authors
+		SET
+			deleted_at = now(),
+			updated_at = now(),
+			version = version + 1
+		WHERE
+			id = $1
+			AND deleted_at IS NULL
+			AND ($2 = 0 OR version = $2)
+			AND NOT EXISTS (` + creditedBooks + `)
+		RETURNING version
+	`
+
+	// revision stays 0 when no author was deleted
+	var revision int
+	err := inTx(ctx, a.db, func(tx PgxIface) error {
+		err := tx.QueryRow(ctx, query, id, version).Scan(&revision)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return audit(ctx, tx, "author", id, revision, entities.AuditDelete, nil, nil)
+	})
+	if err != nil {
+		return a.queryError(ctx, fmt.Sprintf("failed to delete author with ID %d", id), err)
+	}
+
+	if revision == 0 {
+		var hasBooks bool
+		err := a.db.QueryRow(withQueryName(ctx, "authors.has_books"), `SELECT EXISTS (`+creditedBooks+`)`, id).Scan(&hasBooks)
+		if err != nil {
+			return a.queryError(ctx, fmt.Sprintf("failed to find books of author with ID %d", id), err)
+		}
+		if hasBooks {
+			return apperr.Conflict(fmt.Sprintf("author with ID %d still has books", id), storage.ErrAuthorHasBooks)
+		}
+		return a.versionError(ctx, a.db, "authors", "author", id, version, "delete")
+	}
+
+	return nil
+}
+
+// creditedBooks selects the books that are not deleted and credit the
+// author $1, as primary author or contributor.
+const creditedBooks = `
+	SELECT 1
+	FROM books b
+	WHERE b.deleted_at IS NULL
+		AND (b.author_id = $1 OR EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id AND ba.author_id = $1))`
+
+// Restore undoes the soft delete of an author like the book repository's
+// Restore.
+func (a *Author) Restore(ctx context.Context, id, version int) (*entities.Author, error) {
+	ctx = withQueryName(ctx, "authors.restore")
+
+	query := `
+		UPDATE authors
+		SET
+			deleted_at = NULL,
+			updated_at = now(),
+			version = version + 1
+		WHERE
+			id = $1
+			AND deleted_at IS NOT NULL
+			AND ($2 = 0 OR version = $2)
+		RETURNING version
+	`
+
+	// revision stays 0 when no author was restored
+	var revision int
+	err := inTx(ctx, a.db, func(tx PgxIface) error {
+		err := tx.QueryRow(ctx, query, id, version).Scan(&revision)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return audit(ctx, tx, "author", id, revision, entities.AuditRestore, nil, nil)
+	})
+	if err != nil {
+		return nil, a.queryError(ctx, fmt.Sprintf("failed to restore author with ID %d", id), err)
+	}
+
+	author, err := a.FindByID(storage.WithDeleted(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if revision == 0 {
+		if err := checkVersion("author", id, author.Version, version); err != nil {
+			return nil, err
+		}
+	}
+	return author, nil
+}
+
+// Purge removes the authors deleted before deletedBefore for good, except
+// those still credited on a book, even a deleted one: they are purged
+// once their books are. Their history is kept like the books'.
+func (a *Author) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ctx = withQueryName(ctx, "authors.purge")
+
+	query := `
+		WITH purged AS (
+			DELETE
+			FROM
+				authors
+			WHERE
+				deleted_at < $1
+				AND NOT EXISTS (SELECT 1 FROM books b WHERE b.author_id = authors.id)
+				AND NOT EXISTS (SELECT 1 FROM book_authors ba WHERE ba.author_id = authors.id)
+			RETURNING id, version
+		)
+		INSERT INTO audit_log (entity, entity_id, revision, action, actor)
+		SELECT 'author', id, version + 1, $2, $3 FROM purged
+	`
+
+	cmdTag, err := a.db.Exec(ctx, query, deletedBefore, string(entities.AuditPurge), auth.Actor(ctx))
+	if err != nil {
+		return 0, a.queryError(ctx, "failed to purge deleted authors", err)
+	}
+	return cmdTag.RowsAffected(), nil
+}
+
+// History returns the recorded revisions of the author, newest first,
+// even once they are purged.
+func (a *Author) History(ctx context.Context, id int) ([]*entities.Revision, error) {
+	return a.history(ctx, a.db, "author", id)
+}
+
+// lockAuthor reads the author who is not deleted and locks their row
+// until tx ends. A missing author is pgx.ErrNoRows.
+func lockAuthor(ctx context.Context, tx PgxIface, id int) (*entities.Author, error) {
+	query := `
+		SELECT ` + authorColumns + `
+		FROM
+			authors
+		WHERE
+			id = $1
+			AND deleted_at IS NULL
+		FOR UPDATE
+	`
+	return scanAuthor(tx.QueryRow(withQueryName(ctx, "authors.find_for_update"), query, id))
 }
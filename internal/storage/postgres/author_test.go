@@ -0,0 +1,549 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage/postgres"
+)
+
+// selectAuthors matches the start of a query reading every author column.
+const selectAuthors = `SELECT id, name, bio, birthdate, version, created_at, updated_at, deleted_at FROM authors`
+
+var authorColumns = []string{"id", "name", "bio", "birthdate", "version", "created_at", "updated_at", "deleted_at"}
+
+func setupMockAuthorRepo(t *testing.T) (pgxmock.PgxPoolIface, *postgres.Author, func()) {
+	mockPool, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	repo := postgres.NewAuthorRepository(mockPool, slog.New(slog.DiscardHandler))
+
+	cleanup := func() {
+		if err := mockPool.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+		mockPool.Close()
+	}
+
+	return mockPool, repo, cleanup
+}
+
+func TestAuthorRepository_FindAll(t *testing.T) {
+	ctx := context.Background()
+	mockPool, repo, cleanup := setupMockAuthorRepo(t)
+	defer cleanup()
+
+	birth := time.Date(1965, 7, 31, 0, 0, 0, 0, time.UTC)
+	rows := pgxmock.NewRows(authorColumns).
+		AddRow(1, "J.K. Rowling", "British author", birth, 1, stamp, stamp, nil).
+		AddRow(2, "Terry Pratchett", "Discworld", birth, 3, stamp, stamp, nil)
+	mockPool.ExpectQuery(selectAuthors + ` WHERE \$1 OR deleted_at IS NULL ORDER BY name, id`).
+		WithArgs(false).
+		WillReturnRows(rows)
+
+	authors, err := repo.FindAll(ctx)
+
+	assert.NoError(t, err)
+	assert.Len(t, authors, 2)
+	assert.Equal(t, "J.K. Rowling", authors[0].Name)
+	assert.Equal(t, 2, authors[1].ID)
+	assert.Equal(t, 3, authors[1].Version)
+}
+
+func TestAuthorRepository_FindOrCreateByName(t *testing.T) {
+	ctx := context.Background()
+	lockName := `SELECT pg_advisory_xact_lock\(hashtext\('authors.name'\), hashtext\(lower\(\$1\)\)\)`
+	findByName := selectAuthors + ` WHERE lower\(name\) = lower\(\$1\) AND deleted_at IS NULL ORDER BY id LIMIT 1`
+
+	t.Run("should return the author with the name", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockAuthorRepo(t)
+		defer cleanup()
+
+		// Imported authors have no birthdate
+		mockPool.ExpectBegin()
+		mockPool.ExpectExec(lockName).
+			WithArgs("frank herbert").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mockPool.ExpectQuery(findByName).
+			WithArgs("frank herbert").
+			WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Frank Herbert", "", nil, 1, stamp, stamp, nil))
+		mockPool.ExpectCommit()
+
+		author, created, err := repo.FindOrCreateByName(ctx, "frank herbert")
+
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, 4, author.ID)
+		assert.True(t, author.BirthDate.IsZero())
+	})
+
+	t.Run("should create a missing author under the lock on the name", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockAuthorRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectBegin()
+		mockPool.ExpectExec(lockName).
+			WithArgs("Frank Herbert").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mockPool.ExpectQuery(findByName).
+			WithArgs("Frank Herbert").
+			WillReturnError(pgx.ErrNoRows)
+		mockPool.ExpectQuery(`INSERT INTO authors \(name, bio, birthdate\)`).
+			WithArgs("Frank Herbert", "", nil).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(4, 1, stamp, stamp))
+		expectAudit(mockPool, "author", 4, 1, "create", `{"bio":{"before":null,"after":""},"name":{"before":null,"after":"Frank Herbert"}}`)
+		mockPool.ExpectCommit()
+
+		author, created, err := repo.FindOrCreateByName(ctx, "Frank Herbert")
+
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, 4, author.ID)
+	})
+
+	t.Run("should return error on database failure", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockAuthorRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectBegin()
+		mockPool.ExpectExec(lockName).
+			WithArgs("Frank Herbert").
+			WillReturnError(errors.New("db error during lock"))
+		mockPool.ExpectRollback()
+
+		_, _, err := repo.FindOrCreateByName(ctx, "Frank Herbert")
+
+		assert.ErrorContains(t, err, `failed to find or create author named "Frank Herbert"`)
+	})
+}
+
+func TestAuthorRepository_Update(t *testing.T) {
+	ctx := context.Background()
+	lockQuery := selectAuthors + ` WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`
+	updateQuery := `UPDATE authors SET name = \$1, bio = \$2, birthdate = \$3, version = version \+ 1, updated_at = now\(\) WHERE id = \$4 AND deleted_at IS NULL AND \(\$5 = 0 OR version = \$5\) RETURNING version, updated_at`
+	findVersion := `SELECT version FROM authors WHERE id = \$1 AND deleted_at IS NULL`
+	changes := `{"bio":{"before":"","after":"Bio"},"birthdate":{"before":null,"after":"1970-01-01T00:00:00Z"},"name":{"before":"Octavia E. Butler","after":"Updated"}}`
+
+	// expectLock expects the stored author to be locked and read
+	expectLock := func(mockPool pgxmock.PgxPoolIface, version int) {
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(lockQuery).
+			WithArgs(4).
+			WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Octavia E. Butler", "", nil, version, stamp, stamp, nil))
+	}
+
+	tests := []struct {
+		name            string
+		version         int
+		mockSetup       func(mockPool pgxmock.PgxPoolIface, author *entities.Author)
+		expectedVersion int
+		expectedError   error
+	}{
+		{
+			name: "should update an existing author and record the change",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface, author *entities.Author) {
+				expectLock(mockPool, 2)
+				mockPool.ExpectQuery(updateQuery).
+					WithArgs(author.Name, author.Bio, author.BirthDate, author.ID, 0).
+					WillReturnRows(pgxmock.NewRows([]string{"version", "updated_at"}).AddRow(3, stamp))
+				expectAudit(mockPool, "author", 4, 3, "update", changes)
+				mockPool.ExpectCommit()
+			},
+			expectedVersion: 3,
+		},
+		{
+			name:    "should update the expected version",
+			version: 2,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface, author *entities.Author) {
+				expectLock(mockPool, 2)
+				mockPool.ExpectQuery(updateQuery).
+					WithArgs(author.Name, author.Bio, author.BirthDate, author.ID, 2).
+					WillReturnRows(pgxmock.NewRows([]string{"version", "updated_at"}).AddRow(3, stamp))
+				expectAudit(mockPool, "author", 4, 3, "update", changes)
+				mockPool.ExpectCommit()
+			},
+			expectedVersion: 3,
+		},
+		{
+			name: "should return error if author not found for update",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface, author *entities.Author) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(lockQuery).
+					WithArgs(4).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+			},
+			expectedError: apperr.ErrNotFound,
+		},
+		{
+			name:    "should return a conflict for a stale version",
+			version: 2,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface, author *entities.Author) {
+				expectLock(mockPool, 5)
+				mockPool.ExpectQuery(updateQuery).
+					WithArgs(author.Name, author.Bio, author.BirthDate, author.ID, 2).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(findVersion).
+					WithArgs(author.ID).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(5))
+			},
+			expectedVersion: 2,
+			expectedError:   storage.ErrVersionMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockAuthorRepo(t)
+			defer cleanup()
+
+			author := &entities.Author{ID: 4, Name: "Updated", Bio: "Bio", BirthDate: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), Version: tc.version}
+			tc.mockSetup(mockPool, author)
+
+			err := repo.Update(ctx, author)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.expectedVersion != 0 {
+				assert.Equal(t, tc.expectedVersion, author.Version)
+			}
+		})
+	}
+}
+
+func TestAuthorRepository_Patch(t *testing.T) {
+	ctx := context.Background()
+	lockQuery := selectAuthors + ` WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`
+	birth := time.Date(1947, 6, 22, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		version         int
+		apply           func(author *entities.Author) error
+		mockSetup       func(mockPool pgxmock.PgxPoolIface)
+		expectedVersion int
+		expectedError   error
+	}{
+		{
+			name:  "should write only the changed columns",
+			apply: func(author *entities.Author) error { author.Bio = "Science fiction writer"; return nil },
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(lockQuery).
+					WithArgs(4).
+					WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Octavia E. Butler", "", birth, 2, stamp, stamp, nil))
+				mockPool.ExpectQuery(`UPDATE authors SET bio = \$1, version = version \+ 1, updated_at = now\(\) WHERE id = \$2 RETURNING version, updated_at`).
+					WithArgs("Science fiction writer", 4).
+					WillReturnRows(pgxmock.NewRows([]string{"version", "updated_at"}).AddRow(3, stamp))
+				expectAudit(mockPool, "author", 4, 3, "update", `{"bio":{"before":"","after":"Science fiction writer"}}`)
+				mockPool.ExpectCommit()
+			},
+			expectedVersion: 3,
+		},
+		{
+			name:  "should write nothing when nothing changed",
+			apply: func(author *entities.Author) error { return nil },
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(lockQuery).
+					WithArgs(4).
+					WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Octavia E. Butler", "", birth, 2, stamp, stamp, nil))
+				mockPool.ExpectCommit()
+			},
+			expectedVersion: 2,
+		},
+		{
+			name:    "should return a conflict for a stale version",
+			version: 1,
+			apply:   func(author *entities.Author) error { return nil },
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(lockQuery).
+					WithArgs(4).
+					WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Octavia E. Butler", "", birth, 2, stamp, stamp, nil))
+				mockPool.ExpectRollback()
+			},
+			expectedError: storage.ErrVersionMismatch,
+		},
+		{
+			name:  "should return ErrNotFound for a missing author",
+			apply: func(author *entities.Author) error { return nil },
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(lockQuery).
+					WithArgs(4).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectRollback()
+			},
+			expectedError: apperr.ErrNotFound,
+		},
+		{
+			name:  "should return the error of apply",
+			apply: func(author *entities.Author) error { return apperr.Validation("name is required", nil) },
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(lockQuery).
+					WithArgs(4).
+					WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Octavia E. Butler", "", birth, 2, stamp, stamp, nil))
+				mockPool.ExpectRollback()
+			},
+			expectedError: apperr.ErrValidation,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockAuthorRepo(t)
+			defer cleanup()
+			tc.mockSetup(mockPool)
+
+			author, err := repo.Patch(ctx, 4, tc.version, tc.apply)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedVersion, author.Version)
+		})
+	}
+}
+
+func TestAuthorRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+	deleteQuery := `UPDATE authors SET deleted_at = now\(\), updated_at = now\(\), version = version \+ 1 WHERE id = \$1 AND deleted_at IS NULL AND \(\$2 = 0 OR version = \$2\) AND NOT EXISTS \(`
+	hasBooks := `SELECT EXISTS \( SELECT 1 FROM books b WHERE b.deleted_at IS NULL`
+	findVersion := `SELECT version FROM authors WHERE id = \$1 AND deleted_at IS NULL`
+
+	tests := []struct {
+		name          string
+		version       int
+		mockSetup     func(mockPool pgxmock.PgxPoolIface)
+		expectedError error
+	}{
+		{
+			name: "should successfully delete an author",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteQuery).
+					WithArgs(1, 0).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(2))
+				expectAudit(mockPool, "author", 1, 2, "delete", `{}`)
+				mockPool.ExpectCommit()
+			},
+		},
+		{
+			name: "should reject deleting an author who still has books",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteQuery).
+					WithArgs(1, 0).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(hasBooks).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			},
+			expectedError: storage.ErrAuthorHasBooks,
+		},
+		{
+			name: "should return error if author not found for delete",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteQuery).
+					WithArgs(1, 0).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(hasBooks).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+			},
+			expectedError: errors.New("author with ID 1 not found for delete"),
+		},
+		{
+			name:    "should return error if a versioned author is not found for delete",
+			version: 2,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteQuery).
+					WithArgs(1, 2).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(hasBooks).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mockPool.ExpectQuery(findVersion).
+					WithArgs(1).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			expectedError: errors.New("author with ID 1 not found for delete"),
+		},
+		{
+			name:    "should reject deleting a stale version",
+			version: 2,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteQuery).
+					WithArgs(1, 2).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(hasBooks).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+				mockPool.ExpectQuery(findVersion).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(3))
+			},
+			expectedError: storage.ErrVersionMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockAuthorRepo(t)
+			defer cleanup()
+
+			tc.mockSetup(mockPool)
+
+			err := repo.Delete(ctx, 1, tc.version)
+
+			switch {
+			case tc.expectedError == nil:
+				assert.NoError(t, err)
+			case errors.Is(tc.expectedError, storage.ErrAuthorHasBooks), errors.Is(tc.expectedError, storage.ErrVersionMismatch):
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.ErrorIs(t, err, apperr.ErrConflict)
+			default:
+				assert.ErrorContains(t, err, tc.expectedError.Error())
+			}
+		})
+	}
+}
+
+func TestAuthorRepository_Restore(t *testing.T) {
+	ctx := context.Background()
+	restoreQuery := `UPDATE authors SET deleted_at = NULL, updated_at = now\(\), version = version \+ 1 WHERE id = \$1 AND deleted_at IS NOT NULL AND \(\$2 = 0 OR version = \$2\)`
+	// The author is read back whether deleted or not
+	findQuery := selectAuthors + ` WHERE id = \$1 .* AND \(\$2 OR deleted_at IS NULL\)`
+	deletedAt := stamp.Add(time.Hour)
+
+	tests := []struct {
+		name            string
+		version         int
+		mockSetup       func(mockPool pgxmock.PgxPoolIface)
+		expectedVersion int
+		expectedError   error
+	}{
+		{
+			name: "should restore a deleted author",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreQuery).
+					WithArgs(4, 0).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(3))
+				expectAudit(mockPool, "author", 4, 3, "restore", `{}`)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(findQuery).
+					WithArgs(4, true).
+					WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Octavia E. Butler", "", nil, 3, stamp, stamp, nil))
+			},
+			expectedVersion: 3,
+		},
+		{
+			name: "should return an author who is not deleted unchanged",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreQuery).
+					WithArgs(4, 0).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(findQuery).
+					WithArgs(4, true).
+					WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Octavia E. Butler", "", nil, 2, stamp, stamp, nil))
+			},
+			expectedVersion: 2,
+		},
+		{
+			name:    "should return a conflict for a stale version",
+			version: 1,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreQuery).
+					WithArgs(4, 1).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(findQuery).
+					WithArgs(4, true).
+					WillReturnRows(pgxmock.NewRows(authorColumns).AddRow(4, "Octavia E. Butler", "", nil, 2, stamp, stamp, &deletedAt))
+			},
+			expectedError: storage.ErrVersionMismatch,
+		},
+		{
+			name: "should return ErrNotFound for a missing author",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreQuery).
+					WithArgs(4, 0).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(findQuery).
+					WithArgs(4, true).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			expectedError: apperr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockAuthorRepo(t)
+			defer cleanup()
+			tc.mockSetup(mockPool)
+
+			author, err := repo.Restore(ctx, 4, tc.version)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedVersion, author.Version)
+			assert.Nil(t, author.DeletedAt)
+		})
+	}
+}
+
+func TestAuthorRepository_Purge(t *testing.T) {
+	ctx := context.Background()
+	mockPool, repo, cleanup := setupMockAuthorRepo(t)
+	defer cleanup()
+
+	// Authors still credited on a book are kept, and the purge of the
+	// others is recorded
+	mockPool.ExpectExec(`WITH purged AS \( DELETE FROM authors WHERE deleted_at < \$1 AND NOT EXISTS \(SELECT 1 FROM books b WHERE b.author_id = authors.id\) AND NOT EXISTS \(SELECT 1 FROM book_authors ba WHERE ba.author_id = authors.id\) RETURNING id, version \) INSERT INTO audit_log \(entity, entity_id, revision, action, actor\) SELECT 'author', id, version \+ 1, \$2, \$3 FROM purged`).
+		WithArgs(stamp, "purge", auth.ActorAnonymous).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+
+	n, err := repo.Purge(ctx, stamp)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
@@ -2,154 +2,484 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"time"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/isbn"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Book struct {
 	db PgxIface
+	queryLogger
 }
 
-func NewBookRepository(db PgxIface) *Book {
-	return &Book{db: db}
+func NewBookRepository(db PgxIface, logger *slog.Logger) *Book {
+	return &Book{db: db, queryLogger: newQueryLogger(logger)}
+}
+
+// bookColumns lists the book columns read by scanBook, in order.
+const bookColumns = `
+		id,
+		title,
+		description,
+		published_at,
+		author_id,
+		price,
+		COALESCE(google_id, ''),
+		COALESCE(isbn, ''),
+		version,
+		created_at,
+		updated_at,
+		deleted_at`
+
+// bookFields returns the scan destinations of bookColumns.
+func bookFields(book *entities.Book) []any {
+	return []any{
+		&book.ID,
+		&book.Title,
+		&book.Description,
+		&book.PublishedAt,
+		&book.AuthorID,
+		&book.Price,
+		&book.GoogleID,
+		&book.ISBN13,
+		&book.Version,
+		&book.CreatedAt,
+		&book.UpdatedAt,
+		&book.DeletedAt,
+	}
+}
+
+// scanBook reads a book selected with bookColumns.
+func scanBook(row pgx.Row) (*entities.Book, error) {
+	book := &entities.Book{}
+	err := row.Scan(bookFields(book)...)
+	book.ISBN10, _ = isbn.ToISBN10(book.ISBN13)
+	return book, err
 }
 
 type PgxIface interface {
 	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
 	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
 }
 
-func (b *Book) FindAll(ctx context.Context) ([]*entities.Book, error) {
+// inTx runs fn in a transaction, which is committed when fn succeeds and
+// rolled back otherwise.
+func inTx(ctx context.Context, db PgxIface, fn func(tx PgxIface) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// FindAll returns one page of books matching the query's filters, together
+// with the total number of matching books and the cursor of the next page.
+func (b *Book) FindAll(ctx context.Context, q entities.BookQuery) (*entities.BookPage, error) {
+	q = q.WithDefaults()
+
+	sortCol, ok := sortColumns[q.Sort]
+	if !ok {
+		return nil, apperr.Validation(fmt.Sprintf("unsupported sort field %q", q.Sort), nil)
+	}
+
+	where := bookFilters(q)
+	if !storage.IncludesDeleted(ctx) {
+		where.add("deleted_at IS NULL")
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM books ` + where.String()
+	if err := b.db.QueryRow(withQueryName(ctx, "books.count"), countQuery, where.args...).Scan(&total); err != nil {
+		return nil, b.queryError(ctx, "failed to count books", err)
+	}
+
+	offset := q.Offset
+	if q.Cursor != "" {
+		cursor, err := decodeBookCursor(q)
+		if err != nil {
+			return nil, err
+		}
+		cmp := ">"
+		if q.Desc {
+			cmp = "<"
+		}
+		where.add(fmt.Sprintf("(%s, id) %s (?::%s, ?)", sortCol.column, cmp, sortCol.cast), cursor.Value, cursor.ID)
+		offset = 0
+	}
+
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	query := fmt.Sprintf(`
+	SELECT `+bookColumns+`
+	FROM
+		books
+	%s
+	ORDER BY %s %s, id %s
+	LIMIT %d OFFSET %d
+	`, where.String(), sortCol.column, direction, direction, q.Limit+1, offset)
+
+	rows, err := b.db.Query(withQueryName(ctx, "books.find_all"), query, where.args...)
+	if err != nil {
+		return nil, b.queryError(ctx, "failed to execute query", err)
+	}
+	defer rows.Close()
+
+	books := make([]*entities.Book, 0, q.Limit)
+	for rows.Next() {
+		book, err := scanBook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan book row: %w", err)
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, b.queryError(ctx, "error during rows iteration", err)
+	}
+	rows.Close()
+
+	if err := b.loadDetails(ctx, books[:min(len(books), q.Limit)]...); err != nil {
+		return nil, err
+	}
+
+	page := &entities.BookPage{
+		Items:  books,
+		Total:  total,
+		Limit:  q.Limit,
+		Offset: offset,
+	}
+	if len(books) > q.Limit {
+		page.Items = books[:q.Limit]
+		page.NextCursor = encodeBookCursor(q, page.Items[q.Limit-1])
+	}
+
+	return page, nil
+}
+
+// FindByAuthorID returns every book written by the given author, newest first.
+func (b *Book) FindByAuthorID(ctx context.Context, authorID int) ([]*entities.Book, error) {
+	ctx = withQueryName(ctx, "books.find_by_author_id")
 
 	query := `
-	SELECT
-		id,
-		title,
-		description,
-		published_at,
-		author_id,
-		price
+	SELECT ` + bookColumns + `
 	FROM
 		books
-	ORDER BY published_at DESC -- Good to have an ORDER BY even without pagination
+	WHERE
+		EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = books.id AND ba.author_id = $1)
+		AND ($2 OR deleted_at IS NULL)
+	ORDER BY published_at DESC
 	`
 
-	rows, err := b.db.Query(ctx, query) // Use 'b.db' now
+	rows, err := b.db.Query(ctx, query, authorID, storage.IncludesDeleted(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return nil, b.queryError(ctx, fmt.Sprintf("failed to find books for author ID %d", authorID), err)
 	}
-	defer rows.Close() // ALWAYS defer rows.Close()
+	defer rows.Close()
 
 	books := make([]*entities.Book, 0)
 	for rows.Next() {
-		book := &entities.Book{}
-		// Scan all columns in the order they are selected
-		if err := rows.Scan(
-			&book.ID,
-			&book.Title,
-			&book.Description,
-			&book.PublishedAt,
-			&book.AuthorID,
-			&book.Price,
-		); err != nil {
+		book, err := scanBook(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan book row: %w", err)
 		}
 		books = append(books, book)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error during rows iteration: %w", err)
+		return nil, b.queryError(ctx, "error during rows iteration", err)
 	}
+	rows.Close()
 
+	if err := b.loadDetails(ctx, books...); err != nil {
+		return nil, err
+	}
 	return books, nil
-
 }
 
 func (b *Book) FindById(ctx context.Context, id int) (*entities.Book, error) {
+	ctx = withQueryName(ctx, "books.find_by_id")
 
 	query :=
 		`
-		SELECT
-			id,
-			title,
-			description,
-			published_at,
-			author_id,
-			price
+		SELECT ` + bookColumns + `
 		FROM
 			books
 		WHERE
 			id = $1
+			AND ($2 OR deleted_at IS NULL)
 	`
 
-	book := &entities.Book{}
-	err := b.db.QueryRow(ctx, query, id).Scan(
-		&book.ID,
-		&book.Title,
-		&book.Description,
-		&book.PublishedAt,
-		&book.AuthorID,
-		&book.Price,
-	)
+	book, err := scanBook(b.db.QueryRow(ctx, query, id, storage.IncludesDeleted(ctx)))
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			// If no row was found, return nil for the author and a specific error
-			return nil, fmt.Errorf("author with ID %d not found: %w", id, err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// If no row was found, return nil for the book and a specific error
+			return nil, apperr.NotFound(fmt.Sprintf("book with ID %d not found", id))
 		}
 		// For any other database error
-		return nil, fmt.Errorf("failed to find author by ID %d: %w", id, err)
+		return nil, b.queryError(ctx, fmt.Sprintf("failed to find book by ID %d", id), err)
 	}
 
+	if err := b.loadDetails(ctx, book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// FindByISBN returns the book with the given normalized ISBN-13.
+func (b *Book) FindByISBN(ctx context.Context, isbn13 string) (*entities.Book, error) {
+	ctx = withQueryName(ctx, "books.find_by_isbn")
+
+	query := `
+	SELECT ` + bookColumns + `
+	FROM
+		books
+	WHERE
+		isbn = $1
+		AND ($2 OR deleted_at IS NULL)
+	`
+
+	book, err := scanBook(b.db.QueryRow(ctx, query, isbn13, storage.IncludesDeleted(ctx)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.NotFound(fmt.Sprintf("book with ISBN %s not found", isbn13))
+		}
+		return nil, b.queryError(ctx, fmt.Sprintf("failed to find book by ISBN %s", isbn13), err)
+	}
+
+	if err := b.loadDetails(ctx, book); err != nil {
+		return nil, err
+	}
 	return book, nil
 }
 
 func (b *Book) Create(ctx context.Context, book *entities.Book) error {
+	ctx = withQueryName(ctx, "books.create")
+
 	query := `
-    INSERT INTO books (title, description, published_at, author_id, price)
-    VALUES ($1, $2, $3, $4, $5)
-    RETURNING id -- This returns the auto-generated ID
+    INSERT INTO books (title, description, published_at, author_id, price, isbn)
+    VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+    RETURNING id, version, created_at, updated_at -- This returns the auto-generated ID
 	`
-	err := b.db.QueryRow(ctx, query, book.Title, book.Description, book.PublishedAt, book.AuthorID, book.Price).Scan(&book.ID)
+	err := inTx(ctx, b.db, func(tx PgxIface) error {
+		err := tx.QueryRow(ctx, query, book.Title, book.Description, book.PublishedAt, book.AuthorID, book.Price, book.ISBN13).
+			Scan(&book.ID, &book.Version, &book.CreatedAt, &book.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if err := insertContributors(ctx, tx, book); err != nil {
+			return err
+		}
+		if err := insertLabels(ctx, tx, book); err != nil {
+			return err
+		}
+		return audit(ctx, tx, "book", book.ID, book.Version, entities.AuditCreate, nil, auditedBook(book))
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create book: %w", err)
+		book.ID, book.Version = 0, 0
+		return b.writeError(ctx, "failed to create book", book, err)
 	}
 
 	return nil
 }
-func (b *Book) Delete(ctx context.Context, id int) error {
-	query :=
-		`
-		DELETE 
-		
-		FROM
-			books
+
+// Delete soft-deletes the book and bumps its version. A non-zero version
+// must match the stored one, otherwise the conflict wraps
+// storage.ErrVersionMismatch. The row stays until Purge removes it.
+func (b *Book) Delete(ctx context.Context, id, version int) error {
+	ctx = withQueryName(ctx, "books.delete")
+
+	query := `
+		UPDATE books
+		SET
+			deleted_at = now(),
+			updated_at = now(),
+			version = version + 1
 		WHERE
-			id = $1 -- Use $1 for the first parameter in pgx
+			id = $1
+			AND deleted_at IS NULL
+			AND ($2 = 0 OR version = $2)
+		RETURNING version
 	`
 
-	cmdTag, err := b.db.Exec(ctx, query, id)
+	// revision stays 0 when no book was deleted
+	var revision int
+	err := inTx(ctx, b.db, func(tx PgxIface) error {
+		err := tx.QueryRow(ctx, query, id, version).Scan(&revision)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return audit(ctx, tx, "book", id, revision, entities.AuditDelete, nil, nil)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete book with ID %d: %w", id, err) // Corrected error message
+		return b.queryError(ctx, fmt.Sprintf("failed to delete book with ID %d", id), err)
 	}
 
-	// Check if any row was actually deleted (i.e., if the book existed)
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("book with ID %d not found for delete", id)
+	if revision == 0 {
+		return b.versionError(ctx, b.db, "books", "book", id, version, "delete")
 	}
 
 	return nil
+}
+
+// Restore undoes the soft delete of the book, bumps its version and
+// returns the book. A book that is not deleted is returned unchanged. A
+// non-zero version must match the stored one. A book whose ISBN or Google
+// volume another book has taken since, or one crediting a deleted author,
+// cannot be restored.
+func (b *Book) Restore(ctx context.Context, id, version int) (*entities.Book, error) {
+	ctx = withQueryName(ctx, "books.restore")
+
+	query := `
+		UPDATE books
+		SET
+			deleted_at = NULL,
+			updated_at = now(),
+			version = version + 1
+		WHERE
+			id = $1
+			AND deleted_at IS NOT NULL
+			AND ($2 = 0 OR version = $2)
+		RETURNING version
+	`
+
+	// The credited authors are locked so that none is deleted before the
+	// restore commits
+	credited := `
+		SELECT
+			id,
+			deleted_at IS NOT NULL
+		FROM
+			authors
+		WHERE
+			id IN (
+				SELECT author_id FROM books WHERE id = $1
+				UNION
+				SELECT author_id FROM book_authors WHERE book_id = $1
+			)
+		ORDER BY id
+		FOR SHARE
+	`
+
+	// revision stays 0 when no book was restored
+	var revision int
+	// restoreErr is returned as it is: it is not a database failure
+	var restoreErr error
+	err := inTx(ctx, b.db, func(tx PgxIface) error {
+		err := tx.QueryRow(ctx, query, id, version).Scan(&revision)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(withQueryName(ctx, "books.lock_credited_authors"), credited, id)
+		if err != nil {
+			return err
+		}
+		deletedAuthor, err := firstDeletedAuthor(rows)
+		if err != nil {
+			return err
+		}
+		if deletedAuthor != 0 {
+			restoreErr = apperr.Conflict(fmt.Sprintf("book with ID %d cannot be restored: it credits author with ID %d, who is deleted", id, deletedAuthor), storage.ErrAuthorDeleted)
+			return restoreErr
+		}
+		return audit(ctx, tx, "book", id, revision, entities.AuditRestore, nil, nil)
+	})
+	if restoreErr != nil {
+		return nil, restoreErr
+	}
+	if err != nil {
+		return nil, b.restoreError(ctx, id, err)
+	}
+
+	book, err := b.FindById(storage.WithDeleted(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	// Nothing restored: the book was not deleted or is at another version
+	if revision == 0 {
+		if err := checkVersion("book", id, book.Version, version); err != nil {
+			return nil, err
+		}
+	}
+	return book, nil
+}
+
+// firstDeletedAuthor returns the ID of the first deleted author among rows
+// of author IDs and whether they are deleted, or 0 when none is.
+func firstDeletedAuthor(rows pgx.Rows) (int, error) {
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var deleted bool
+		if err := rows.Scan(&id, &deleted); err != nil {
+			return 0, err
+		}
+		if deleted {
+			return id, nil
+		}
+	}
+	return 0, rows.Err()
+}
+
+// Purge removes the books deleted before deletedBefore for good, together
+// with their credits, genres and tags. Their history is kept, ending with
+// the purge.
+func (b *Book) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	ctx = withQueryName(ctx, "books.purge")
+
+	query := `
+	WITH purged AS (
+		DELETE FROM books WHERE deleted_at < $1 RETURNING id, version
+	)
+	INSERT INTO audit_log (entity, entity_id, revision, action, actor)
+	SELECT 'book', id, version + 1, $2, $3 FROM purged
+	`
 
+	cmdTag, err := b.db.Exec(ctx, query, deletedBefore, string(entities.AuditPurge), auth.Actor(ctx))
+	if err != nil {
+		return 0, b.queryError(ctx, "failed to purge deleted books", err)
+	}
+	return cmdTag.RowsAffected(), nil
 }
 
-// internal/storage/postgres/book.go
+// History returns the recorded revisions of the book, newest first, even
+// once it is purged.
+func (b *Book) History(ctx context.Context, id int) ([]*entities.Revision, error) {
+	return b.history(ctx, b.db, "book", id)
+}
 
-// Update modifies an existing book's details in the database.
-// It uses book.ID to identify the record to update.
+// Update overwrites the book, its contributors, genres and tags, and
+// bumps its version. A non-zero book.Version must match the stored one,
+// otherwise the conflict wraps storage.ErrVersionMismatch.
 func (b *Book) Update(ctx context.Context, book *entities.Book) error {
+	ctx = withQueryName(ctx, "books.update")
+
 	query := `
         UPDATE books
         SET
@@ -157,30 +487,476 @@ func (b *Book) Update(ctx context.Context, book *entities.Book) error {
             description = $2,
             published_at = $3,
             author_id = $4,
-            price = $5
+            price = $5,
+            isbn = NULLIF($6, ''),
+            version = version + 1,
+            updated_at = now()
         WHERE
-            id = $6 -- The ID of the book to update
+            id = $7
+            AND deleted_at IS NULL
+            AND ($8 = 0 OR version = $8)
+        RETURNING version, updated_at
     `
 
-	// Use Exec for UPDATE operations, as it doesn't return rows of data
-	cmdTag, err := b.db.Exec(
-		ctx,
-		query,
-		book.Title,
-		book.Description,
-		book.PublishedAt,
-		book.AuthorID,
-		book.Price,
-		book.ID, // This is the value for $6 in the WHERE clause
-	)
+	// The contributors, genres and tags are replaced in the same transaction
+	var found bool
+	err := inTx(ctx, b.db, func(tx PgxIface) error {
+		// The stored book is locked and read for the audit log
+		before, err := b.lockBook(ctx, tx, book.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(
+			ctx,
+			query,
+			book.Title,
+			book.Description,
+			book.PublishedAt,
+			book.AuthorID,
+			book.Price,
+			book.ISBN13,
+			book.ID,
+			book.Version,
+		).Scan(&book.Version, &book.UpdatedAt)
+		// No row means the book is missing or at another version
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		if err := replaceContributors(ctx, tx, book); err != nil {
+			return err
+		}
+		if err := replaceLabels(ctx, tx, book); err != nil {
+			return err
+		}
+		return audit(ctx, tx, "book", book.ID, book.Version, entities.AuditUpdate, auditedBook(before), auditedBook(book))
+	})
+	if err != nil {
+		return b.writeError(ctx, fmt.Sprintf("failed to update book with ID %d", book.ID), book, err)
+	}
+	if !found {
+		return b.versionError(ctx, b.db, "books", "book", book.ID, book.Version, "update")
+	}
+
+	return nil
+}
+
+// Patch locks the book and passes it, with its contributors, genres and
+// tags, to apply. Only what apply changed is written back: the changed
+// columns, and the contributors or labels if they changed. The version is
+// bumped, and the change recorded, unless nothing changed. apply cannot
+// change the ID, version, timestamps or Google volume of the book. Deleted
+// books cannot be patched.
+func (b *Book) Patch(ctx context.Context, id, version int, apply func(book *entities.Book) error) (*entities.Book, error) {
+	ctx = withQueryName(ctx, "books.patch")
+
+	var book *entities.Book
+	// patchErr is returned as it is: it was classified where it happened
+	var patchErr error
+	err := inTx(ctx, b.db, func(tx PgxIface) error {
+		current, err := b.lockBook(ctx, tx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			patchErr = apperr.NotFound(fmt.Sprintf("book with ID %d not found for patch", id))
+			return patchErr
+		}
+		if err != nil {
+			return err
+		}
+		if patchErr = checkVersion("book", id, current.Version, version); patchErr != nil {
+			return patchErr
+		}
+
+		book = cloneBook(current)
+		if patchErr = apply(book); patchErr != nil {
+			return patchErr
+		}
+		book.ID, book.Version, book.GoogleID = current.ID, current.Version, current.GoogleID
+		book.CreatedAt, book.UpdatedAt, book.DeletedAt = current.CreatedAt, current.UpdatedAt, current.DeletedAt
+		if err := writeBookChanges(ctx, tx, current, book); err != nil {
+			return err
+		}
+		if book.Version == current.Version {
+			return nil
+		}
+		return audit(ctx, tx, "book", id, book.Version, entities.AuditUpdate, auditedBook(current), auditedBook(book))
+	})
+	if patchErr != nil {
+		return nil, patchErr
+	}
+	if err != nil {
+		return nil, b.writeError(ctx, fmt.Sprintf("failed to patch book with ID %d", id), book, err)
+	}
+
+	return book, nil
+}
+
+// lockBook reads the book that is not deleted, with its contributors,
+// genres and tags, and locks its row until tx ends. A missing book is
+// pgx.ErrNoRows.
+func (b *Book) lockBook(ctx context.Context, tx PgxIface, id int) (*entities.Book, error) {
+	query := `
+		SELECT ` + bookColumns + `
+		FROM
+			books
+		WHERE
+			id = $1
+			AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	book, err := scanBook(tx.QueryRow(withQueryName(ctx, "books.find_for_update"), query, id))
+	if err != nil {
+		return nil, err
+	}
+	// Read the details in the transaction too, under the row lock
+	locked := &Book{db: tx, queryLogger: b.queryLogger}
+	if err := locked.loadDetails(ctx, book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+// cloneBook copies a book, including its contributors, genres and tags.
+func cloneBook(book *entities.Book) *entities.Book {
+	c := *book
+	c.Contributors = slices.Clone(book.Contributors)
+	c.Genres = slices.Clone(book.Genres)
+	c.Tags = slices.Clone(book.Tags)
+	return &c
+}
+
+// writeBookChanges writes the columns, contributors and labels in which
+// book differs from before, and bumps its version. It writes nothing when
+// they are the same.
+func writeBookChanges(ctx context.Context, tx PgxIface, before, book *entities.Book) error {
+	c := &columnChanges{}
+	c.add(book.Title != before.Title, "title = ?", book.Title)
+	c.add(book.Description != before.Description, "description = ?", book.Description)
+	c.add(!book.PublishedAt.Equal(before.PublishedAt), "published_at = ?", book.PublishedAt)
+	c.add(book.AuthorID != before.AuthorID, "author_id = ?", book.AuthorID)
+	c.add(book.Price != before.Price, "price = ?", book.Price)
+	c.add(book.ISBN13 != before.ISBN13, "isbn = NULLIF(?, '')", book.ISBN13)
+	creditsChanged := !entities.SameCredits(book.Contributors, before.Contributors)
+	labelsChanged := !slices.Equal(book.Genres, before.Genres) || !slices.Equal(book.Tags, before.Tags)
+	if len(c.set) == 0 && !creditsChanged && !labelsChanged {
+		return nil
+	}
+
+	query, args := c.update("books", book.ID)
+	if err := tx.QueryRow(ctx, query, args...).Scan(&book.Version, &book.UpdatedAt); err != nil {
+		return err
+	}
+	if creditsChanged {
+		if err := replaceContributors(ctx, tx, book); err != nil {
+			return err
+		}
+	}
+	if labelsChanged {
+		return replaceLabels(ctx, tx, book)
+	}
+	return nil
+}
+
+// UpsertByGoogleID inserts the book or, when a book was already imported
+// from the same Google volume, refreshes the fields that come from the
+// volume. book.ID is set either way. The contributors, genres and tags of
+// a refreshed book are kept, and so is its author_id, as they may have been
+// edited since. A deleted book is not refreshed: the volume is imported as
+// a new book. Either way the write is recorded in the audit log.
+func (b *Book) UpsertByGoogleID(ctx context.Context, book *entities.Book) (bool, error) {
+	ctx = withQueryName(ctx, "books.upsert_by_google_id")
+
+	query := `
+	INSERT INTO books (title, description, published_at, author_id, price, google_id, isbn)
+	VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+	ON CONFLICT (google_id) WHERE deleted_at IS NULL DO UPDATE SET
+		title = EXCLUDED.title,
+		description = EXCLUDED.description,
+		published_at = EXCLUDED.published_at,
+		price = EXCLUDED.price,
+		isbn = EXCLUDED.isbn,
+		version = books.version + 1,
+		updated_at = now()
+	RETURNING id, author_id, version, created_at, updated_at, (xmax = 0) AS created
+	`
+
+	existing := `
+	SELECT ` + bookColumns + `
+	FROM
+		books
+	WHERE
+		google_id = $1
+		AND deleted_at IS NULL
+	FOR UPDATE
+	`
+
+	var created bool
+	err := inTx(ctx, b.db, func(tx PgxIface) error {
+		// The book about to be overwritten, if any, is read for the audit log
+		var before any
+		current, err := scanBook(tx.QueryRow(withQueryName(ctx, "books.find_by_google_id_for_update"), existing, book.GoogleID))
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+		case err != nil:
+			return err
+		default:
+			locked := &Book{db: tx, queryLogger: b.queryLogger}
+			if err := locked.loadDetails(ctx, current); err != nil {
+				return err
+			}
+			book.AuthorID, book.Contributors = current.AuthorID, current.Contributors
+			book.Genres, book.Tags = current.Genres, current.Tags
+			before = auditedBook(current)
+		}
+
+		err = tx.QueryRow(ctx, query, book.Title, book.Description, book.PublishedAt, book.AuthorID, book.Price, book.GoogleID, book.ISBN13).
+			Scan(&book.ID, &book.AuthorID, &book.Version, &book.CreatedAt, &book.UpdatedAt, &created)
+		if err != nil {
+			return err
+		}
+		switch {
+		case created:
+			if err := replaceContributors(ctx, tx, book); err != nil {
+				return err
+			}
+		case before == nil:
+			// Another import created the book after it was looked up: its
+			// contributors and labels are kept like any refreshed book's
+			locked := &Book{db: tx, queryLogger: b.queryLogger}
+			if err := locked.loadDetails(ctx, book); err != nil {
+				return err
+			}
+		}
+		action := entities.AuditUpdate
+		if created {
+			action = entities.AuditCreate
+		}
+		return audit(ctx, tx, "book", book.ID, book.Version, action, before, auditedBook(book))
+	})
+	if err != nil {
+		return false, b.writeError(ctx, fmt.Sprintf("failed to import google volume %s", book.GoogleID), book, err)
+	}
+
+	return created, nil
+}
+
+// loadDetails fills in the contributors, genres and tags of books, with
+// one query for each for all of them.
+func (b *Book) loadDetails(ctx context.Context, books ...*entities.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*entities.Book, len(books))
+	ids := make([]int, 0, len(books))
+	for _, book := range books {
+		book.Contributors = []entities.Contributor{}
+		book.Genres = []string{}
+		book.Tags = []string{}
+		byID[book.ID] = book
+		ids = append(ids, book.ID)
+	}
+
+	if err := b.loadContributors(ctx, byID, ids); err != nil {
+		return err
+	}
+	return b.loadLabels(ctx, byID, ids)
+}
+
+// loadContributors fills in the contributors of books in credit order.
+func (b *Book) loadContributors(ctx context.Context, byID map[int]*entities.Book, ids []int) error {
+	ctx = withQueryName(ctx, "book_authors.find_by_book_ids")
+
+	query := `
+	SELECT
+		ba.book_id,
+		ba.author_id,
+		a.name,
+		ba.role
+	FROM
+		book_authors ba
+		JOIN authors a ON a.id = ba.author_id
+	WHERE
+		ba.book_id = ANY($1)
+	ORDER BY ba.book_id, ba.position
+	`
+
+	rows, err := b.db.Query(ctx, query, ids)
 	if err != nil {
-		return fmt.Errorf("failed to update book with ID %d: %w", book.ID, err)
+		return b.queryError(ctx, "failed to find book contributors", err)
 	}
+	defer rows.Close()
 
-	// Check if any row was actually updated (i.e., if the book existed)
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("book with ID %d not found for update", book.ID)
+	for rows.Next() {
+		var bookID int
+		var role string
+		var c entities.Contributor
+		if err := rows.Scan(&bookID, &c.AuthorID, &c.Name, &role); err != nil {
+			return fmt.Errorf("failed to scan contributor row: %w", err)
+		}
+		c.Role = entities.ContributorRole(role)
+		if book, ok := byID[bookID]; ok {
+			book.Contributors = append(book.Contributors, c)
+		}
 	}
 
+	if err := rows.Err(); err != nil {
+		return b.queryError(ctx, "error during rows iteration", err)
+	}
 	return nil
 }
+
+// loadLabels fills in the genre slugs and tag names of books, sorted.
+func (b *Book) loadLabels(ctx context.Context, byID map[int]*entities.Book, ids []int) error {
+	ctx = withQueryName(ctx, "book_labels.find_by_book_ids")
+
+	query := `
+	SELECT bg.book_id, 'genre', g.slug
+	FROM book_genres bg JOIN genres g ON g.id = bg.genre_id
+	WHERE bg.book_id = ANY($1)
+	UNION ALL
+	SELECT bt.book_id, 'tag', t.name
+	FROM book_tags bt JOIN tags t ON t.id = bt.tag_id
+	WHERE bt.book_id = ANY($1)
+	ORDER BY 1, 3
+	`
+
+	rows, err := b.db.Query(ctx, query, ids)
+	if err != nil {
+		return b.queryError(ctx, "failed to find book genres and tags", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		var kind, label string
+		if err := rows.Scan(&bookID, &kind, &label); err != nil {
+			return fmt.Errorf("failed to scan label row: %w", err)
+		}
+		book, ok := byID[bookID]
+		if !ok {
+			continue
+		}
+		if kind == "genre" {
+			book.Genres = append(book.Genres, label)
+		} else {
+			book.Tags = append(book.Tags, label)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return b.queryError(ctx, "error during rows iteration", err)
+	}
+	return nil
+}
+
+// insertLabels files a book under its genres, which must exist, and tags
+// it, creating the tags that do not exist yet.
+func insertLabels(ctx context.Context, tx PgxIface, book *entities.Book) error {
+	if len(book.Genres) > 0 {
+		query := `
+		INSERT INTO book_genres (book_id, genre_id)
+		SELECT $1, id FROM genres WHERE slug = ANY($2)
+		`
+		if _, err := tx.Exec(withQueryName(ctx, "book_genres.insert"), query, book.ID, book.Genres); err != nil {
+			return err
+		}
+	}
+
+	if len(book.Tags) > 0 {
+		query := `INSERT INTO tags (name) SELECT unnest($1::text[]) ON CONFLICT (name) DO NOTHING`
+		if _, err := tx.Exec(withQueryName(ctx, "tags.create_missing"), query, book.Tags); err != nil {
+			return err
+		}
+		query = `
+		INSERT INTO book_tags (book_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = ANY($2)
+		`
+		if _, err := tx.Exec(withQueryName(ctx, "book_tags.insert"), query, book.ID, book.Tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceLabels removes a book from its stored genres and tags and files
+// it under its current ones.
+func replaceLabels(ctx context.Context, tx PgxIface, book *entities.Book) error {
+	if _, err := tx.Exec(withQueryName(ctx, "book_genres.delete"), `DELETE FROM book_genres WHERE book_id = $1`, book.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(withQueryName(ctx, "book_tags.delete"), `DELETE FROM book_tags WHERE book_id = $1`, book.ID); err != nil {
+		return err
+	}
+	return insertLabels(ctx, tx, book)
+}
+
+// insertContributors stores the contributors of a book in credit order.
+func insertContributors(ctx context.Context, tx PgxIface, book *entities.Book) error {
+	if len(book.Contributors) == 0 {
+		return nil
+	}
+
+	authorIDs := make([]int, len(book.Contributors))
+	roles := make([]string, len(book.Contributors))
+	for i, c := range book.Contributors {
+		authorIDs[i] = c.AuthorID
+		roles[i] = string(c.Role)
+	}
+
+	query := `
+	INSERT INTO book_authors (book_id, author_id, role, position)
+	SELECT $1, c.author_id, c.role, c.position - 1
+	FROM unnest($2::int[], $3::text[]) WITH ORDINALITY AS c(author_id, role, position)
+	`
+	_, err := tx.Exec(withQueryName(ctx, "book_authors.insert"), query, book.ID, authorIDs, roles)
+	return err
+}
+
+// replaceContributors deletes the stored contributors of a book and stores
+// its current ones.
+func replaceContributors(ctx context.Context, tx PgxIface, book *entities.Book) error {
+	query := `DELETE FROM book_authors WHERE book_id = $1`
+	if _, err := tx.Exec(withQueryName(ctx, "book_authors.delete"), query, book.ID); err != nil {
+		return err
+	}
+	return insertContributors(ctx, tx, book)
+}
+
+// restoreError reports a book that cannot be restored because another
+// book has taken its ISBN or Google volume as a conflict saying so.
+func (b *Book) restoreError(ctx context.Context, id int, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		switch pgErr.ConstraintName {
+		case "books_isbn_key":
+			return apperr.Conflict(fmt.Sprintf("book with ID %d cannot be restored: another book has its ISBN", id), err)
+		case "books_google_id_key":
+			return apperr.Conflict(fmt.Sprintf("book with ID %d cannot be restored: another book was imported from its Google volume", id), err)
+		}
+	}
+	return b.queryError(ctx, fmt.Sprintf("failed to restore book with ID %d", id), err)
+}
+
+// writeError reports a book referencing an author that does not exist as
+// a validation error, and one whose ISBN is taken as a conflict naming it,
+// instead of a database failure.
+func (b *Book) writeError(ctx context.Context, message string, book *entities.Book, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == foreignKeyViolation:
+			return apperr.Validation("the book references an author that does not exist", err)
+		case pgErr.Code == uniqueViolation && pgErr.ConstraintName == "books_isbn_key":
+			return apperr.Conflict(fmt.Sprintf("a book with ISBN %s already exists", book.ISBN13), err)
+		}
+	}
+	return b.queryError(ctx, message, err)
+}
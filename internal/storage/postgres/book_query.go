@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+// sortColumns maps the whitelisted sort fields to their column and the
+// SQL type used to compare cursor values against that column.
+var sortColumns = map[entities.BookSortField]struct {
+	column string
+	cast   string
+}{
+	entities.SortByTitle:       {column: "title", cast: "text"},
+	entities.SortByPrice:       {column: "price", cast: "numeric"},
+	entities.SortByPublishedAt: {column: "published_at", cast: "timestamptz"},
+}
+
+// bookCursor is the keyset position after the last book of a page. It is
+// handed to clients base64-encoded, so its layout is not part of the API.
+type bookCursor struct {
+	Sort  entities.BookSortField `json:"s"`
+	Desc  bool                   `json:"d,omitempty"`
+	Value string                 `json:"v"`
+	ID    int                    `json:"id"`
+}
+
+func encodeBookCursor(q entities.BookQuery, last *entities.Book) string {
+	c := bookCursor{Sort: q.Sort, Desc: q.Desc, ID: last.ID}
+	switch q.Sort {
+	case entities.SortByTitle:
+		c.Value = last.Title
+	case entities.SortByPrice:
+		c.Value = strconv.FormatFloat(last.Price, 'f', -1, 64)
+	case entities.SortByPublishedAt:
+		c.Value = last.PublishedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeBookCursor(q entities.BookQuery) (*bookCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, apperr.Validation("invalid cursor", storage.ErrInvalidCursor)
+	}
+
+	var c bookCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, apperr.Validation("invalid cursor", storage.ErrInvalidCursor)
+	}
+	if c.Sort != q.Sort || c.Desc != q.Desc {
+		return nil, apperr.Validation("cursor was issued for a different sort order", storage.ErrInvalidCursor)
+	}
+
+	return &c, nil
+}
+
+// bookWhere accumulates the WHERE conditions and positional arguments of a
+// book listing query.
+type bookWhere struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (w *bookWhere) add(condition string, args ...interface{}) {
+	// Replace each ? with the next positional parameter
+	for _, arg := range args {
+		w.args = append(w.args, arg)
+		condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(w.args)), 1)
+	}
+	w.conditions = append(w.conditions, condition)
+}
+
+func (w *bookWhere) String() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+func bookFilters(q entities.BookQuery) *bookWhere {
+	w := &bookWhere{}
+	if q.AuthorID != 0 {
+		// Any contributor counts, not only the primary author
+		w.add("EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = books.id AND ba.author_id = ?)", q.AuthorID)
+	}
+	if q.MinPrice != nil {
+		w.add("price >= ?", *q.MinPrice)
+	}
+	if q.MaxPrice != nil {
+		w.add("price <= ?", *q.MaxPrice)
+	}
+	if q.PublishedAfter != nil {
+		w.add("published_at >= ?", *q.PublishedAfter)
+	}
+	if q.PublishedBefore != nil {
+		w.add("published_at < ?", *q.PublishedBefore)
+	}
+	if q.Genre != "" {
+		// Books filed under a subgenre are in the genre too
+		w.add(`EXISTS (SELECT 1 FROM book_genres bg WHERE bg.book_id = books.id AND bg.genre_id IN (
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM genres WHERE slug = ?
+				UNION
+				SELECT g.id FROM genres g JOIN subtree s ON g.parent_id = s.id
+			)
+			SELECT id FROM subtree))`, q.Genre)
+	}
+	for _, tag := range q.Tags {
+		w.add("EXISTS (SELECT 1 FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.book_id = books.id AND t.name = ?)", tag)
+	}
+	return w
+}
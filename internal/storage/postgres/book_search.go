@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/isbn"
+)
+
+// bookSearch is a way of matching books against a search query: count
+// counts the matches of $1, hits returns them best first, paged by $2 and
+// $3, as bookColumns followed by rank and snippet. Deleted books are never
+// matched.
+type bookSearch struct {
+	name  string
+	count string
+	hits  string
+}
+
+// ftsMatches finds the books whose title or description, or the name of
+// one of whose contributors, matches the query. Both sides use their GIN
+// index.
+const ftsMatches = `
+	WITH query AS (
+		SELECT websearch_to_tsquery('english', $1) AS q
+	), matches AS (
+		SELECT id AS book_id FROM books, query WHERE search_vector @@ q
+		UNION
+		SELECT ba.book_id
+		FROM book_authors ba
+		JOIN authors a ON a.id = ba.author_id, query
+		WHERE a.search_vector @@ q
+	)`
+
+// fullTextSearch ranks books on their title (weight A), description (B)
+// and contributor names (C).
+var fullTextSearch = bookSearch{
+	name:  "books.search",
+	count: ftsMatches + ` SELECT COUNT(*) FROM matches JOIN books ON book_id = id WHERE deleted_at IS NULL`,
+	hits: ftsMatches + `
+	SELECT ` + bookColumns + `,
+		ts_rank(search_vector || setweight(names.vector, 'C'), q)::float8 AS rank,
+		ts_headline('english', concat_ws('. ', title, NULLIF(description, '')), q,
+			'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MinWords=5, MaxWords=25') AS snippet
+	FROM books
+	JOIN matches ON book_id = id
+	CROSS JOIN query
+	CROSS JOIN LATERAL (
+		SELECT COALESCE(to_tsvector('english', string_agg(a.name, ' ')), ''::tsvector) AS vector
+		FROM book_authors ba
+		JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id = books.id
+	) names
+	WHERE deleted_at IS NULL
+	ORDER BY rank DESC, id
+	LIMIT $2 OFFSET $3`,
+}
+
+// fuzzyMatches finds the books whose title, or the name of one of whose
+// contributors, contains a word similar to the query.
+const fuzzyMatches = `
+	WITH matches AS (
+		SELECT book_id, MAX(score) AS score
+		FROM (
+			SELECT id AS book_id, word_similarity($1, title) AS score FROM books WHERE $1 <% title
+			UNION ALL
+			SELECT ba.book_id, word_similarity($1, a.name)
+			FROM book_authors ba
+			JOIN authors a ON a.id = ba.author_id
+			WHERE $1 <% a.name
+		) similar
+		GROUP BY book_id
+	)`
+
+// fuzzySearch ranks books on their trigram similarity. Nothing matched
+// word for word, so the snippet is the title.
+var fuzzySearch = bookSearch{
+	name:  "books.search_fuzzy",
+	count: fuzzyMatches + ` SELECT COUNT(*) FROM matches JOIN books ON book_id = id WHERE deleted_at IS NULL`,
+	hits: fuzzyMatches + `
+	SELECT ` + bookColumns + `, score::float8 AS rank, title AS snippet
+	FROM books
+	JOIN matches ON book_id = id
+	WHERE deleted_at IS NULL
+	ORDER BY rank DESC, id
+	LIMIT $2 OFFSET $3`,
+}
+
+// Search returns one page of the books matching a full-text query, best
+// first. With q.Fuzzy, a query that matches nothing is retried with
+// trigram similarity, which forgives typos.
+func (b *Book) Search(ctx context.Context, q entities.BookSearchQuery) (*entities.BookSearchPage, error) {
+	q = q.WithDefaults()
+
+	page, err := b.search(ctx, fullTextSearch, q)
+	if err != nil || page.Total > 0 || !q.Fuzzy {
+		return page, err
+	}
+
+	page, err = b.search(ctx, fuzzySearch, q)
+	if err != nil {
+		return nil, err
+	}
+	page.Fuzzy = true
+	return page, nil
+}
+
+func (b *Book) search(ctx context.Context, s bookSearch, q entities.BookSearchQuery) (*entities.BookSearchPage, error) {
+	page := &entities.BookSearchPage{
+		Items:  []*entities.BookSearchHit{},
+		Limit:  q.Limit,
+		Offset: q.Offset,
+	}
+
+	if err := b.db.QueryRow(withQueryName(ctx, s.name+"_count"), s.count, q.Query).Scan(&page.Total); err != nil {
+		return nil, b.queryError(ctx, "failed to count search results", err)
+	}
+	if page.Total <= q.Offset {
+		return page, nil
+	}
+
+	rows, err := b.db.Query(withQueryName(ctx, s.name), s.hits, q.Query, q.Limit, q.Offset)
+	if err != nil {
+		return nil, b.queryError(ctx, "failed to search books", err)
+	}
+	defer rows.Close()
+
+	books := make([]*entities.Book, 0, q.Limit)
+	for rows.Next() {
+		hit := &entities.BookSearchHit{}
+		if err := rows.Scan(append(bookFields(&hit.Book), &hit.Rank, &hit.Snippet)...); err != nil {
+			return nil, fmt.Errorf("failed to scan search hit: %w", err)
+		}
+		hit.ISBN10, _ = isbn.ToISBN10(hit.ISBN13)
+		page.Items = append(page.Items, hit)
+		books = append(books, &hit.Book)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, b.queryError(ctx, "error during rows iteration", err)
+	}
+	rows.Close()
+
+	if err := b.loadDetails(ctx, books...); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
@@ -0,0 +1,161 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+)
+
+var searchHitColumns = []string{"id", "title", "description", "published_at", "author_id", "price", "google_id", "isbn", "version", "created_at", "updated_at", "deleted_at", "rank", "snippet"}
+
+func TestBookRepository_Search(t *testing.T) {
+	ctx := context.Background()
+	publishedAt := time.Date(1969, 3, 1, 0, 0, 0, 0, time.UTC)
+	const (
+		countFullText = `websearch_to_tsquery\('english', \$1\) .* SELECT COUNT\(\*\) FROM matches JOIN books ON book_id = id WHERE deleted_at IS NULL`
+		hitsFullText  = `websearch_to_tsquery\('english', \$1\) .* ts_headline.* WHERE deleted_at IS NULL ORDER BY rank DESC, id LIMIT \$2 OFFSET \$3`
+		countFuzzy    = `word_similarity\(\$1, title\) .* SELECT COUNT\(\*\) FROM matches JOIN books ON book_id = id WHERE deleted_at IS NULL`
+		hitsFuzzy     = `word_similarity\(\$1, title\) .* title AS snippet FROM books JOIN matches ON book_id = id WHERE deleted_at IS NULL ORDER BY rank DESC, id LIMIT \$2 OFFSET \$3`
+	)
+
+	tests := []struct {
+		name         string
+		query        entities.BookSearchQuery
+		mockSetup    func(mockPool pgxmock.PgxPoolIface)
+		expectedPage *entities.BookSearchPage
+	}{
+		{
+			name:  "should return ranked hits with snippets",
+			query: entities.BookSearchQuery{Query: "left hand", Limit: 10},
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectQuery(countFullText).
+					WithArgs("left hand").
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mockPool.ExpectQuery(hitsFullText).
+					WithArgs("left hand", 10, 0).
+					WillReturnRows(pgxmock.NewRows(searchHitColumns).
+						AddRow(1, "The Left Hand of Darkness", "Genly Ai visits Gethen.", publishedAt, 7, 9.99, "", "9780441478125", 2, stamp, stamp, nil, 0.61, "The <mark>Left</mark> <mark>Hand</mark> of Darkness"))
+				mockPool.ExpectQuery(selectContributors).
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows(contributorColumns).AddRow(1, 7, "Ursula K. Le Guin", "author"))
+				mockPool.ExpectQuery(selectLabels).
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows(labelColumns))
+			},
+			expectedPage: &entities.BookSearchPage{
+				Items: []*entities.BookSearchHit{{
+					Book: entities.Book{
+						ID:           1,
+						Title:        "The Left Hand of Darkness",
+						Description:  "Genly Ai visits Gethen.",
+						PublishedAt:  publishedAt,
+						AuthorID:     7,
+						Price:        9.99,
+						ISBN13:       "9780441478125",
+						ISBN10:       "0441478123",
+						Version:      2,
+						CreatedAt:    stamp,
+						UpdatedAt:    stamp,
+						Contributors: []entities.Contributor{{AuthorID: 7, Name: "Ursula K. Le Guin", Role: entities.RoleAuthor}},
+						Genres:       []string{},
+						Tags:         []string{},
+					},
+					Rank:    0.61,
+					Snippet: "The <mark>Left</mark> <mark>Hand</mark> of Darkness",
+				}},
+				Total: 1,
+				Limit: 10,
+			},
+		},
+		{
+			name:  "should not fetch hits past the last one",
+			query: entities.BookSearchQuery{Query: "dune", Offset: 40},
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectQuery(countFullText).
+					WithArgs("dune").
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+			},
+			expectedPage: &entities.BookSearchPage{
+				Items:  []*entities.BookSearchHit{},
+				Total:  3,
+				Limit:  entities.DefaultBookLimit,
+				Offset: 40,
+			},
+		},
+		{
+			name:  "should not fall back without fuzzy",
+			query: entities.BookSearchQuery{Query: "hobit"},
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectQuery(countFullText).
+					WithArgs("hobit").
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+			},
+			expectedPage: &entities.BookSearchPage{
+				Items: []*entities.BookSearchHit{},
+				Limit: entities.DefaultBookLimit,
+			},
+		},
+		{
+			name:  "should fall back to trigram similarity",
+			query: entities.BookSearchQuery{Query: "hobit", Fuzzy: true},
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectQuery(countFullText).
+					WithArgs("hobit").
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+				mockPool.ExpectQuery(countFuzzy).
+					WithArgs("hobit").
+					WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+				mockPool.ExpectQuery(hitsFuzzy).
+					WithArgs("hobit", entities.DefaultBookLimit, 0).
+					WillReturnRows(pgxmock.NewRows(searchHitColumns).
+						AddRow(2, "The Hobbit", "", publishedAt, 8, 12.5, "", "", 1, stamp, stamp, nil, 0.8, "The Hobbit"))
+				mockPool.ExpectQuery(selectContributors).
+					WithArgs([]int{2}).
+					WillReturnRows(pgxmock.NewRows(contributorColumns))
+				mockPool.ExpectQuery(selectLabels).
+					WithArgs([]int{2}).
+					WillReturnRows(pgxmock.NewRows(labelColumns))
+			},
+			expectedPage: &entities.BookSearchPage{
+				Items: []*entities.BookSearchHit{{
+					Book: entities.Book{
+						ID:           2,
+						Title:        "The Hobbit",
+						PublishedAt:  publishedAt,
+						AuthorID:     8,
+						Price:        12.5,
+						Version:      1,
+						CreatedAt:    stamp,
+						UpdatedAt:    stamp,
+						Contributors: []entities.Contributor{},
+						Genres:       []string{},
+						Tags:         []string{},
+					},
+					Rank:    0.8,
+					Snippet: "The Hobbit",
+				}},
+				Total: 1,
+				Limit: entities.DefaultBookLimit,
+				Fuzzy: true,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockRepo(t)
+			defer cleanup()
+			tc.mockSetup(mockPool)
+
+			page, err := repo.Search(ctx, tc.query)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedPage, page)
+		})
+	}
+}
@@ -3,23 +3,62 @@ package postgres_test
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"testing"
 	"time"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/auth"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
 	"github.com/demirbalemir/hop/Onboardingv2/internal/storage/postgres"
 )
 
+// selectBooks matches the start of a query reading every book column.
+const selectBooks = `SELECT id, title, description, published_at, author_id, price, COALESCE\(google_id, ''\), COALESCE\(isbn, ''\), version, created_at, updated_at, deleted_at FROM books`
+
+// bookColumns are the columns of the rows selectBooks returns.
+var bookColumns = []string{"id", "title", "description", "published_at", "author_id", "price", "google_id", "isbn", "version", "created_at", "updated_at", "deleted_at"}
+
+// stamp is the creation and update time of the rows the tests read.
+var stamp = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// selectContributors matches the query loading the contributors of books.
+const selectContributors = `SELECT ba.book_id, ba.author_id, a.name, ba.role FROM book_authors ba JOIN authors a ON a.id = ba.author_id WHERE ba.book_id = ANY\(\$1\) ORDER BY ba.book_id, ba.position`
+
+// insertContributors matches the query storing the contributors of a book.
+const insertContributors = `INSERT INTO book_authors \(book_id, author_id, role, position\)`
+
+var contributorColumns = []string{"book_id", "author_id", "name", "role"}
+
+// selectLabels matches the query loading the genres and tags of books.
+const selectLabels = `SELECT bg.book_id, 'genre', g.slug FROM book_genres bg`
+
+var labelColumns = []string{"book_id", "kind", "label"}
+
+// insertAudit matches the query recording a write in the audit log.
+const insertAudit = `INSERT INTO audit_log \(entity, entity_id, revision, action, actor, changes\)`
+
+// expectAudit expects a write by an anonymous actor to be recorded with
+// the given JSON changes.
+func expectAudit(mockPool pgxmock.PgxPoolIface, entity string, id, revision int, action, changes string) {
+	mockPool.ExpectExec(insertAudit).
+		WithArgs(entity, id, revision, action, auth.ActorAnonymous, []byte(changes)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+}
+
 func setupMockRepo(t *testing.T) (pgxmock.PgxPoolIface, *postgres.Book, func()) {
 	mockPool, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
-	repo := postgres.NewBookRepository(mockPool)
+	repo := postgres.NewBookRepository(mockPool, slog.New(slog.DiscardHandler))
 
 	cleanup := func() {
 		if err := mockPool.ExpectationsWereMet(); err != nil {
@@ -35,6 +74,7 @@ func TestBookRepository_FindById(t *testing.T) {
 
 	tests := []struct {
 		name         string
+		ctx          context.Context
 		bookID       int
 		mockSetup    func(mockPool pgxmock.PgxPoolIface)
 		expectedBook *entities.Book
@@ -52,12 +92,23 @@ func TestBookRepository_FindById(t *testing.T) {
 					AuthorID:    101,
 					Price:       19.99,
 				}
-				rows := pgxmock.NewRows([]string{"id", "title", "description", "published_at", "author_id", "price"}).
-					AddRow(expectedBook.ID, expectedBook.Title, expectedBook.Description, expectedBook.PublishedAt, expectedBook.AuthorID, expectedBook.Price)
+				rows := pgxmock.NewRows(bookColumns).
+					AddRow(expectedBook.ID, expectedBook.Title, expectedBook.Description, expectedBook.PublishedAt, expectedBook.AuthorID, expectedBook.Price, expectedBook.GoogleID, expectedBook.ISBN13, 1, stamp, stamp, nil)
 
-				mockPool.ExpectQuery(`SELECT id, title, description, published_at, author_id, price FROM books WHERE id = \$1`).
-					WithArgs(1).
+				mockPool.ExpectQuery(selectBooks+` WHERE id = \$1 AND \(\$2 OR deleted_at IS NULL\)`).
+					WithArgs(1, false).
 					WillReturnRows(rows)
+				mockPool.ExpectQuery(selectContributors).
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows(contributorColumns).
+						AddRow(1, 101, "Main Author", "author").
+						AddRow(1, 102, "Some Translator", "translator"))
+				mockPool.ExpectQuery(selectLabels).
+					WithArgs([]int{1}).
+					WillReturnRows(pgxmock.NewRows(labelColumns).
+						AddRow(1, "genre", "fantasy").
+						AddRow(1, "tag", "award-winner").
+						AddRow(1, "genre", "high-fantasy"))
 			},
 			expectedBook: &entities.Book{
 				ID:          1,
@@ -66,26 +117,58 @@ func TestBookRepository_FindById(t *testing.T) {
 				PublishedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
 				AuthorID:    101,
 				Price:       19.99,
+				Contributors: []entities.Contributor{
+					{AuthorID: 101, Name: "Main Author", Role: entities.RoleAuthor},
+					{AuthorID: 102, Name: "Some Translator", Role: entities.RoleTranslator},
+				},
+				Genres: []string{"fantasy", "high-fantasy"},
+				Tags:   []string{"award-winner"},
 			},
 			expectedErr: nil,
 		},
 		{
-			name:   "should return ErrNoRows when book not found",
+			name:   "should return a deleted book when asked to",
+			ctx:    storage.WithDeleted(ctx),
+			bookID: 5,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectQuery(selectBooks+` WHERE id = \$1 AND \(\$2 OR deleted_at IS NULL\)`).
+					WithArgs(5, true).
+					WillReturnRows(pgxmock.NewRows(bookColumns).AddRow(5, "Deleted", "", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), 101, 9.5, "", "", 2, stamp, stamp, &stamp))
+				mockPool.ExpectQuery(selectContributors).
+					WithArgs([]int{5}).
+					WillReturnRows(pgxmock.NewRows(contributorColumns))
+				mockPool.ExpectQuery(selectLabels).
+					WithArgs([]int{5}).
+					WillReturnRows(pgxmock.NewRows(labelColumns))
+			},
+			expectedBook: &entities.Book{
+				ID:           5,
+				Title:        "Deleted",
+				PublishedAt:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+				AuthorID:     101,
+				Price:        9.5,
+				Contributors: []entities.Contributor{},
+				Genres:       []string{},
+				Tags:         []string{},
+			},
+		},
+		{
+			name:   "should return ErrNotFound when book not found",
 			bookID: 999,
 			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
-				mockPool.ExpectQuery(`SELECT id, title, description, published_at, author_id, price FROM books WHERE id = \$1`).
-					WithArgs(999).
+				mockPool.ExpectQuery(selectBooks+` WHERE id = \$1 AND \(\$2 OR deleted_at IS NULL\)`).
+					WithArgs(999, false).
 					WillReturnError(pgx.ErrNoRows) // Simulate no rows found
 			},
 			expectedBook: nil,
-			expectedErr:  pgx.ErrNoRows,
+			expectedErr:  apperr.ErrNotFound,
 		},
 		{
 			name:   "should return error for database query failure",
 			bookID: 2,
 			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
-				mockPool.ExpectQuery(`SELECT id, title, description, published_at, author_id, price FROM books WHERE id = \$1`).
-					WithArgs(2).
+				mockPool.ExpectQuery(selectBooks+` WHERE id = \$1 AND \(\$2 OR deleted_at IS NULL\)`).
+					WithArgs(2, false).
 					WillReturnError(errors.New("db connection lost")) // Simulate a generic DB error
 			},
 			expectedBook: nil,
@@ -100,12 +183,16 @@ func TestBookRepository_FindById(t *testing.T) {
 
 			tc.mockSetup(mockPool)
 
-			foundBook, err := repo.FindById(ctx, tc.bookID)
+			findCtx := ctx
+			if tc.ctx != nil {
+				findCtx = tc.ctx
+			}
+			foundBook, err := repo.FindById(findCtx, tc.bookID)
 
 			if tc.expectedErr != nil {
 				assert.Error(t, err)
-				if errors.Is(tc.expectedErr, pgx.ErrNoRows) {
-					assert.True(t, errors.Is(err, pgx.ErrNoRows), "expected error to be pgx.ErrNoRows")
+				if errors.Is(tc.expectedErr, apperr.ErrNotFound) {
+					assert.True(t, errors.Is(err, apperr.ErrNotFound), "expected error to be apperr.ErrNotFound")
 				} else {
 					assert.Contains(t, err.Error(), tc.expectedErr.Error())
 				}
@@ -119,6 +206,9 @@ func TestBookRepository_FindById(t *testing.T) {
 				assert.WithinDuration(t, tc.expectedBook.PublishedAt, foundBook.PublishedAt, time.Second)
 				assert.Equal(t, tc.expectedBook.AuthorID, foundBook.AuthorID)
 				assert.Equal(t, tc.expectedBook.Price, foundBook.Price)
+				assert.Equal(t, tc.expectedBook.Contributors, foundBook.Contributors)
+				assert.Equal(t, tc.expectedBook.Genres, foundBook.Genres)
+				assert.Equal(t, tc.expectedBook.Tags, foundBook.Tags)
 			}
 		})
 	}
@@ -141,13 +231,26 @@ func TestBookRepository_Create(t *testing.T) {
 				PublishedAt: time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC),
 				AuthorID:    201,
 				Price:       29.99,
+				Contributors: []entities.Contributor{
+					{AuthorID: 201, Role: entities.RoleAuthor},
+					{AuthorID: 202, Role: entities.RoleIllustrator},
+				},
 			},
 			mockSetup: func(mockPool pgxmock.PgxPoolIface, book *entities.Book) {
-				// Expect an INSERT query returning the ID
-				// Adjust regex to exactly match your INSERT query in postgres/book.go
-				mockPool.ExpectQuery(`INSERT INTO books \(title, description, published_at, author_id, price\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id`).
-					WithArgs(book.Title, book.Description, book.PublishedAt, book.AuthorID, book.Price).
-					WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(5)) // Simulate returning new ID 5
+				// The book and its contributors are inserted in one transaction
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(`INSERT INTO books \(title, description, published_at, author_id, price, isbn\) VALUES \(\$1, \$2, \$3, \$4, \$5, NULLIF\(\$6, ''\)\) RETURNING id, version, created_at, updated_at`).
+					WithArgs(book.Title, book.Description, book.PublishedAt, book.AuthorID, book.Price, book.ISBN13).
+					WillReturnRows(pgxmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(5, 1, stamp, stamp)) // Simulate returning new ID 5
+				mockPool.ExpectExec(insertContributors).
+					WithArgs(5, []int{201, 202}, []string{"author", "illustrator"}).
+					WillReturnResult(pgxmock.NewResult("INSERT", 2))
+				expectAudit(mockPool, "book", 5, 1, "create", `{"author_id":{"before":null,"after":201},`+
+					`"contributors":{"before":null,"after":[{"author_id":201,"role":"author"},{"author_id":202,"role":"illustrator"}]},`+
+					`"description":{"before":null,"after":"A freshly created book."},"genres":{"before":null,"after":[]},`+
+					`"price":{"before":null,"after":29.99},"published_at":{"before":null,"after":"2024-05-15T10:00:00Z"},`+
+					`"tags":{"before":null,"after":[]},"title":{"before":null,"after":"New Mocked Book"}}`)
+				mockPool.ExpectCommit()
 			},
 			expectedError: nil,
 		},
@@ -161,9 +264,11 @@ func TestBookRepository_Create(t *testing.T) {
 				Price:       15.00,
 			},
 			mockSetup: func(mockPool pgxmock.PgxPoolIface, book *entities.Book) {
-				mockPool.ExpectQuery(`INSERT INTO books \(title, description, published_at, author_id, price\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id`).
-					WithArgs(book.Title, book.Description, book.PublishedAt, book.AuthorID, book.Price).
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(`INSERT INTO books \(title, description, published_at, author_id, price, isbn\) VALUES \(\$1, \$2, \$3, \$4, \$5, NULLIF\(\$6, ''\)\) RETURNING id`).
+					WithArgs(book.Title, book.Description, book.PublishedAt, book.AuthorID, book.Price, book.ISBN13).
 					WillReturnError(errors.New("duplicate key error")) // Simulate a DB error
+				mockPool.ExpectRollback()
 			},
 			expectedError: errors.New("duplicate key error"),
 		},
@@ -194,12 +299,54 @@ func TestBookRepository_Create(t *testing.T) {
 	}
 }
 
+func TestBookRepository_CreateAfterDeleteWithTheSameISBN(t *testing.T) {
+	ctx := context.Background()
+	mockPool, repo, cleanup := setupMockRepo(t)
+	defer cleanup()
+
+	// The deleted book keeps its ISBN, which no longer blocks a new book
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(`UPDATE books SET deleted_at = now\(\)`).
+		WithArgs(1, 0).
+		WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(2))
+	expectAudit(mockPool, "book", 1, 2, "delete", `{}`)
+	mockPool.ExpectCommit()
+	mockPool.ExpectBegin()
+	mockPool.ExpectQuery(`INSERT INTO books \(title, description, published_at, author_id, price, isbn\)`).
+		WithArgs("Dune", "", stamp, 4, 9.99, "9780441172719").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(2, 1, stamp, stamp))
+	mockPool.ExpectExec(insertContributors).
+		WithArgs(2, []int{4}, []string{"author"}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	expectAudit(mockPool, "book", 2, 1, "create", `{"author_id":{"before":null,"after":4},`+
+		`"contributors":{"before":null,"after":[{"author_id":4,"role":"author"}]},"description":{"before":null,"after":""},`+
+		`"genres":{"before":null,"after":[]},"isbn_13":{"before":null,"after":"9780441172719"},"price":{"before":null,"after":9.99},`+
+		`"published_at":{"before":null,"after":"2024-05-01T12:00:00Z"},"tags":{"before":null,"after":[]},"title":{"before":null,"after":"Dune"}}`)
+	mockPool.ExpectCommit()
+
+	require.NoError(t, repo.Delete(ctx, 1, 0))
+	book := &entities.Book{
+		Title:        "Dune",
+		PublishedAt:  stamp,
+		AuthorID:     4,
+		Price:        9.99,
+		ISBN13:       "9780441172719",
+		Contributors: []entities.Contributor{{AuthorID: 4, Role: entities.RoleAuthor}},
+	}
+	err := repo.Create(ctx, book)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, book.ID)
+}
+
 func TestBookRepository_Delete(t *testing.T) {
 	ctx := context.Background()
+	deleteBook := `UPDATE books SET deleted_at = now\(\), updated_at = now\(\), version = version \+ 1 WHERE id = \$1 AND deleted_at IS NULL AND \(\$2 = 0 OR version = \$2\)`
 
 	tests := []struct {
 		name           string
 		bookIDToDelete int
+		version        int
 		mockSetup      func(mockPool pgxmock.PgxPoolIface)
 		expectedError  error
 	}{
@@ -207,9 +354,26 @@ func TestBookRepository_Delete(t *testing.T) {
 			name:           "should successfully delete a book",
 			bookIDToDelete: 1,
 			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
-				mockPool.ExpectExec(`DELETE FROM books WHERE id = \$1`).
-					WithArgs(1).
-					WillReturnResult(pgxmock.NewResult("DELETE", 1)) // Simulate 1 row affected
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteBook).
+					WithArgs(1, 0).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(2))
+				expectAudit(mockPool, "book", 1, 2, "delete", `{}`)
+				mockPool.ExpectCommit()
+			},
+			expectedError: nil,
+		},
+		{
+			name:           "should delete the expected version",
+			bookIDToDelete: 1,
+			version:        3,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteBook).
+					WithArgs(1, 3).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(4))
+				expectAudit(mockPool, "book", 1, 4, "delete", `{}`)
+				mockPool.ExpectCommit()
 			},
 			expectedError: nil,
 		},
@@ -217,21 +381,41 @@ func TestBookRepository_Delete(t *testing.T) {
 			name:           "should return error if book not found for delete",
 			bookIDToDelete: 999,
 			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
-				mockPool.ExpectExec(`DELETE FROM books WHERE id = \$1`).
-					WithArgs(999).
-					WillReturnResult(pgxmock.NewResult("DELETE", 0)) // Simulate 0 rows affected
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteBook).
+					WithArgs(999, 0).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
 			},
 			// Your repository's Delete method should return an error like "not found"
-			// if RowsAffected is 0. Adjust this expected error string accordingly.
+			// if no row was deleted. Adjust this expected error string accordingly.
 			expectedError: errors.New("book with ID 999 not found for delete"),
 		},
+		{
+			name:           "should return a conflict if the book is at another version",
+			bookIDToDelete: 1,
+			version:        3,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteBook).
+					WithArgs(1, 3).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(`SELECT version FROM books WHERE id = \$1 AND deleted_at IS NULL`).
+					WithArgs(1).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(4))
+			},
+			expectedError: errors.New("book with ID 1 is at version 4, not 3"),
+		},
 		{
 			name:           "should return error on database delete failure",
 			bookIDToDelete: 2,
 			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
-				mockPool.ExpectExec(`DELETE FROM books WHERE id = \$1`).
-					WithArgs(2).
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(deleteBook).
+					WithArgs(2, 0).
 					WillReturnError(errors.New("db error during delete")) // Simulate a generic DB error
+				mockPool.ExpectRollback()
 			},
 			expectedError: errors.New("db error during delete"),
 		},
@@ -245,7 +429,7 @@ func TestBookRepository_Delete(t *testing.T) {
 			tc.mockSetup(mockPool)
 
 			// Act
-			err := repo.Delete(ctx, tc.bookIDToDelete)
+			err := repo.Delete(ctx, tc.bookIDToDelete, tc.version)
 
 			// Assert
 			if tc.expectedError != nil {
@@ -258,4 +442,760 @@ func TestBookRepository_Delete(t *testing.T) {
 	}
 }
 
-// TODO: Add similar tests for Update and FindAll methods
+func TestBookRepository_Restore(t *testing.T) {
+	ctx := context.Background()
+	restoreBook := `UPDATE books SET deleted_at = NULL, updated_at = now\(\), version = version \+ 1 WHERE id = \$1 AND deleted_at IS NOT NULL AND \(\$2 = 0 OR version = \$2\)`
+	deletedAt := stamp.Add(time.Hour)
+
+	// findBook expects the book to be read back, deleted or not
+	findBook := func(mockPool pgxmock.PgxPoolIface, version int, deletedAt *time.Time) {
+		mockPool.ExpectQuery(selectBooks+` WHERE id = \$1 AND \(\$2 OR deleted_at IS NULL\)`).
+			WithArgs(1, true).
+			WillReturnRows(pgxmock.NewRows(bookColumns).AddRow(1, "Dune", "", stamp, 4, 9.99, "", "", version, stamp, stamp, deletedAt))
+		mockPool.ExpectQuery(selectContributors).
+			WithArgs([]int{1}).
+			WillReturnRows(pgxmock.NewRows(contributorColumns))
+		mockPool.ExpectQuery(selectLabels).
+			WithArgs([]int{1}).
+			WillReturnRows(pgxmock.NewRows(labelColumns))
+	}
+
+	// lockAuthors expects the credited authors to be locked and read
+	lockAuthors := func(mockPool pgxmock.PgxPoolIface, rows *pgxmock.Rows) {
+		mockPool.ExpectQuery(`SELECT id, deleted_at IS NOT NULL FROM authors WHERE id IN \( SELECT author_id FROM books WHERE id = \$1 UNION SELECT author_id FROM book_authors WHERE book_id = \$1 \) ORDER BY id FOR SHARE`).
+			WithArgs(1).
+			WillReturnRows(rows)
+	}
+	creditedColumns := []string{"id", "deleted"}
+
+	tests := []struct {
+		name            string
+		version         int
+		mockSetup       func(mockPool pgxmock.PgxPoolIface)
+		expectedVersion int
+		expectedErr     error
+	}{
+		{
+			name:    "should restore a deleted book",
+			version: 2,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreBook).
+					WithArgs(1, 2).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(3))
+				lockAuthors(mockPool, pgxmock.NewRows(creditedColumns).AddRow(4, false).AddRow(5, false))
+				expectAudit(mockPool, "book", 1, 3, "restore", `{}`)
+				mockPool.ExpectCommit()
+				findBook(mockPool, 3, nil)
+			},
+			expectedVersion: 3,
+		},
+		{
+			name: "should return a book that is not deleted unchanged",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreBook).
+					WithArgs(1, 0).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				findBook(mockPool, 2, nil)
+			},
+			expectedVersion: 2,
+		},
+		{
+			name:    "should return a conflict if the book is at another version",
+			version: 1,
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreBook).
+					WithArgs(1, 1).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				findBook(mockPool, 2, &deletedAt)
+			},
+			expectedErr: storage.ErrVersionMismatch,
+		},
+		{
+			name: "should return a conflict if a credited author is deleted",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreBook).
+					WithArgs(1, 0).
+					WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(3))
+				lockAuthors(mockPool, pgxmock.NewRows(creditedColumns).AddRow(4, false).AddRow(5, true))
+				mockPool.ExpectRollback()
+			},
+			expectedErr: storage.ErrAuthorDeleted,
+		},
+		{
+			name: "should return a conflict if another book has taken the ISBN",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreBook).
+					WithArgs(1, 0).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "books_isbn_key"})
+				mockPool.ExpectRollback()
+			},
+			expectedErr: apperr.ErrConflict,
+		},
+		{
+			name: "should return a conflict if another book was imported from the Google volume",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreBook).
+					WithArgs(1, 0).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "books_google_id_key"})
+				mockPool.ExpectRollback()
+			},
+			expectedErr: apperr.ErrConflict,
+		},
+		{
+			name: "should return ErrNotFound when book not found",
+			mockSetup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectBegin()
+				mockPool.ExpectQuery(restoreBook).
+					WithArgs(1, 0).
+					WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectCommit()
+				mockPool.ExpectQuery(selectBooks+` WHERE id = \$1`).
+					WithArgs(1, true).
+					WillReturnError(pgx.ErrNoRows)
+			},
+			expectedErr: apperr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockRepo(t)
+			defer cleanup()
+			tc.mockSetup(mockPool)
+
+			book, err := repo.Restore(ctx, 1, tc.version)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, book)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedVersion, book.Version)
+			assert.Nil(t, book.DeletedAt)
+		})
+	}
+}
+
+func TestBookRepository_Purge(t *testing.T) {
+	ctx := context.Background()
+	// The purge of every book is recorded in the audit log
+	purgeBooks := `WITH purged AS \( DELETE FROM books WHERE deleted_at < \$1 RETURNING id, version \) INSERT INTO audit_log \(entity, entity_id, revision, action, actor\) SELECT 'book', id, version \+ 1, \$2, \$3 FROM purged`
+
+	t.Run("should remove the books deleted before the cutoff", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectExec(purgeBooks).
+			WithArgs(stamp, "purge", "purge").
+			WillReturnResult(pgxmock.NewResult("INSERT", 3))
+
+		n, err := repo.Purge(auth.WithActor(ctx, "purge"), stamp)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+	})
+
+	t.Run("should return error on database failure", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectExec(purgeBooks).
+			WithArgs(stamp, "purge", auth.ActorAnonymous).
+			WillReturnError(errors.New("db error during purge"))
+
+		_, err := repo.Purge(ctx, stamp)
+
+		assert.ErrorContains(t, err, "failed to purge deleted books")
+	})
+}
+
+func TestBookRepository_Update(t *testing.T) {
+	ctx := context.Background()
+	book := &entities.Book{
+		ID:           3,
+		Title:        "Dune",
+		PublishedAt:  time.Date(1965, 8, 1, 0, 0, 0, 0, time.UTC),
+		AuthorID:     4,
+		Contributors: []entities.Contributor{{AuthorID: 4, Role: entities.RoleAuthor}, {AuthorID: 9, Role: entities.RoleEditor}},
+		Genres:       []string{"science-fiction"},
+		Tags:         []string{"award-winner", "classic"},
+	}
+	updateBook := `UPDATE books SET title = \$1, description = \$2, published_at = \$3, author_id = \$4, price = \$5, isbn = NULLIF\(\$6, ''\), version = version \+ 1, updated_at = now\(\) WHERE id = \$7 AND deleted_at IS NULL AND \(\$8 = 0 OR version = \$8\) RETURNING version, updated_at`
+
+	// expectLock expects the stored book to be locked and read with its
+	// details, for the audit log
+	expectLock := func(mockPool pgxmock.PgxPoolIface, version int) {
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(selectBooks + ` WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+			WithArgs(3).
+			WillReturnRows(pgxmock.NewRows(bookColumns).
+				AddRow(3, "Dune", "", book.PublishedAt, 4, 9.99, "", "", version, stamp, stamp, nil))
+		mockPool.ExpectQuery(selectContributors).
+			WithArgs([]int{3}).
+			WillReturnRows(pgxmock.NewRows(contributorColumns).AddRow(3, 4, "Frank Herbert", "author"))
+		mockPool.ExpectQuery(selectLabels).
+			WithArgs([]int{3}).
+			WillReturnRows(pgxmock.NewRows(labelColumns).AddRow(3, "genre", "science-fiction"))
+	}
+
+	t.Run("should update the book and replace its contributors, genres and tags", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		expectLock(mockPool, 1)
+		mockPool.ExpectQuery(updateBook).
+			WithArgs(book.Title, book.Description, book.PublishedAt, 4, book.Price, book.ISBN13, 3, 0).
+			WillReturnRows(pgxmock.NewRows([]string{"version", "updated_at"}).AddRow(2, stamp))
+		mockPool.ExpectExec(`DELETE FROM book_authors WHERE book_id = \$1`).
+			WithArgs(3).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mockPool.ExpectExec(insertContributors).
+			WithArgs(3, []int{4, 9}, []string{"author", "editor"}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 2))
+		mockPool.ExpectExec(`DELETE FROM book_genres WHERE book_id = \$1`).
+			WithArgs(3).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mockPool.ExpectExec(`DELETE FROM book_tags WHERE book_id = \$1`).
+			WithArgs(3).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mockPool.ExpectExec(`INSERT INTO book_genres \(book_id, genre_id\) SELECT \$1, id FROM genres WHERE slug = ANY\(\$2\)`).
+			WithArgs(3, []string{"science-fiction"}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mockPool.ExpectExec(`INSERT INTO tags \(name\) SELECT unnest\(\$1::text\[\]\) ON CONFLICT \(name\) DO NOTHING`).
+			WithArgs([]string{"award-winner", "classic"}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mockPool.ExpectExec(`INSERT INTO book_tags \(book_id, tag_id\) SELECT \$1, id FROM tags WHERE name = ANY\(\$2\)`).
+			WithArgs(3, []string{"award-winner", "classic"}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 2))
+		// Contributor names, read from the authors, are not recorded
+		expectAudit(mockPool, "book", 3, 2, "update", `{"contributors":{"before":[{"author_id":4,"role":"author"}],`+
+			`"after":[{"author_id":4,"role":"author"},{"author_id":9,"role":"editor"}]},`+
+			`"price":{"before":9.99,"after":0},"tags":{"before":[],"after":["award-winner","classic"]}}`)
+		mockPool.ExpectCommit()
+
+		updated := *book
+		assert.NoError(t, repo.Update(ctx, &updated))
+		assert.Equal(t, 2, updated.Version)
+	})
+
+	t.Run("should return ErrNotFound for a missing book", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(selectBooks + ` WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+			WithArgs(3).
+			WillReturnError(pgx.ErrNoRows)
+		mockPool.ExpectCommit()
+
+		assert.ErrorIs(t, repo.Update(ctx, book), apperr.ErrNotFound)
+	})
+
+	t.Run("should return a conflict when the book is at another version", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		stale := *book
+		stale.Version = 1
+		expectLock(mockPool, 2)
+		mockPool.ExpectQuery(updateBook).
+			WithArgs(book.Title, book.Description, book.PublishedAt, 4, book.Price, book.ISBN13, 3, 1).
+			WillReturnError(pgx.ErrNoRows)
+		mockPool.ExpectCommit()
+		mockPool.ExpectQuery(`SELECT version FROM books WHERE id = \$1 AND deleted_at IS NULL`).
+			WithArgs(3).
+			WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(2))
+
+		err := repo.Update(ctx, &stale)
+
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+		assert.ErrorIs(t, err, storage.ErrVersionMismatch)
+	})
+
+	t.Run("should roll back when a contributor's author does not exist", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		expectLock(mockPool, 1)
+		mockPool.ExpectQuery(updateBook).
+			WithArgs(book.Title, book.Description, book.PublishedAt, 4, book.Price, book.ISBN13, 3, 0).
+			WillReturnRows(pgxmock.NewRows([]string{"version", "updated_at"}).AddRow(2, stamp))
+		mockPool.ExpectExec(`DELETE FROM book_authors WHERE book_id = \$1`).
+			WithArgs(3).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mockPool.ExpectExec(insertContributors).
+			WithArgs(3, []int{4, 9}, []string{"author", "editor"}).
+			WillReturnError(&pgconn.PgError{Code: "23503"})
+		mockPool.ExpectRollback()
+
+		assert.ErrorIs(t, repo.Update(ctx, book), apperr.ErrValidation)
+	})
+}
+
+func TestBookRepository_Patch(t *testing.T) {
+	ctx := context.Background()
+	publishedAt := time.Date(1965, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	// expectLock expects the book to be locked and read with its details
+	expectLock := func(mockPool pgxmock.PgxPoolIface, version int) {
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(selectBooks + ` WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+			WithArgs(3).
+			WillReturnRows(pgxmock.NewRows(bookColumns).
+				AddRow(3, "Dune", "", publishedAt, 4, 9.99, "B1hSG45JCX4C", "9780441172719", version, stamp, stamp, nil))
+		mockPool.ExpectQuery(selectContributors).
+			WithArgs([]int{3}).
+			WillReturnRows(pgxmock.NewRows(contributorColumns).AddRow(3, 4, "Frank Herbert", "author"))
+		mockPool.ExpectQuery(selectLabels).
+			WithArgs([]int{3}).
+			WillReturnRows(pgxmock.NewRows(labelColumns).AddRow(3, "genre", "science-fiction"))
+	}
+
+	t.Run("should write only the changed columns", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		expectLock(mockPool, 2)
+		mockPool.ExpectQuery(`UPDATE books SET price = \$1, version = version \+ 1, updated_at = now\(\) WHERE id = \$2 RETURNING version, updated_at`).
+			WithArgs(12.5, 3).
+			WillReturnRows(pgxmock.NewRows([]string{"version", "updated_at"}).AddRow(3, stamp))
+		expectAudit(mockPool, "book", 3, 3, "update", `{"price":{"before":9.99,"after":12.5}}`)
+		mockPool.ExpectCommit()
+
+		book, err := repo.Patch(ctx, 3, 2, func(book *entities.Book) error {
+			assert.Equal(t, []string{"science-fiction"}, book.Genres)
+			book.Price = 12.5
+			// Neither may be changed by a patch
+			book.ID, book.Version = 99, 99
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, book.ID)
+		assert.Equal(t, 3, book.Version)
+		assert.Equal(t, 12.5, book.Price)
+		assert.Equal(t, "B1hSG45JCX4C", book.GoogleID)
+	})
+
+	t.Run("should replace changed contributors", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		expectLock(mockPool, 2)
+		mockPool.ExpectQuery(`UPDATE books SET author_id = \$1, version = version \+ 1, updated_at = now\(\) WHERE id = \$2 RETURNING version, updated_at`).
+			WithArgs(9, 3).
+			WillReturnRows(pgxmock.NewRows([]string{"version", "updated_at"}).AddRow(3, stamp))
+		mockPool.ExpectExec(`DELETE FROM book_authors WHERE book_id = \$1`).
+			WithArgs(3).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mockPool.ExpectExec(insertContributors).
+			WithArgs(3, []int{9, 4}, []string{"author", "author"}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 2))
+		expectAudit(mockPool, "book", 3, 3, "update", `{"author_id":{"before":4,"after":9},`+
+			`"contributors":{"before":[{"author_id":4,"role":"author"}],"after":[{"author_id":9,"role":"author"},{"author_id":4,"role":"author"}]}}`)
+		mockPool.ExpectCommit()
+
+		_, err := repo.Patch(ctx, 3, 0, func(book *entities.Book) error {
+			book.Contributors = append([]entities.Contributor{{AuthorID: 9, Role: entities.RoleAuthor}}, book.Contributors...)
+			book.AuthorID = 9
+			return nil
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should write nothing when nothing changed", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		expectLock(mockPool, 2)
+		mockPool.ExpectCommit()
+
+		book, err := repo.Patch(ctx, 3, 0, func(book *entities.Book) error { return nil })
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, book.Version)
+	})
+
+	t.Run("should return what apply returns", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		expectLock(mockPool, 2)
+		mockPool.ExpectRollback()
+		rejected := apperr.Validation("title is required", nil)
+
+		_, err := repo.Patch(ctx, 3, 0, func(book *entities.Book) error { return rejected })
+
+		assert.Equal(t, rejected, err)
+	})
+
+	t.Run("should return a conflict when the book is at another version", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		expectLock(mockPool, 2)
+		mockPool.ExpectRollback()
+
+		_, err := repo.Patch(ctx, 3, 1, func(book *entities.Book) error {
+			t.Fatal("apply must not be called")
+			return nil
+		})
+
+		assert.ErrorIs(t, err, apperr.ErrConflict)
+		assert.ErrorIs(t, err, storage.ErrVersionMismatch)
+	})
+
+	t.Run("should return ErrNotFound for a missing book", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(selectBooks + ` WHERE id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+			WithArgs(3).
+			WillReturnError(pgx.ErrNoRows)
+		mockPool.ExpectRollback()
+
+		_, err := repo.Patch(ctx, 3, 0, func(book *entities.Book) error { return nil })
+
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+}
+
+func TestBookRepository_FindByAuthorID(t *testing.T) {
+	ctx := context.Background()
+	mockPool, repo, cleanup := setupMockRepo(t)
+	defer cleanup()
+
+	publishedAt := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := pgxmock.NewRows(bookColumns).
+		AddRow(1, "Book One", "First", publishedAt, 7, 10.0, "", "", 1, stamp, stamp, nil).
+		AddRow(2, "Book Two", "Second", publishedAt, 7, 12.5, "", "", 1, stamp, stamp, nil)
+	mockPool.ExpectQuery(selectBooks+` WHERE EXISTS \(SELECT 1 FROM book_authors ba WHERE ba.book_id = books.id AND ba.author_id = \$1\) AND \(\$2 OR deleted_at IS NULL\) ORDER BY published_at DESC`).
+		WithArgs(7, false).
+		WillReturnRows(rows)
+	mockPool.ExpectQuery(selectContributors).
+		WithArgs([]int{1, 2}).
+		WillReturnRows(pgxmock.NewRows(contributorColumns).
+			AddRow(1, 7, "Seven", "author").
+			AddRow(2, 3, "Three", "author").
+			AddRow(2, 7, "Seven", "editor"))
+	mockPool.ExpectQuery(selectLabels).
+		WithArgs([]int{1, 2}).
+		WillReturnRows(pgxmock.NewRows(labelColumns))
+
+	books, err := repo.FindByAuthorID(ctx, 7)
+
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+	assert.Equal(t, []entities.Contributor{{AuthorID: 7, Name: "Seven", Role: entities.RoleAuthor}}, books[0].Contributors)
+	assert.Equal(t, []entities.Contributor{
+		{AuthorID: 3, Name: "Three", Role: entities.RoleAuthor},
+		{AuthorID: 7, Name: "Seven", Role: entities.RoleEditor},
+	}, books[1].Contributors)
+}
+
+func TestBookRepository_FindByISBN(t *testing.T) {
+	ctx := context.Background()
+	publishedAt := time.Date(1965, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("should return the book with its ISBN-10", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectQuery(selectBooks+` WHERE isbn = \$1 AND \(\$2 OR deleted_at IS NULL\)`).
+			WithArgs("9780441172719", false).
+			WillReturnRows(pgxmock.NewRows(bookColumns).
+				AddRow(1, "Dune", "", publishedAt, 4, 9.99, "", "9780441172719", 1, stamp, stamp, nil))
+		mockPool.ExpectQuery(selectContributors).
+			WithArgs([]int{1}).
+			WillReturnRows(pgxmock.NewRows(contributorColumns).AddRow(1, 4, "Frank Herbert", "author"))
+		mockPool.ExpectQuery(selectLabels).
+			WithArgs([]int{1}).
+			WillReturnRows(pgxmock.NewRows(labelColumns))
+
+		book, err := repo.FindByISBN(ctx, "9780441172719")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "9780441172719", book.ISBN13)
+		assert.Equal(t, "0441172717", book.ISBN10)
+	})
+
+	t.Run("should return ErrNotFound for an unknown ISBN", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectQuery(selectBooks+` WHERE isbn = \$1 AND \(\$2 OR deleted_at IS NULL\)`).
+			WithArgs("9791090636071", false).
+			WillReturnError(pgx.ErrNoRows)
+
+		_, err := repo.FindByISBN(ctx, "9791090636071")
+
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+}
+
+func TestBookRepository_FindAll(t *testing.T) {
+	ctx := context.Background()
+	publishedAt := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	columns := bookColumns
+	minPrice := 5.0
+
+	t.Run("should apply filters, sorting and limit and return a next cursor", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectQuery(`SELECT COUNT\(\*\) FROM books WHERE EXISTS \(SELECT 1 FROM book_authors ba WHERE ba.book_id = books.id AND ba.author_id = \$1\) AND price >= \$2`).
+			WithArgs(7, minPrice).
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+		mockPool.ExpectQuery(selectBooks+` WHERE EXISTS \(SELECT 1 FROM book_authors ba WHERE ba.book_id = books.id AND ba.author_id = \$1\) AND price >= \$2 AND deleted_at IS NULL ORDER BY price ASC, id ASC LIMIT 3 OFFSET 0`).
+			WithArgs(7, minPrice).
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow(1, "A", "", publishedAt, 7, 5.0, "", "", 1, stamp, stamp, nil).
+				AddRow(2, "B", "", publishedAt, 7, 6.5, "", "", 1, stamp, stamp, nil).
+				AddRow(3, "C", "", publishedAt, 7, 8.0, "", "", 1, stamp, stamp, nil))
+		// Only the books of the page get their contributors
+		mockPool.ExpectQuery(selectContributors).
+			WithArgs([]int{1, 2}).
+			WillReturnRows(pgxmock.NewRows(contributorColumns))
+		mockPool.ExpectQuery(selectLabels).
+			WithArgs([]int{1, 2}).
+			WillReturnRows(pgxmock.NewRows(labelColumns))
+
+		page, err := repo.FindAll(ctx, entities.BookQuery{Limit: 2, Sort: entities.SortByPrice, AuthorID: 7, MinPrice: &minPrice})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+		assert.Len(t, page.Items, 2)
+		assert.NotEmpty(t, page.NextCursor)
+
+		// The cursor resumes after the last book of the page
+		mockPool.ExpectQuery(`SELECT COUNT\(\*\) FROM books WHERE EXISTS \(SELECT 1 FROM book_authors ba WHERE ba.book_id = books.id AND ba.author_id = \$1\) AND price >= \$2`).
+			WithArgs(7, minPrice).
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+		mockPool.ExpectQuery(`FROM books WHERE EXISTS \(SELECT 1 FROM book_authors ba WHERE ba.book_id = books.id AND ba.author_id = \$1\) AND price >= \$2 AND deleted_at IS NULL AND \(price, id\) > \(\$3::numeric, \$4\) ORDER BY price ASC, id ASC LIMIT 3 OFFSET 0`).
+			WithArgs(7, minPrice, "6.5", 2).
+			WillReturnRows(pgxmock.NewRows(columns).AddRow(3, "C", "", publishedAt, 7, 8.0, "", "", 1, stamp, stamp, nil))
+		mockPool.ExpectQuery(selectContributors).
+			WithArgs([]int{3}).
+			WillReturnRows(pgxmock.NewRows(contributorColumns).AddRow(3, 7, "Seven", "author"))
+		mockPool.ExpectQuery(selectLabels).
+			WithArgs([]int{3}).
+			WillReturnRows(pgxmock.NewRows(labelColumns))
+
+		next, err := repo.FindAll(ctx, entities.BookQuery{Limit: 2, Sort: entities.SortByPrice, AuthorID: 7, MinPrice: &minPrice, Cursor: page.NextCursor})
+
+		assert.NoError(t, err)
+		assert.Len(t, next.Items, 1)
+		assert.Empty(t, next.NextCursor)
+	})
+
+	t.Run("should default to newest first without a next cursor on the last page", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectQuery(`SELECT COUNT\(\*\) FROM books`).
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+		mockPool.ExpectQuery(`FROM books WHERE deleted_at IS NULL ORDER BY published_at DESC, id DESC LIMIT 21 OFFSET 0`).
+			WillReturnRows(pgxmock.NewRows(columns).AddRow(1, "A", "", publishedAt, 7, 5.0, "", "", 1, stamp, stamp, nil))
+		mockPool.ExpectQuery(selectContributors).
+			WithArgs([]int{1}).
+			WillReturnRows(pgxmock.NewRows(contributorColumns))
+		mockPool.ExpectQuery(selectLabels).
+			WithArgs([]int{1}).
+			WillReturnRows(pgxmock.NewRows(labelColumns))
+
+		page, err := repo.FindAll(ctx, entities.BookQuery{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entities.DefaultBookLimit, page.Limit)
+		assert.Len(t, page.Items, 1)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("should filter by genre, including subgenres, and by every tag", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		where := `WHERE EXISTS \(SELECT 1 FROM book_genres bg WHERE bg.book_id = books.id AND bg.genre_id IN \( WITH RECURSIVE subtree AS \( SELECT id FROM genres WHERE slug = \$1 UNION SELECT g.id FROM genres g JOIN subtree s ON g.parent_id = s.id \) SELECT id FROM subtree\)\)` +
+			` AND EXISTS \(SELECT 1 FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.book_id = books.id AND t.name = \$2\)` +
+			` AND EXISTS \(SELECT 1 FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.book_id = books.id AND t.name = \$3\)` +
+			` AND deleted_at IS NULL`
+		mockPool.ExpectQuery(`SELECT COUNT\(\*\) FROM books `+where).
+			WithArgs("fantasy", "award-winner", "classic").
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+		mockPool.ExpectQuery(selectBooks+` `+where).
+			WithArgs("fantasy", "award-winner", "classic").
+			WillReturnRows(pgxmock.NewRows(columns))
+
+		page, err := repo.FindAll(ctx, entities.BookQuery{Genre: "fantasy", Tags: []string{"award-winner", "classic"}})
+
+		assert.NoError(t, err)
+		assert.Empty(t, page.Items)
+	})
+
+	t.Run("should reject a cursor issued for another sort order", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectQuery(`SELECT COUNT\(\*\) FROM books`).
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+		mockPool.ExpectQuery(`FROM books WHERE deleted_at IS NULL ORDER BY price ASC, id ASC LIMIT 2 OFFSET 0`).
+			WillReturnRows(pgxmock.NewRows(columns).
+				AddRow(1, "A", "", publishedAt, 7, 5.0, "", "", 1, stamp, stamp, nil).
+				AddRow(2, "B", "", publishedAt, 7, 6.5, "", "", 1, stamp, stamp, nil))
+		mockPool.ExpectQuery(selectContributors).
+			WithArgs([]int{1}).
+			WillReturnRows(pgxmock.NewRows(contributorColumns))
+		mockPool.ExpectQuery(selectLabels).
+			WithArgs([]int{1}).
+			WillReturnRows(pgxmock.NewRows(labelColumns))
+
+		page, err := repo.FindAll(ctx, entities.BookQuery{Limit: 1, Sort: entities.SortByPrice})
+		assert.NoError(t, err)
+
+		mockPool.ExpectQuery(`SELECT COUNT\(\*\) FROM books`).
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+
+		_, err = repo.FindAll(ctx, entities.BookQuery{Limit: 1, Sort: entities.SortByTitle, Cursor: page.NextCursor})
+		assert.ErrorIs(t, err, storage.ErrInvalidCursor)
+	})
+}
+
+func TestBookRepository_ErrorKinds(t *testing.T) {
+	ctx := context.Background()
+	book := &entities.Book{Title: "T", AuthorID: 404, PublishedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name         string
+		dbErr        error
+		expectedKind error
+	}{
+		{name: "missing author is a validation error", dbErr: &pgconn.PgError{Code: "23503"}, expectedKind: apperr.ErrValidation},
+		{name: "unique violation is a conflict", dbErr: &pgconn.PgError{Code: "23505"}, expectedKind: apperr.ErrConflict},
+		{name: "taken ISBN is a conflict", dbErr: &pgconn.PgError{Code: "23505", ConstraintName: "books_isbn_key"}, expectedKind: apperr.ErrConflict},
+		{name: "connection failure is unavailable", dbErr: &pgconn.PgError{Code: "08006"}, expectedKind: apperr.ErrUnavailable},
+		{name: "deadline is unavailable", dbErr: context.DeadlineExceeded, expectedKind: apperr.ErrUnavailable},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockRepo(t)
+			defer cleanup()
+
+			mockPool.ExpectBegin()
+			mockPool.ExpectQuery(`INSERT INTO books`).
+				WithArgs(book.Title, book.Description, book.PublishedAt, book.AuthorID, book.Price, book.ISBN13).
+				WillReturnError(tc.dbErr)
+			mockPool.ExpectRollback()
+
+			err := repo.Create(ctx, book)
+
+			assert.ErrorIs(t, err, tc.expectedKind)
+		})
+	}
+}
+
+func TestBookRepository_UpsertByGoogleID(t *testing.T) {
+	ctx := context.Background()
+	publishedAt := time.Date(1965, 8, 1, 0, 0, 0, 0, time.UTC)
+	upsert := `INSERT INTO books \(title, description, published_at, author_id, price, google_id, isbn\) .* ON CONFLICT \(google_id\) WHERE deleted_at IS NULL DO UPDATE SET .* version = books.version \+ 1, updated_at = now\(\) RETURNING id, author_id, version, created_at, updated_at, \(xmax = 0\) AS created`
+	upsertColumns := []string{"id", "author_id", "version", "created_at", "updated_at", "created"}
+
+	// imported returns the book as read from the volume, credited to its
+	// first author
+	imported := func() *entities.Book {
+		return &entities.Book{
+			Title:        "Dune",
+			PublishedAt:  publishedAt,
+			AuthorID:     4,
+			Price:        9.99,
+			GoogleID:     "B1hSG45JCX4C",
+			ISBN13:       "9780441172719",
+			Contributors: []entities.Contributor{{AuthorID: 4, Role: entities.RoleAuthor}},
+		}
+	}
+
+	t.Run("should create and credit a new book", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(selectBooks + ` WHERE google_id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+			WithArgs("B1hSG45JCX4C").
+			WillReturnError(pgx.ErrNoRows)
+		mockPool.ExpectQuery(upsert).
+			WithArgs("Dune", "", publishedAt, 4, 9.99, "B1hSG45JCX4C", "9780441172719").
+			WillReturnRows(pgxmock.NewRows(upsertColumns).AddRow(10, 4, 1, stamp, stamp, true))
+		mockPool.ExpectExec(`DELETE FROM book_authors WHERE book_id = \$1`).
+			WithArgs(10).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		mockPool.ExpectExec(insertContributors).
+			WithArgs(10, []int{4}, []string{"author"}).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		expectAudit(mockPool, "book", 10, 1, "create", `{"author_id":{"before":null,"after":4},"contributors":{"before":null,"after":[{"author_id":4,"role":"author"}]},`+
+			`"description":{"before":null,"after":""},"genres":{"before":null,"after":[]},"google_id":{"before":null,"after":"B1hSG45JCX4C"},`+
+			`"isbn_13":{"before":null,"after":"9780441172719"},"price":{"before":null,"after":9.99},`+
+			`"published_at":{"before":null,"after":"1965-08-01T00:00:00Z"},"tags":{"before":null,"after":[]},"title":{"before":null,"after":"Dune"}}`)
+		mockPool.ExpectCommit()
+
+		book := imported()
+		created, err := repo.UpsertByGoogleID(ctx, book)
+
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, 10, book.ID)
+		assert.Equal(t, 1, book.Version)
+	})
+
+	t.Run("should refresh the volume's fields and keep edited contributors", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockRepo(t)
+		defer cleanup()
+
+		// Since the first import, a curator made author 5 the primary author
+		// and credited a translator; the book was also given a genre
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(selectBooks + ` WHERE google_id = \$1 AND deleted_at IS NULL FOR UPDATE`).
+			WithArgs("B1hSG45JCX4C").
+			WillReturnRows(pgxmock.NewRows(bookColumns).
+				AddRow(10, "Dune", "Imported before", publishedAt, 5, 9.99, "B1hSG45JCX4C", "9780441172719", 3, stamp, stamp, nil))
+		mockPool.ExpectQuery(selectContributors).
+			WithArgs([]int{10}).
+			WillReturnRows(pgxmock.NewRows(contributorColumns).
+				AddRow(10, 5, "Brian Herbert", "author").
+				AddRow(10, 4, "Frank Herbert", "author").
+				AddRow(10, 6, "Kevin J. Anderson", "translator"))
+		mockPool.ExpectQuery(selectLabels).
+			WithArgs([]int{10}).
+			WillReturnRows(pgxmock.NewRows(labelColumns).AddRow(10, "genre", "science-fiction"))
+		mockPool.ExpectQuery(upsert).
+			WithArgs("Dune", "", publishedAt, 5, 9.99, "B1hSG45JCX4C", "9780441172719").
+			WillReturnRows(pgxmock.NewRows(upsertColumns).AddRow(10, 5, 4, stamp, stamp, false))
+		// The contributors are not rewritten
+		expectAudit(mockPool, "book", 10, 4, "update", `{"description":{"before":"Imported before","after":""}}`)
+		mockPool.ExpectCommit()
+
+		book := imported()
+		created, err := repo.UpsertByGoogleID(ctx, book)
+
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, 4, book.Version)
+		assert.Equal(t, 5, book.AuthorID)
+		assert.Equal(t, []entities.Contributor{
+			{AuthorID: 5, Name: "Brian Herbert", Role: entities.RoleAuthor},
+			{AuthorID: 4, Name: "Frank Herbert", Role: entities.RoleAuthor},
+			{AuthorID: 6, Name: "Kevin J. Anderson", Role: entities.RoleTranslator},
+		}, book.Contributors)
+		assert.Equal(t, []string{"science-fiction"}, book.Genres)
+	})
+}
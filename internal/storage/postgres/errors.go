@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/logging"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes the repositories translate into apperr kinds.
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	checkViolation      = "23514"
+)
+
+// queryLogger logs the failed queries of a repository at debug level.
+type queryLogger struct {
+	logger *slog.Logger
+}
+
+func newQueryLogger(logger *slog.Logger) queryLogger {
+	return queryLogger{logger: logging.Component(logger, "storage")}
+}
+
+// queryError logs err and classifies it with dbError.
+func (l queryLogger) queryError(ctx context.Context, message string, err error) error {
+	l.logger.DebugContext(ctx, message, "query", queryName(ctx), "err", err)
+	return dbError(message, err)
+}
+
+// versionError explains why a write of the entity with the given ID
+// matched no row: either the row is gone or deleted or, when a version was
+// expected, another write has moved it to a different version.
+func (l queryLogger) versionError(ctx context.Context, db PgxIface, table, entity string, id, version int, action string) error {
+	notFound := apperr.NotFound(fmt.Sprintf("%s with ID %d not found for %s", entity, id, action))
+	if version == 0 {
+		return notFound
+	}
+
+	var current int
+	query := `SELECT version FROM ` + table + ` WHERE id = $1 AND deleted_at IS NULL`
+	err := db.QueryRow(withQueryName(ctx, table+".find_version"), query, id).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return notFound
+	}
+	if err != nil {
+		return l.queryError(ctx, fmt.Sprintf("failed to find version of %s with ID %d", entity, id), err)
+	}
+	return apperr.Conflict(fmt.Sprintf("%s with ID %d is at version %d, not %d", entity, id, current, version), storage.ErrVersionMismatch)
+}
+
+// dbError wraps err with message and classifies it: constraint violations
+// become apperr.ErrConflict, connection problems apperr.ErrUnavailable.
+// Other errors are wrapped unchanged and surface as internal errors.
+func dbError(message string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == uniqueViolation:
+			return apperr.Conflict(message, err)
+		case pgErr.Code == checkViolation:
+			return apperr.Validation(message, err)
+		case isUnavailableCode(pgErr.Code):
+			return apperr.Unavailable(message, err)
+		}
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		pgconn.Timeout(err) {
+		return apperr.Unavailable(message, err)
+	}
+
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// isUnavailableCode reports connection exceptions (class 08), server
+// shutdowns and resource exhaustion (class 53).
+func isUnavailableCode(code string) bool {
+	return strings.HasPrefix(code, "08") ||
+		strings.HasPrefix(code, "53") ||
+		code == "57P01" || code == "57P02" || code == "57P03"
+}
@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Genre struct {
+	db PgxIface
+	queryLogger
+}
+
+func NewGenreRepository(db PgxIface, logger *slog.Logger) *Genre {
+	return &Genre{db: db, queryLogger: newQueryLogger(logger)}
+}
+
+// selectGenres reads genres with the number of distinct books filed under
+// each genre or any genre below it, not counting deleted books. %s is the
+// WHERE clause.
+const selectGenres = `
+	WITH RECURSIVE subtree AS (
+		SELECT id AS root_id, id FROM genres
+		UNION
+		SELECT s.root_id, g.id FROM genres g JOIN subtree s ON g.parent_id = s.id
+	)
+	SELECT
+		g.id,
+		g.slug,
+		g.name,
+		g.parent_id,
+		(SELECT COUNT(DISTINCT bg.book_id)
+			FROM subtree s
+			JOIN book_genres bg ON bg.genre_id = s.id
+			JOIN books b ON b.id = bg.book_id AND b.deleted_at IS NULL
+			WHERE s.root_id = g.id)
+	FROM
+		genres g
+	%s
+	ORDER BY g.name, g.id
+	`
+
+func scanGenre(row pgx.Row) (*entities.Genre, error) {
+	genre := &entities.Genre{}
+	err := row.Scan(&genre.ID, &genre.Slug, &genre.Name, &genre.ParentID, &genre.BookCount)
+	return genre, err
+}
+
+// FindAll returns every genre, sorted by name.
+func (g *Genre) FindAll(ctx context.Context) ([]*entities.Genre, error) {
+	ctx = withQueryName(ctx, "genres.find_all")
+
+	rows, err := g.db.Query(ctx, fmt.Sprintf(selectGenres, ""))
+	if err != nil {
+		return nil, g.queryError(ctx, "failed to execute query", err)
+	}
+	defer rows.Close()
+
+	genres := make([]*entities.Genre, 0)
+	for rows.Next() {
+		genre, err := scanGenre(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan genre row: %w", err)
+		}
+		genres = append(genres, genre)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, g.queryError(ctx, "error during rows iteration", err)
+	}
+
+	return genres, nil
+}
+
+func (g *Genre) FindByID(ctx context.Context, id int) (*entities.Genre, error) {
+	ctx = withQueryName(ctx, "genres.find_by_id")
+
+	genre, err := scanGenre(g.db.QueryRow(ctx, fmt.Sprintf(selectGenres, "WHERE g.id = $1"), id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.NotFound(fmt.Sprintf("genre with ID %d not found", id))
+		}
+		return nil, g.queryError(ctx, fmt.Sprintf("failed to find genre by ID %d", id), err)
+	}
+	return genre, nil
+}
+
+func (g *Genre) FindBySlug(ctx context.Context, slug string) (*entities.Genre, error) {
+	ctx = withQueryName(ctx, "genres.find_by_slug")
+
+	genre, err := scanGenre(g.db.QueryRow(ctx, fmt.Sprintf(selectGenres, "WHERE g.slug = $1"), slug))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.NotFound(fmt.Sprintf("genre %q not found", slug))
+		}
+		return nil, g.queryError(ctx, "failed to find genre by slug", err)
+	}
+	return genre, nil
+}
+
+func (g *Genre) Create(ctx context.Context, genre *entities.Genre) error {
+	ctx = withQueryName(ctx, "genres.create")
+
+	query := `
+	INSERT INTO genres (slug, name, parent_id)
+	VALUES ($1, $2, $3)
+	RETURNING id
+	`
+	err := g.db.QueryRow(ctx, query, genre.Slug, genre.Name, genre.ParentID).Scan(&genre.ID)
+	if err != nil {
+		return g.writeError(ctx, "failed to create genre", genre, err)
+	}
+	return nil
+}
+
+// Update renames or moves a genre. Moving a genre below itself or one of
+// its subgenres is rejected with storage.ErrGenreCycle.
+func (g *Genre) Update(ctx context.Context, genre *entities.Genre) error {
+	ctx = withQueryName(ctx, "genres.update")
+
+	cycleQuery := `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM genres WHERE id = $1
+		UNION
+		SELECT g.id FROM genres g JOIN subtree s ON g.parent_id = s.id
+	)
+	SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2)
+	`
+	updateQuery := `
+	UPDATE genres
+	SET
+		slug = $1,
+		name = $2,
+		parent_id = $3
+	WHERE
+		id = $4
+	`
+
+	var found, cycle bool
+	err := inTx(ctx, g.db, func(tx PgxIface) error {
+		if genre.ParentID != nil {
+			if err := tx.QueryRow(ctx, cycleQuery, genre.ID, *genre.ParentID).Scan(&cycle); err != nil || cycle {
+				return err
+			}
+		}
+		cmdTag, err := tx.Exec(ctx, updateQuery, genre.Slug, genre.Name, genre.ParentID, genre.ID)
+		found = err == nil && cmdTag.RowsAffected() > 0
+		return err
+	})
+	if err != nil {
+		return g.writeError(ctx, fmt.Sprintf("failed to update genre with ID %d", genre.ID), genre, err)
+	}
+	if cycle {
+		return apperr.Validation("a genre cannot be moved below itself or one of its subgenres", storage.ErrGenreCycle)
+	}
+	if !found {
+		return apperr.NotFound(fmt.Sprintf("genre with ID %d not found for update", genre.ID))
+	}
+	return nil
+}
+
+// Delete removes a genre and unfiles its books. Genres that still have
+// subgenres are rejected with storage.ErrGenreHasSubgenres.
+func (g *Genre) Delete(ctx context.Context, id int) error {
+	ctx = withQueryName(ctx, "genres.delete")
+
+	cmdTag, err := g.db.Exec(ctx, `DELETE FROM genres WHERE id = $1`, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return apperr.Conflict(fmt.Sprintf("genre with ID %d still has subgenres", id), storage.ErrGenreHasSubgenres)
+		}
+		return g.queryError(ctx, fmt.Sprintf("failed to delete genre with ID %d", id), err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return apperr.NotFound(fmt.Sprintf("genre with ID %d not found for delete", id))
+	}
+	return nil
+}
+
+// writeError reports a parent genre that does not exist as a validation
+// error and a slug that is taken as a conflict naming it.
+func (g *Genre) writeError(ctx context.Context, message string, genre *entities.Genre, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case foreignKeyViolation:
+			return apperr.Validation(fmt.Sprintf("parent genre with ID %d does not exist", *genre.ParentID), err)
+		case uniqueViolation:
+			return apperr.Conflict(fmt.Sprintf("a genre with slug %q already exists", genre.Slug), err)
+		}
+	}
+	return g.queryError(ctx, message, err)
+}
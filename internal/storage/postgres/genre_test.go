@@ -0,0 +1,215 @@
+package postgres_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage/postgres"
+)
+
+var genreColumns = []string{"id", "slug", "name", "parent_id", "book_count"}
+
+func setupMockGenreRepo(t *testing.T) (pgxmock.PgxPoolIface, *postgres.Genre, func()) {
+	mockPool, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	repo := postgres.NewGenreRepository(mockPool, slog.New(slog.DiscardHandler))
+
+	cleanup := func() {
+		if err := mockPool.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+		mockPool.Close()
+	}
+
+	return mockPool, repo, cleanup
+}
+
+func TestGenreRepository_FindAll(t *testing.T) {
+	ctx := context.Background()
+	mockPool, repo, cleanup := setupMockGenreRepo(t)
+	defer cleanup()
+
+	fantasy := 1
+	mockPool.ExpectQuery(`WITH RECURSIVE subtree AS .* FROM genres g ORDER BY g.name, g.id`).
+		WillReturnRows(pgxmock.NewRows(genreColumns).
+			AddRow(1, "fantasy", "Fantasy", nil, 5).
+			AddRow(2, "high-fantasy", "High fantasy", &fantasy, 2))
+
+	genres, err := repo.FindAll(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*entities.Genre{
+		{ID: 1, Slug: "fantasy", Name: "Fantasy", BookCount: 5},
+		{ID: 2, Slug: "high-fantasy", Name: "High fantasy", ParentID: &fantasy, BookCount: 2},
+	}, genres)
+}
+
+func TestGenreRepository_FindBySlug(t *testing.T) {
+	ctx := context.Background()
+	mockPool, repo, cleanup := setupMockGenreRepo(t)
+	defer cleanup()
+
+	mockPool.ExpectQuery(`FROM genres g WHERE g.slug = \$1`).
+		WithArgs("horror").
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err := repo.FindBySlug(ctx, "horror")
+
+	assert.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
+func TestGenreRepository_Create(t *testing.T) {
+	ctx := context.Background()
+	parent := 404
+
+	t.Run("should return the new ID", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockGenreRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectQuery(`INSERT INTO genres \(slug, name, parent_id\) VALUES \(\$1, \$2, \$3\) RETURNING id`).
+			WithArgs("fantasy", "Fantasy", (*int)(nil)).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(1))
+
+		genre := &entities.Genre{Slug: "fantasy", Name: "Fantasy"}
+		assert.NoError(t, repo.Create(ctx, genre))
+		assert.Equal(t, 1, genre.ID)
+	})
+
+	tests := []struct {
+		name         string
+		dbErr        error
+		expectedKind error
+	}{
+		{name: "missing parent is a validation error", dbErr: &pgconn.PgError{Code: "23503"}, expectedKind: apperr.ErrValidation},
+		{name: "taken slug is a conflict", dbErr: &pgconn.PgError{Code: "23505"}, expectedKind: apperr.ErrConflict},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockGenreRepo(t)
+			defer cleanup()
+
+			mockPool.ExpectQuery(`INSERT INTO genres`).
+				WithArgs("epic", "Epic", &parent).
+				WillReturnError(tc.dbErr)
+
+			err := repo.Create(ctx, &entities.Genre{Slug: "epic", Name: "Epic", ParentID: &parent})
+
+			assert.ErrorIs(t, err, tc.expectedKind)
+		})
+	}
+}
+
+func TestGenreRepository_Update(t *testing.T) {
+	ctx := context.Background()
+	parent := 2
+	genre := &entities.Genre{ID: 1, Slug: "fantasy", Name: "Fantasy", ParentID: &parent}
+	cycleQuery := `WITH RECURSIVE subtree AS .* SELECT EXISTS \(SELECT 1 FROM subtree WHERE id = \$2\)`
+	updateQuery := `UPDATE genres SET slug = \$1, name = \$2, parent_id = \$3 WHERE id = \$4`
+
+	t.Run("should move the genre", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockGenreRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(cycleQuery).
+			WithArgs(1, 2).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+		mockPool.ExpectExec(updateQuery).
+			WithArgs("fantasy", "Fantasy", &parent, 1).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mockPool.ExpectCommit()
+
+		assert.NoError(t, repo.Update(ctx, genre))
+	})
+
+	t.Run("should refuse to move a genre below one of its subgenres", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockGenreRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectBegin()
+		mockPool.ExpectQuery(cycleQuery).
+			WithArgs(1, 2).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+		mockPool.ExpectCommit()
+
+		err := repo.Update(ctx, genre)
+
+		assert.ErrorIs(t, err, apperr.ErrValidation)
+		assert.ErrorIs(t, err, storage.ErrGenreCycle)
+	})
+
+	t.Run("should return ErrNotFound for a missing genre", func(t *testing.T) {
+		mockPool, repo, cleanup := setupMockGenreRepo(t)
+		defer cleanup()
+
+		mockPool.ExpectBegin()
+		mockPool.ExpectExec(updateQuery).
+			WithArgs("fantasy", "Fantasy", (*int)(nil), 1).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mockPool.ExpectCommit()
+
+		err := repo.Update(ctx, &entities.Genre{ID: 1, Slug: "fantasy", Name: "Fantasy"})
+
+		assert.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+}
+
+func TestGenreRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		setup       func(mockPool pgxmock.PgxPoolIface)
+		expectedErr error
+	}{
+		{
+			name: "should delete the genre",
+			setup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectExec(`DELETE FROM genres WHERE id = \$1`).WithArgs(1).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			},
+		},
+		{
+			name: "should refuse a genre with subgenres",
+			setup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectExec(`DELETE FROM genres WHERE id = \$1`).WithArgs(1).WillReturnError(&pgconn.PgError{Code: "23503"})
+			},
+			expectedErr: storage.ErrGenreHasSubgenres,
+		},
+		{
+			name: "should return ErrNotFound for a missing genre",
+			setup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectExec(`DELETE FROM genres WHERE id = \$1`).WithArgs(1).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+			},
+			expectedErr: apperr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockGenreRepo(t)
+			defer cleanup()
+			tc.setup(mockPool)
+
+			err := repo.Delete(ctx, 1)
+
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
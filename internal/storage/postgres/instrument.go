@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// QueryObserver receives the duration and outcome of every named query.
+type QueryObserver interface {
+	ObserveQuery(name string, duration time.Duration, err error)
+}
+
+type queryNameKey struct{}
+
+// withQueryName labels the queries run with ctx, e.g. "books.find_by_id".
+func withQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+func queryName(ctx context.Context) string {
+	if name, ok := ctx.Value(queryNameKey{}).(string); ok {
+		return name
+	}
+	return "unnamed"
+}
+
+// instrumentedDB reports every query to a QueryObserver. Query durations
+// include reading the rows, which ends when the rows are closed or the
+// single row is scanned.
+type instrumentedDB struct {
+	db       PgxIface
+	observer QueryObserver
+}
+
+// Instrument wraps db so the repositories built on it report their queries.
+func Instrument(db PgxIface, observer QueryObserver) PgxIface {
+	return &instrumentedDB{db: db, observer: observer}
+}
+
+func (i *instrumentedDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	start := time.Now()
+	rows, err := i.db.Query(ctx, sql, args...)
+	if err != nil {
+		i.observer.ObserveQuery(queryName(ctx), time.Since(start), err)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, done: func(err error) {
+		i.observer.ObserveQuery(queryName(ctx), time.Since(start), err)
+	}}, nil
+}
+
+func (i *instrumentedDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	start := time.Now()
+	return &instrumentedRow{Row: i.db.QueryRow(ctx, sql, args...), done: func(err error) {
+		i.observer.ObserveQuery(queryName(ctx), time.Since(start), err)
+	}}
+}
+
+func (i *instrumentedDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	start := time.Now()
+	tag, err := i.db.Exec(ctx, sql, args...)
+	i.observer.ObserveQuery(queryName(ctx), time.Since(start), err)
+	return tag, err
+}
+
+// Begin starts a transaction whose queries are reported like the others.
+func (i *instrumentedDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := i.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedTx{Tx: tx, db: &instrumentedDB{db: tx, observer: i.observer}}, nil
+}
+
+type instrumentedTx struct {
+	pgx.Tx
+	db *instrumentedDB
+}
+
+func (t *instrumentedTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return t.db.Query(ctx, sql, args...)
+}
+
+func (t *instrumentedTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return t.db.QueryRow(ctx, sql, args...)
+}
+
+func (t *instrumentedTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return t.db.Exec(ctx, sql, args...)
+}
+
+type instrumentedRows struct {
+	pgx.Rows
+	done   func(err error)
+	closed bool
+}
+
+func (r *instrumentedRows) Close() {
+	r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.done(r.Rows.Err())
+	}
+}
+
+type instrumentedRow struct {
+	pgx.Row
+	done func(err error)
+}
+
+func (r *instrumentedRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	if err == pgx.ErrNoRows {
+		// Finding nothing is a normal query outcome, not a failure
+		r.done(nil)
+	} else {
+		r.done(err)
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage/postgres"
+)
+
+type observed struct {
+	name string
+	err  error
+}
+
+type fakeObserver struct {
+	queries []observed
+}
+
+func (f *fakeObserver) ObserveQuery(name string, _ time.Duration, err error) {
+	f.queries = append(f.queries, observed{name: name, err: err})
+}
+
+func TestInstrument_ReportsNamedQueries(t *testing.T) {
+	ctx := context.Background()
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	observer := &fakeObserver{}
+	repo := postgres.NewAuthorRepository(postgres.Instrument(mockPool, observer), slog.New(slog.DiscardHandler))
+
+	birth := time.Date(1965, 7, 31, 0, 0, 0, 0, time.UTC)
+	mockPool.ExpectQuery(selectAuthors + ` WHERE \$1 OR deleted_at IS NULL ORDER BY name, id`).
+		WithArgs(false).
+		WillReturnRows(pgxmock.NewRows(authorColumns).
+			AddRow(1, "J.K. Rowling", "British author", birth, 1, birth, birth, nil))
+	mockPool.ExpectQuery(`SELECT`).
+		WithArgs(42, false).
+		WillReturnError(pgx.ErrNoRows)
+	mockPool.ExpectExec(`WITH purged AS \( DELETE FROM authors`).
+		WithArgs(birth, "purge", "anonymous").
+		WillReturnError(errors.New("connection reset"))
+
+	_, err = repo.FindAll(ctx)
+	require.NoError(t, err)
+	_, err = repo.FindByID(ctx, 42)
+	require.Error(t, err)
+	_, err = repo.Purge(ctx, birth)
+	require.Error(t, err)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+	require.Len(t, observer.queries, 3)
+	assert.Equal(t, "authors.find_all", observer.queries[0].name)
+	assert.NoError(t, observer.queries[0].err)
+	// Not finding a row is not a query failure
+	assert.Equal(t, "authors.find_by_id", observer.queries[1].name)
+	assert.NoError(t, observer.queries[1].err)
+	assert.Equal(t, "authors.purge", observer.queries[2].name)
+	assert.Error(t, observer.queries[2].err)
+}
+
+func TestInstrument_ReportsQueriesInTransactions(t *testing.T) {
+	ctx := context.Background()
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	observer := &fakeObserver{}
+	db := postgres.Instrument(mockPool, observer)
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(`DELETE FROM book_authors`).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mockPool.ExpectCommit()
+
+	tx, err := db.Begin(ctx)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, `DELETE FROM book_authors`)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+	require.Len(t, observer.queries, 1)
+	assert.NoError(t, observer.queries[0].err)
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
+)
+
+// columnChanges accumulates the SET clauses and positional arguments of a
+// patch, one for each column whose value changed.
+type columnChanges struct {
+	set  []string
+	args []interface{}
+}
+
+// add assigns arg to a column if it changed. The assignment refers to arg
+// with ?, as in "title = ?".
+func (c *columnChanges) add(changed bool, assignment string, arg interface{}) {
+	if !changed {
+		return
+	}
+	c.args = append(c.args, arg)
+	c.set = append(c.set, strings.Replace(assignment, "?", fmt.Sprintf("$%d", len(c.args)), 1))
+}
+
+// update returns the UPDATE of the changed columns of the row with the
+// given ID. It also bumps the row's version and updated_at and returns
+// both.
+func (c *columnChanges) update(table string, id int) (string, []interface{}) {
+	set := append(c.set, "version = version + 1", "updated_at = now()")
+	args := append(c.args, id)
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d RETURNING version, updated_at`, table, strings.Join(set, ", "), len(args))
+	return query, args
+}
+
+// checkVersion returns the conflict of a patch that expected the row at
+// version, or nil when version is 0 or the stored one.
+func checkVersion(entity string, id, stored, version int) error {
+	if version == 0 || version == stored {
+		return nil
+	}
+	return apperr.Conflict(fmt.Sprintf("%s with ID %d is at version %d, not %d", entity, id, stored, version), storage.ErrVersionMismatch)
+}
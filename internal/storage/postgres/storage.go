@@ -2,13 +2,16 @@
 package postgres
 
 import (
+	"log/slog"
+
 	"github.com/demirbalemir/hop/Onboardingv2/internal/storage"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewRepository(db *pgxpool.Pool) *storage.Repository {
+func NewRepository(db PgxIface, logger *slog.Logger) *storage.Repository {
 	return &storage.Repository{
-		Book:   NewBookRepository(db),   // postgres.Book implements storage.BookRepository
-		Author: NewAuthorRepository(db), // postgres.Author implements storage.AuthorRepository
+		Book:   NewBookRepository(db, logger),   // postgres.Book implements storage.BookRepository
+		Author: NewAuthorRepository(db, logger), // postgres.Author implements storage.AuthorRepository
+		Genre:  NewGenreRepository(db, logger),
+		Tag:    NewTagRepository(db, logger),
 	}
 }
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Tag struct {
+	db PgxIface
+	queryLogger
+}
+
+func NewTagRepository(db PgxIface, logger *slog.Logger) *Tag {
+	return &Tag{db: db, queryLogger: newQueryLogger(logger)}
+}
+
+// selectTags reads tags with the number of books carrying each, not
+// counting deleted books. %s is the WHERE clause.
+const selectTags = `
+	SELECT
+		t.id,
+		t.name,
+		(SELECT COUNT(*) FROM book_tags bt JOIN books b ON b.id = bt.book_id
+			WHERE bt.tag_id = t.id AND b.deleted_at IS NULL)
+	FROM
+		tags t
+	%s
+	ORDER BY t.name
+	`
+
+func scanTag(row pgx.Row) (*entities.Tag, error) {
+	tag := &entities.Tag{}
+	err := row.Scan(&tag.ID, &tag.Name, &tag.BookCount)
+	return tag, err
+}
+
+// FindAll returns every tag, sorted by name.
+func (t *Tag) FindAll(ctx context.Context) ([]*entities.Tag, error) {
+	ctx = withQueryName(ctx, "tags.find_all")
+
+	rows, err := t.db.Query(ctx, fmt.Sprintf(selectTags, ""))
+	if err != nil {
+		return nil, t.queryError(ctx, "failed to execute query", err)
+	}
+	defer rows.Close()
+
+	tags := make([]*entities.Tag, 0)
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, t.queryError(ctx, "error during rows iteration", err)
+	}
+
+	return tags, nil
+}
+
+func (t *Tag) FindByID(ctx context.Context, id int) (*entities.Tag, error) {
+	ctx = withQueryName(ctx, "tags.find_by_id")
+
+	tag, err := scanTag(t.db.QueryRow(ctx, fmt.Sprintf(selectTags, "WHERE t.id = $1"), id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, apperr.NotFound(fmt.Sprintf("tag with ID %d not found", id))
+		}
+		return nil, t.queryError(ctx, fmt.Sprintf("failed to find tag by ID %d", id), err)
+	}
+	return tag, nil
+}
+
+func (t *Tag) Create(ctx context.Context, tag *entities.Tag) error {
+	ctx = withQueryName(ctx, "tags.create")
+
+	err := t.db.QueryRow(ctx, `INSERT INTO tags (name) VALUES ($1) RETURNING id`, tag.Name).Scan(&tag.ID)
+	if err != nil {
+		return t.writeError(ctx, "failed to create tag", tag, err)
+	}
+	return nil
+}
+
+// Update renames a tag.
+func (t *Tag) Update(ctx context.Context, tag *entities.Tag) error {
+	ctx = withQueryName(ctx, "tags.update")
+
+	cmdTag, err := t.db.Exec(ctx, `UPDATE tags SET name = $1 WHERE id = $2`, tag.Name, tag.ID)
+	if err != nil {
+		return t.writeError(ctx, fmt.Sprintf("failed to update tag with ID %d", tag.ID), tag, err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return apperr.NotFound(fmt.Sprintf("tag with ID %d not found for update", tag.ID))
+	}
+	return nil
+}
+
+// Delete removes a tag from every book and then the tag itself.
+func (t *Tag) Delete(ctx context.Context, id int) error {
+	ctx = withQueryName(ctx, "tags.delete")
+
+	cmdTag, err := t.db.Exec(ctx, `DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		return t.queryError(ctx, fmt.Sprintf("failed to delete tag with ID %d", id), err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return apperr.NotFound(fmt.Sprintf("tag with ID %d not found for delete", id))
+	}
+	return nil
+}
+
+// writeError reports a name that is taken as a conflict naming it.
+func (t *Tag) writeError(ctx context.Context, message string, tag *entities.Tag, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return apperr.Conflict(fmt.Sprintf("a tag named %q already exists", tag.Name), err)
+	}
+	return t.queryError(ctx, message, err)
+}
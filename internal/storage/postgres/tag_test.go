@@ -0,0 +1,99 @@
+package postgres_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/apperr"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
+	"github.com/demirbalemir/hop/Onboardingv2/internal/storage/postgres"
+)
+
+func setupMockTagRepo(t *testing.T) (pgxmock.PgxPoolIface, *postgres.Tag, func()) {
+	mockPool, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	repo := postgres.NewTagRepository(mockPool, slog.New(slog.DiscardHandler))
+
+	cleanup := func() {
+		if err := mockPool.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+		mockPool.Close()
+	}
+
+	return mockPool, repo, cleanup
+}
+
+func TestTagRepository_FindAll(t *testing.T) {
+	ctx := context.Background()
+	mockPool, repo, cleanup := setupMockTagRepo(t)
+	defer cleanup()
+
+	mockPool.ExpectQuery(`SELECT t.id, t.name, \(SELECT COUNT\(\*\) FROM book_tags bt JOIN books b ON b.id = bt.book_id WHERE bt.tag_id = t.id AND b.deleted_at IS NULL\) FROM tags t ORDER BY t.name`).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "book_count"}).
+			AddRow(1, "award-winner", 3).
+			AddRow(2, "classic", 0))
+
+	tags, err := repo.FindAll(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*entities.Tag{
+		{ID: 1, Name: "award-winner", BookCount: 3},
+		{ID: 2, Name: "classic"},
+	}, tags)
+}
+
+func TestTagRepository_Update(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		setup       func(mockPool pgxmock.PgxPoolIface)
+		expectedErr error
+	}{
+		{
+			name: "should rename the tag",
+			setup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectExec(`UPDATE tags SET name = \$1 WHERE id = \$2`).WithArgs("classic", 1).WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name: "should return a conflict for a taken name",
+			setup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectExec(`UPDATE tags`).WithArgs("classic", 1).WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			expectedErr: apperr.ErrConflict,
+		},
+		{
+			name: "should return ErrNotFound for a missing tag",
+			setup: func(mockPool pgxmock.PgxPoolIface) {
+				mockPool.ExpectExec(`UPDATE tags`).WithArgs("classic", 1).WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			expectedErr: apperr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockPool, repo, cleanup := setupMockTagRepo(t)
+			defer cleanup()
+			tc.setup(mockPool)
+
+			err := repo.Update(ctx, &entities.Tag{ID: 1, Name: "classic"})
+
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
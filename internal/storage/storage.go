@@ -9,24 +9,156 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/demirbalemir/hop/Onboardingv2/internal/entities"
 )
 
 type BookRepository interface {
-	FindAll(ctx context.Context) ([]*entities.Book, error)
+	FindAll(ctx context.Context, query entities.BookQuery) (*entities.BookPage, error)
 	FindById(ctx context.Context, id int) (*entities.Book, error)
+	FindByAuthorID(ctx context.Context, authorID int) ([]*entities.Book, error)
+	// FindByISBN returns the book with the given normalized ISBN-13.
+	FindByISBN(ctx context.Context, isbn13 string) (*entities.Book, error)
+	// Search runs a full-text search over titles, descriptions and
+	// contributor names.
+	Search(ctx context.Context, query entities.BookSearchQuery) (*entities.BookSearchPage, error)
 	Create(ctx context.Context, book *entities.Book) error
+	// Update overwrites the book and bumps its version. A non-zero
+	// book.Version must match the stored one.
 	Update(ctx context.Context, book *entities.Book) error
-	Delete(ctx context.Context, id int) error
+	// Patch locks the book, passes it to apply to change and writes back
+	// what apply changed, all in one transaction. A non-zero version must
+	// match the stored one. Errors of apply are returned as they are.
+	Patch(ctx context.Context, id, version int, apply func(book *entities.Book) error) (*entities.Book, error)
+	// Delete soft-deletes the book: it is no longer found, unless the
+	// context asks for deleted rows (see WithDeleted), until Restore. A
+	// non-zero version must match the stored one.
+	Delete(ctx context.Context, id, version int) error
+	// Restore undoes Delete and returns the book. Restoring a book that
+	// is not deleted changes nothing. A deleted book does not hold on to
+	// its ISBN or Google volume, so restoring it answers
+	// apperr.ErrConflict when another book has taken either since. A book
+	// crediting a deleted author cannot be restored either (see
+	// ErrAuthorDeleted).
+	Restore(ctx context.Context, id, version int) (*entities.Book, error)
+	// Purge removes the books deleted before deletedBefore for good and
+	// returns how many there were.
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// UpsertByGoogleID creates the book, or refreshes the fields that come
+	// from Google of the book imported from the same volume, keeping its
+	// credits and labels, and reports whether it was created.
+	UpsertByGoogleID(ctx context.Context, book *entities.Book) (bool, error)
+	// History returns the recorded revisions of the book, newest first.
+	// Every write above records one in its transaction.
+	History(ctx context.Context, id int) ([]*entities.Revision, error)
 }
 
 type AuthorRepository interface {
+	FindAll(ctx context.Context) ([]*entities.Author, error)
 	FindByID(ctx context.Context, id int) (*entities.Author, error)
+	// FindOrCreateByName returns the author named name, ignoring case, or
+	// creates one with only that name, and reports whether it was created.
+	// Concurrent calls for a new name create a single author.
+	FindOrCreateByName(ctx context.Context, name string) (*entities.Author, bool, error)
 	Create(ctx context.Context, author *entities.Author) error
+	// Update and Delete check a non-zero version like the book
+	// repository's.
+	Update(ctx context.Context, author *entities.Author) error
+	// Patch changes the author like the book repository's.
+	Patch(ctx context.Context, id, version int, apply func(author *entities.Author) error) (*entities.Author, error)
+	// Delete, Restore and Purge work like the book repository's. Purge
+	// keeps the authors still credited on a book, deleted or not.
+	Delete(ctx context.Context, id, version int) error
+	Restore(ctx context.Context, id, version int) (*entities.Author, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// History returns the recorded revisions of the author like the book
+	// repository's.
+	History(ctx context.Context, id int) ([]*entities.Revision, error)
+}
+
+// GenreRepository stores the genre taxonomy. Genres are read with their
+// book counts, which include the books of their subgenres.
+type GenreRepository interface {
+	FindAll(ctx context.Context) ([]*entities.Genre, error)
+	FindByID(ctx context.Context, id int) (*entities.Genre, error)
+	FindBySlug(ctx context.Context, slug string) (*entities.Genre, error)
+	Create(ctx context.Context, genre *entities.Genre) error
+	Update(ctx context.Context, genre *entities.Genre) error
+	Delete(ctx context.Context, id int) error
+}
+
+// TagRepository stores tags. Tags are read with their book counts.
+type TagRepository interface {
+	FindAll(ctx context.Context) ([]*entities.Tag, error)
+	FindByID(ctx context.Context, id int) (*entities.Tag, error)
+	Create(ctx context.Context, tag *entities.Tag) error
+	Update(ctx context.Context, tag *entities.Tag) error
+	Delete(ctx context.Context, id int) error
+}
+
+// ErrAuthorHasBooks is returned when deleting an author who still has books.
+var ErrAuthorHasBooks = errors.New("author still has books")
+
+// ErrAuthorDeleted is returned when restoring a book that credits a deleted
+// author.
+var ErrAuthorDeleted = errors.New("author is deleted")
+
+// ErrGenreHasSubgenres is returned when deleting a genre that still has
+// subgenres.
+var ErrGenreHasSubgenres = errors.New("genre still has subgenres")
+
+// ErrGenreCycle is returned when a genre would become its own ancestor.
+var ErrGenreCycle = errors.New("genre would be its own ancestor")
+
+// ErrVersionMismatch is returned, as an apperr.ErrConflict, when a write
+// expected another version of the row than the stored one.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// ErrHistoryIncomplete is returned, as an apperr.ErrConflict, when a book
+// or author cannot be reverted to a revision because some of the writes
+// since are not in the audit log.
+var ErrHistoryIncomplete = errors.New("history incomplete")
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+// or was issued for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type withDeletedKey struct{}
+
+// WithDeleted returns a context in which the book and author repositories
+// also find soft-deleted rows.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withDeletedKey{}, true)
+}
+
+// IncludesDeleted reports whether ctx asks for soft-deleted rows.
+func IncludesDeleted(ctx context.Context) bool {
+	include, _ := ctx.Value(withDeletedKey{}).(bool)
+	return include
+}
+
+type auditActionKey struct{}
+
+// WithAuditAction returns a context in which the writes of the book and
+// author repositories are recorded as action, like an update reverting
+// to an earlier revision.
+func WithAuditAction(ctx context.Context, action entities.AuditAction) context.Context {
+	return context.WithValue(ctx, auditActionKey{}, action)
+}
+
+// AuditActionOf returns the action set with WithAuditAction, or fallback.
+func AuditActionOf(ctx context.Context, fallback entities.AuditAction) entities.AuditAction {
+	if action, ok := ctx.Value(auditActionKey{}).(entities.AuditAction); ok {
+		return action
+	}
+	return fallback
 }
 
 type Repository struct {
 	Book   BookRepository
 	Author AuthorRepository
+	Genre  GenreRepository
+	Tag    TagRepository
 }
@@ -0,0 +1,38 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/demirbalemir/hop/Onboardingv2/internal/tracing"
+)
+
+func TestSetup_Stdout(t *testing.T) {
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	var out bytes.Buffer
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    tracing.ExporterStdout,
+		SampleRatio: 1,
+		ServiceName: "bookapi-test",
+	}, &out)
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "BookService.GetBookByID")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	assert.Contains(t, out.String(), `"Name":"BookService.GetBookByID"`)
+	assert.Contains(t, out.String(), "bookapi-test")
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "zipkin"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "unknown trace exporter")
+}